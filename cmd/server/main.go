@@ -1,17 +1,23 @@
 package main
 
 import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
 	"backend_reservation/internal/infrastructure/web/middleware"
 	"backend_reservation/internal/infrastructure/web/routes"
 	"backend_reservation/pkg/database/connection"
 	"backend_reservation/pkg/firmador"
 	"backend_reservation/pkg/logger"
+	"backend_reservation/pkg/notificador"
+	"backend_reservation/pkg/validator"
 	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,6 +36,12 @@ func main() {
 		log.Printf("advertencia: no se pudo cargar el archivo .env: %v", err)
 	}
 
+	// Comprobar las reglas de validación de los DTOs antes de atender solicitudes:
+	// una etiqueta validate mal escrita detiene el arranque en lugar de provocar un panic en una solicitud.
+	if err := validator.Comprobar(dto.Validables...); err != nil {
+		log.Fatalf("error en las reglas de validación: %v", err)
+	}
+
 	// Inicializar la conexión a la base de datos.
 	// Si ocurre un error crítico, se detiene la ejecución.
 	_, _, err = connection.GetDB()
@@ -65,6 +77,64 @@ func main() {
 	// Inicializar el logger global de la aplicación.
 	logger.InitLogger(config)
 
+	// Configuración de la agenda de citas a partir de variables de entorno.
+	// Si una variable no está definida se usan los valores por defecto del servicio.
+	services.InitCitas(services.ConfigCitas{
+		MinutosIntervalo:   envInt("APPOINTMENT_SLOT_MINUTES", 0),         // Granularidad de los horarios disponibles
+		HorasLimiteCambios: envInt("APPOINTMENT_CHANGE_CUTOFF_HOURS", -1), // Anticipación mínima para cancelar o reprogramar
+		// Exigir email y teléfono verificados para agendar
+		RequiereVerificacion: os.Getenv("APPOINTMENT_REQUIRE_VERIFIED_CONTACT") == "true",
+	})
+
+	// Duración de los tokens de sesión a partir de variables de entorno.
+	// El token de acceso es de corta duración; la sesión se mantiene con el refresh token.
+	services.InitTokens(services.ConfigTokens{
+		DuracionAcceso:  time.Duration(envInt("ACCESS_TOKEN_MINUTES", 0)) * time.Minute,
+		DuracionRefresh: time.Duration(envInt("REFRESH_TOKEN_DAYS", 0)) * 24 * time.Hour,
+		// Restablecimiento de contraseña: vigencia del token y enlace del frontend al que se agrega el token
+		DuracionRestablecimiento: time.Duration(envInt("PASSWORD_RESET_MINUTES", 0)) * time.Minute,
+		URLRestablecimiento:      os.Getenv("PASSWORD_RESET_URL"),
+	})
+
+	// Autenticación en dos pasos (TOTP). REQUIRE_ADMIN_2FA=true la vuelve obligatoria para los administradores.
+	services.InitSegundoFactor(services.ConfigSegundoFactor{
+		Emisor:           os.Getenv("TOTP_ISSUER"),
+		ObligatorioAdmin: os.Getenv("REQUIRE_ADMIN_2FA") == "true",
+	})
+
+	// Protección del login contra fuerza bruta: intentos fallidos permitidos por cuenta y por IP
+	// antes del bloqueo temporal, que se duplica en cada fallo adicional hasta el máximo.
+	services.InitAccesos(services.ConfigAccesos{
+		IntentosCuenta: envInt("LOGIN_MAX_ATTEMPTS", 0),
+		IntentosIP:     envInt("LOGIN_MAX_ATTEMPTS_PER_IP", 0),
+		BloqueoBase:    time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 0)) * time.Minute,
+		BloqueoMaximo:  time.Duration(envInt("LOGIN_LOCKOUT_MAX_MINUTES", 0)) * time.Minute,
+	})
+
+	// Proxies y balanceadores confiables (IPs o rangos CIDR separados por comas). Solo se aceptan los headers
+	// X-Forwarded-For y X-Real-IP de estas direcciones; sin configurarlos se usa la IP de la conexión.
+	if err := middleware.InitProxiesConfiables(strings.Split(os.Getenv("TRUSTED_PROXIES"), ",")); err != nil {
+		log.Fatalf("error en TRUSTED_PROXIES: %v", err)
+	}
+
+	// Tiempo que se conservan en memoria los roles de los usuarios y los permisos de los roles.
+	services.InitPermisos(services.ConfigPermisos{
+		DuracionCache: time.Duration(envInt("PERMISSION_CACHE_SECONDS", 0)) * time.Second,
+	})
+
+	// Notificador para los mensajes a los usuarios (por ejemplo el restablecimiento de contraseña).
+	// NOTIFIER=log los escribe en el log (sin el cuerpo fuera de desarrollo); NOTIFIER=file los agrega a NOTIFIER_FILE.
+	// Fuera de desarrollo NOTIFIER es obligatorio.
+	notifier, err := notificador.DesdeEntorno(logger.EsDesarrollo(os.Getenv("APP_ENV")))
+	if err != nil {
+		log.Fatalf("error al inicializar el notificador: %v", err)
+	}
+	notificador.Init(notifier)
+
+	// Eliminar periódicamente de la lista de revocados los tokens que ya expiraron.
+	detenerLimpieza := services.IniciarLimpiezaTokens(time.Duration(envInt("REVOKED_TOKENS_CLEANUP_MINUTES", 60)) * time.Minute)
+	defer detenerLimpieza() // Detiene la limpieza al cerrar el servidor.
+
 	// Obtener el puerto de escucha del servidor desde las variables de entorno.
 	port := os.Getenv("PORT")
 
@@ -83,6 +153,7 @@ func main() {
 	// 2. rateLimiter.Throttle(): Middleware de limitación de tasa que envuelve al router
 	//    - Controla la cantidad de solicitudes por IP (15 solicitudes cada 120 segundos)
 	//    - Si se excede el límite, retorna HTTP 429 (Too Many Requests) sin procesar la solicitud
+	//    - Las solicitudes con llave de API a /api/admin se limitan por llave en APIKeyMiddleware
 	// 3. middleware.Cors(): Middleware de CORS que envuelve al rate limiter
 	//    - Valida que el origen de la solicitud esté en la lista de orígenes permitidos
 	//    - Configura los headers CORS necesarios para el intercambio de recursos
@@ -113,6 +184,19 @@ func main() {
 		}
 	}()
 
+	// SIGHUP recarga las llaves de los tokens sin reiniciar el servidor (rotación de llaves).
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := firmador.RecargarLlaves(); err != nil {
+				log.Printf("error al recargar las llaves de los tokens: %v", err)
+				continue
+			}
+			log.Printf("llaves de los tokens recargadas; llave activa: %s", firmador.LlaveActiva())
+		}
+	}()
+
 	// Esperar a recibir una señal de cierre (Ctrl+C o kill).
 	<-quit
 	log.Println("Cerrando servidor...")
@@ -128,3 +212,13 @@ func main() {
 
 	log.Println("Servidor cerrado correctamente")
 }
+
+// envInt lee una variable de entorno numérica.
+// Si la variable no existe o no es un número válido, retorna el valor por defecto.
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
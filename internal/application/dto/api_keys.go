@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+// APIKey son los datos para crear una llave de API. Scopes son códigos de permisos;
+// ExpiresAt nil crea una llave sin vencimiento y RateLimit en cero usa el límite por defecto.
+type APIKey struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RateLimit int        `json:"rate_limit" validate:"min=1,max=10000"`
+}
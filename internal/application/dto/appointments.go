@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+type Appointment struct {
+	EmployeeID uint      `json:"employee_id" validate:"required"`
+	StartAt    time.Time `json:"start_at" validate:"required"`
+	ServiceIDs []uint    `json:"service_ids" validate:"required,max=20"`
+}
+
+type Reschedule struct {
+	StartAt    time.Time `json:"start_at" validate:"required"`
+	EmployeeID uint      `json:"employee_id,omitempty"`
+}
+
+// Reassign asigna una cita a otro empleado conservando su inicio
+type Reassign struct {
+	EmployeeID uint `json:"employee_id" validate:"required"`
+}
+
+type AvailabilityQuery struct {
+	Date        time.Time `json:"date" validate:"required"`
+	ServiceIDs  []uint    `json:"service_ids" validate:"required,max=20"`
+	EmployeeID  uint      `json:"employee_id,omitempty"`
+	Granularity int       `json:"granularity,omitempty" validate:"min=5,max=240"`
+}
+
+// AdminAppointment permite a un administrador agendar una cita para un usuario registrado (UserID)
+// o para un cliente sin cuenta (CustomerName y CustomerPhone).
+type AdminAppointment struct {
+	Appointment
+	UserID        uint   `json:"user_id,omitempty"`
+	CustomerName  string `json:"customer_name,omitempty" validate:"max=255"`
+	CustomerPhone string `json:"customer_phone,omitempty" validate:"phone"`
+}
+
+type AppointmentFilter struct {
+	From       time.Time `json:"from,omitzero"`
+	To         time.Time `json:"to,omitzero" validate:"gtfield=From"`
+	EmployeeID uint      `json:"employee_id,omitempty"`
+	UserID     uint      `json:"user_id,omitempty"`
+	Status     string    `json:"status,omitempty" validate:"oneof=booked cancelled rescheduled completed no_show"`
+}
@@ -1,13 +1,40 @@
 package dto
 
 type LoginDTO struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
 
+// RegisterDTO son los datos de registro. La contraseña admite hasta 72 caracteres, el máximo que considera bcrypt.
 type RegisterDTO struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Name     string `json:"name"`
-	Phone    string `json:"phone"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Phone    string `json:"phone" validate:"required,phone"`
+}
+
+type RefreshDTO struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=256"`
+}
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordDTO struct {
+	Token    string `json:"token" validate:"required,max=256"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// VerifyDTO es un código de verificación para el email o el teléfono indicado
+type VerifyDTO struct {
+	Email string `json:"email" validate:"email"`
+	Phone string `json:"phone" validate:"phone"`
+	Code  string `json:"code" validate:"required,max=16"`
+}
+
+// TwoFactorDTO es un código TOTP o de recuperación; ChallengeToken solo se usa en el segundo paso del login
+type TwoFactorDTO struct {
+	ChallengeToken string `json:"challenge_token" validate:"max=1024"`
+	Code           string `json:"code" validate:"required,max=32"`
 }
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// Closure son los datos de un cierre. Si EmployeeID es nil el cierre aplica a todo el local.
+type Closure struct {
+	EmployeeID  *uint     `json:"employee_id"`
+	Kind        string    `json:"kind" validate:"oneof=holiday vacation time_off closure"`
+	Description string    `json:"description" validate:"max=255"`
+	StartAt     time.Time `json:"start_at" validate:"required"`
+	EndAt       time.Time `json:"end_at" validate:"required,gtfield=StartAt"`
+}
+
+// ClosureUpdate son los datos de la actualización parcial de un cierre. Los campos nil no se modifican;
+// EmployeeID 0 convierte el cierre en uno de todo el local.
+type ClosureUpdate struct {
+	EmployeeID  *uint      `json:"employee_id"`
+	Kind        *string    `json:"kind" validate:"notblank,oneof=holiday vacation time_off closure"`
+	Description *string    `json:"description" validate:"max=255"`
+	StartAt     *time.Time `json:"start_at"`
+	EndAt       *time.Time `json:"end_at"`
+}
+
+// ClosureFilter filtra los cierres por rango de fechas y empleado.
+// Si EmployeeID es distinto de cero se incluyen también los cierres de todo el local.
+type ClosureFilter struct {
+	From       time.Time
+	To         time.Time
+	EmployeeID uint
+}
@@ -0,0 +1,9 @@
+package dto
+
+// Day son los datos de un día de atención. StartAt y EndAt usan el formato HH:MM.
+type Day struct {
+	Code        string `json:"code,omitempty" validate:"required,oneof=domingo lunes martes miercoles jueves viernes sabado"`
+	Description string `json:"description,omitempty" validate:"required,max=255"`
+	StartAt     string `json:"start_at,omitempty" validate:"required,hhmm"`
+	EndAt       string `json:"end_at,omitempty" validate:"required,hhmm,gtfield=StartAt"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type EmployeeService struct {
+	EstimatedTime *uint `json:"estimated_time,omitempty" validate:"min=1,max=1440"`
+}
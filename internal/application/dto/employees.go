@@ -0,0 +1,6 @@
+package dto
+
+type Employee struct {
+	Name   string `json:"name,omitempty" validate:"required,max=255"`
+	RoleID uint   `json:"role_id,omitempty"`
+}
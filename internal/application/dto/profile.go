@@ -0,0 +1,24 @@
+package dto
+
+// UpdateProfileDTO son los datos del perfil que el usuario puede editar. Los campos nil no se modifican.
+type UpdateProfileDTO struct {
+	Name  *string `json:"name" validate:"notblank,max=255"`
+	Phone *string `json:"phone" validate:"notblank,phone"`
+}
+
+// ChangeEmailDTO solicita el cambio de email; se exige la contraseña actual
+type ChangeEmailDTO struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ChangePasswordDTO cambia la contraseña del usuario; se exige la contraseña actual
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// DeleteAccountDTO confirma la eliminación de la cuenta con la contraseña actual
+type DeleteAccountDTO struct {
+	Password string `json:"password" validate:"required"`
+}
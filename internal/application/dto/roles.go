@@ -0,0 +1,8 @@
+package dto
+
+// Role son los datos de un rol. Permissions son los códigos de los permisos que se le otorgan al crearlo.
+type Role struct {
+	Code        string   `json:"code,omitempty" validate:"required,min=2,max=50"`
+	Description string   `json:"description,omitempty" validate:"required,max=255"`
+	Permissions []string `json:"permissions,omitempty"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// ScheduleBlock es un turno, descanso o día libre. StartTime y EndTime usan el formato HH:MM.
+type ScheduleBlock struct {
+	Weekday   time.Weekday `json:"weekday,omitempty" validate:"min=0,max=6"`
+	Kind      string       `json:"kind" validate:"required,oneof=shift break off"`
+	StartTime string       `json:"start_time,omitempty" validate:"hhmm"`
+	EndTime   string       `json:"end_time,omitempty" validate:"hhmm,gtfield=StartTime"`
+}
+
+type WeeklySchedule struct {
+	Blocks []ScheduleBlock `json:"blocks"`
+}
+
+// ScheduleOverride reemplaza el horario de un empleado en la fecha indicada (YYYY-MM-DD)
+type ScheduleOverride struct {
+	Date   string          `json:"date" validate:"required,date"`
+	Blocks []ScheduleBlock `json:"blocks"`
+}
@@ -1,8 +1,8 @@
 package dto
 
 type Service struct {
-	Code          string `json:"code,omitempty"`
-	Name          string `json:"name,omitempty"`
-	EstimatedTime uint   `json:"estimated_time,omitempty"`
+	Code          string `json:"code,omitempty" validate:"required,max=50"`
+	Name          string `json:"name,omitempty" validate:"required,max=255"`
+	EstimatedTime uint   `json:"estimated_time,omitempty" validate:"required,min=1,max=1440"`
 	Status        bool   `json:"status,omitempty"`
 }
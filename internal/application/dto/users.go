@@ -0,0 +1,29 @@
+package dto
+
+// UserFilter filtra y pagina el listado de usuarios.
+// Search busca en nombre, email y teléfono; Status puede ser active, suspended o deleted.
+type UserFilter struct {
+	Search  string
+	Name    string
+	Email   string
+	Phone   string
+	Role    string
+	Status  string
+	Page    int
+	PerPage int
+}
+
+// ChangeRole asigna otro rol a un usuario
+type ChangeRole struct {
+	RoleID uint `json:"role_id" validate:"required"`
+}
+
+// Suspension suspende la cuenta de un usuario; Reason es opcional y se muestra en la administración
+type Suspension struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// ContactVerification marca como verificado el email o el teléfono de un usuario desde la administración
+type ContactVerification struct {
+	Channel string `json:"channel" validate:"required,oneof=email phone"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+// Validables son los DTOs con reglas en la etiqueta validate. Sus etiquetas se comprueban al iniciar
+// el servidor (ver validator.Comprobar); al agregar reglas a un DTO nuevo hay que incluirlo aquí.
+var Validables = []any{
+	APIKey{},
+	Appointment{},
+	Reschedule{},
+	Reassign{},
+	AvailabilityQuery{},
+	AdminAppointment{},
+	AppointmentFilter{},
+	LoginDTO{},
+	RegisterDTO{},
+	RefreshDTO{},
+	ForgotPasswordDTO{},
+	ResetPasswordDTO{},
+	VerifyDTO{},
+	TwoFactorDTO{},
+	Closure{},
+	ClosureUpdate{},
+	Day{},
+	EmployeeService{},
+	Employee{},
+	UpdateProfileDTO{},
+	ChangeEmailDTO{},
+	ChangePasswordDTO{},
+	DeleteAccountDTO{},
+	Role{},
+	ScheduleBlock{},
+	ScheduleOverride{},
+	Service{},
+	ChangeRole{},
+	Suspension{},
+	ContactVerification{},
+}
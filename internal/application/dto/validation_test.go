@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"backend_reservation/pkg/validator"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidablesComprobados(t *testing.T) {
+	if err := validator.Comprobar(Validables...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestValidablesCompletos verifica que todos los DTOs con etiquetas validate estén en Validables
+func TestValidablesCompletos(t *testing.T) {
+	registrados := make(map[string]bool, len(Validables))
+	for _, validable := range Validables {
+		registrados[reflect.TypeOf(validable).Name()] = true
+	}
+
+	paquetes, err := parser.ParseDir(token.NewFileSet(), ".", func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, paquete := range paquetes {
+		for _, archivo := range paquete.Files {
+			ast.Inspect(archivo, func(nodo ast.Node) bool {
+				tipo, ok := nodo.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				estructura, ok := tipo.Type.(*ast.StructType)
+				if !ok {
+					return false
+				}
+				for _, campo := range estructura.Fields.List {
+					if campo.Tag != nil && strings.Contains(campo.Tag.Value, `validate:"`) && !registrados[tipo.Name.Name] {
+						t.Errorf("%s tiene reglas de validación y no está en Validables", tipo.Name.Name)
+						break
+					}
+				}
+				return false
+			})
+		}
+	}
+}
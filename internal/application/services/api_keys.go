@@ -0,0 +1,229 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// prefijoLlaveAPI identifica las llaves de API emitidas por el sistema
+	prefijoLlaveAPI = "rk_"
+	// limiteLlaveAPIPorDefecto es el máximo de solicitudes por minuto de una llave sin límite propio
+	limiteLlaveAPIPorDefecto = 60
+	// limiteLlaveAPIMaximo es el máximo de solicitudes por minuto que se puede asignar a una llave
+	limiteLlaveAPIMaximo = 10000
+)
+
+// scopesNoPermitidos son los permisos que no se otorgan a las llaves de API,
+// para que una integración no pueda ampliar sus propios permisos ni los de otros
+var scopesNoPermitidos = []string{
+	models.PermisoRolesEscribir,
+	models.PermisoLlavesAPILeer,
+	models.PermisoLlavesAPIEscribir,
+}
+
+// ErrLlaveAPIInvalida indica que la llave de API no existe, fue revocada o expiró
+var ErrLlaveAPIInvalida = errors.New("llave de API no válida")
+
+// ObtenerLlavesAPI lista las llaves de API, de la más reciente a la más antigua
+func ObtenerLlavesAPI() ([]models.APIKey, error) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var llaves []models.APIKey
+	if err := gormDB.Preload("CreatedBy").Order("created_at DESC").Find(&llaves).Error; err != nil {
+		return nil, err
+	}
+
+	return llaves, nil
+}
+
+// ObtenerLlaveAPI retorna una llave de API
+func ObtenerLlaveAPI(id uint) (*models.APIKey, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	llave, err := buscarLlaveAPI(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return llave, nil, 200
+}
+
+// CrearLlaveAPI crea una llave de API con los permisos indicados, que deben estar entre los del administrador
+// que la crea para que una llave no otorgue más de lo que tiene su responsable.
+// Retorna la llave guardada y la llave en texto plano, que solo se muestra en este momento.
+func CrearLlaveAPI(adminID uint, llaveDto *dto.APIKey) (*models.APIKey, string, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, "", err, 500
+	}
+
+	nombre := strings.TrimSpace(llaveDto.Name)
+	if nombre == "" {
+		return nil, "", errors.New("el nombre de la llave es obligatorio"), 400
+	}
+	if len([]rune(nombre)) > 100 {
+		return nil, "", errors.New("el nombre de la llave no puede superar los 100 caracteres"), 400
+	}
+
+	if llaveDto.ExpiresAt != nil && !llaveDto.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.New("la fecha de expiración debe ser futura"), 400
+	}
+
+	limite := llaveDto.RateLimit
+	if limite == 0 {
+		limite = limiteLlaveAPIPorDefecto
+	}
+	if limite < 1 || limite > limiteLlaveAPIMaximo {
+		return nil, "", errors.New("el límite de solicitudes por minuto debe estar entre 1 y 10000"), 400
+	}
+
+	scopes := []string{}
+	for _, scope := range llaveDto.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || slices.Contains(scopes, scope) {
+			continue
+		}
+		if slices.Contains(scopesNoPermitidos, scope) {
+			return nil, "", errors.New("el permiso no puede otorgarse a una llave de API: " + scope), 400
+		}
+		if _, err := buscarPermiso(gormDB, scope); err != nil {
+			return nil, "", err, codigoError(err)
+		}
+		propio, err := TienePermiso(adminID, scope)
+		if err != nil {
+			return nil, "", err, codigoError(err)
+		}
+		if !propio {
+			return nil, "", errors.New("no puede otorgar a una llave un permiso que no tiene: " + scope), 403
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.New("debe indicar al menos un permiso para la llave"), 400
+	}
+	slices.Sort(scopes)
+
+	aleatorio, err := generarTokenAleatorio()
+	if err != nil {
+		return nil, "", err, 500
+	}
+	clave := prefijoLlaveAPI + aleatorio
+
+	llave := models.APIKey{
+		Name:        nombre,
+		Prefix:      clave[:len(prefijoLlaveAPI)+8],
+		KeyHash:     hashToken(clave),
+		Scopes:      scopes,
+		RateLimit:   limite,
+		ExpiresAt:   llaveDto.ExpiresAt,
+		CreatedByID: adminID,
+	}
+	if err := gormDB.Omit(clause.Associations).Create(&llave).Error; err != nil {
+		return nil, "", errors.New("No se pudo crear la llave de API"), 500
+	}
+
+	creada, err := buscarLlaveAPI(gormDB, llave.ID)
+	if err != nil {
+		return nil, "", err, codigoError(err)
+	}
+
+	return creada, clave, nil, 201
+}
+
+// RevocarLlaveAPI revoca una llave de API; deja de aceptarse de inmediato
+func RevocarLlaveAPI(id uint) (*models.APIKey, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	llave, err := buscarLlaveAPI(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if llave.RevokedAt != nil {
+		return nil, errors.New("la llave ya está revocada"), 409
+	}
+
+	ahora := time.Now()
+	if err := gormDB.Model(llave).Update("revoked_at", ahora).Error; err != nil {
+		return nil, errors.New("No se pudo revocar la llave de API"), 500
+	}
+	llave.RevokedAt = &ahora
+
+	return llave, nil, 200
+}
+
+// AutenticarLlaveAPI busca la llave de API vigente que corresponde a la clave recibida
+// y registra su último uso, como máximo una vez por intervaloActividad.
+// La llave deja de aceptarse mientras la cuenta de su creador esté suspendida o eliminada,
+// ya que las acciones hechas con ella se le atribuyen.
+func AutenticarLlaveAPI(clave, ip string) (*models.APIKey, error) {
+	if !strings.HasPrefix(clave, prefijoLlaveAPI) {
+		return nil, ErrLlaveAPIInvalida
+	}
+
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var llave models.APIKey
+	if err := gormDB.Where("key_hash = ?", hashToken(clave)).First(&llave).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLlaveAPIInvalida
+		}
+		return nil, err
+	}
+
+	if !llave.Vigente() {
+		return nil, ErrLlaveAPIInvalida
+	}
+
+	if err := VerificarCuentaActiva(llave.CreatedByID); err != nil {
+		if errors.Is(err, ErrCuentaSuspendida) || codigoError(err) == 404 {
+			return nil, ErrLlaveAPIInvalida
+		}
+		return nil, err
+	}
+
+	ahora := time.Now()
+	if llave.LastUsedAt == nil || ahora.Sub(*llave.LastUsedAt) >= intervaloActividad || llave.LastUsedIP != ip {
+		err := gormDB.Model(&llave).UpdateColumns(map[string]any{
+			"last_used_at": ahora,
+			"last_used_ip": recortar(ip, 64),
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &llave, nil
+}
+
+// buscarLlaveAPI busca una llave de API por su ID junto con el administrador que la creó
+func buscarLlaveAPI(tx *gorm.DB, id uint) (*models.APIKey, error) {
+	var llave models.APIKey
+	if err := tx.Preload("CreatedBy").First(&llave, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "llave de API no encontrada")
+		}
+		return nil, err
+	}
+	return &llave, nil
+}
@@ -1 +1,630 @@
 package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConfigCitas agrupa los parámetros configurables de la agenda de citas.
+type ConfigCitas struct {
+	// MinutosIntervalo es la granularidad por defecto, en minutos, de las horas de inicio disponibles.
+	MinutosIntervalo int
+	// HorasLimiteCambios es la anticipación mínima, en horas, para cancelar o reprogramar una cita.
+	HorasLimiteCambios int
+	// RequiereVerificacion impide que los usuarios agenden citas hasta verificar su email y su teléfono.
+	RequiereVerificacion bool
+}
+
+var configCitas = ConfigCitas{
+	MinutosIntervalo:   15,
+	HorasLimiteCambios: 24,
+}
+
+// InitCitas establece la configuración de la agenda de citas.
+// Los valores fuera de rango se ignoran y se conservan los valores por defecto.
+func InitCitas(cfg ConfigCitas) {
+	if cfg.MinutosIntervalo > 0 {
+		configCitas.MinutosIntervalo = cfg.MinutosIntervalo
+	}
+	if cfg.HorasLimiteCambios >= 0 {
+		configCitas.HorasLimiteCambios = cfg.HorasLimiteCambios
+	}
+	configCitas.RequiereVerificacion = cfg.RequiereVerificacion
+}
+
+// CrearCita registra una cita para el usuario autenticado.
+// Recibe el ID del usuario y un puntero a dto.Appointment con el empleado, la hora de inicio y los servicios.
+// Retorna la cita creada (con sus servicios cargados), un error si ocurre algún problema y el código HTTP asociado.
+// Si la configuración lo exige, el usuario debe tener verificados su email y su teléfono.
+func CrearCita(userID uint, citaDto *dto.Appointment) (*models.Appointment, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if configCitas.RequiereVerificacion {
+		user, err := buscarUsuario(gormDB, userID)
+		if err != nil {
+			return nil, err, codigoError(err)
+		}
+		if !user.EmailVerificado() || !user.TelefonoVerificado() {
+			return nil, errors.New("debe verificar su email y su teléfono antes de agendar citas"), 403
+		}
+	}
+
+	return registrarCita(gormDB, citaDto, models.Appointment{UserID: &userID})
+}
+
+// CrearCitaAdmin registra una cita en nombre de un cliente.
+// Si se indica UserID la cita se asocia a ese usuario; en caso contrario se trata de un cliente sin cuenta
+// y son obligatorios CustomerName y CustomerPhone.
+func CrearCitaAdmin(citaDto *dto.AdminAppointment) (*models.Appointment, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	cliente := models.Appointment{
+		CustomerName:  strings.TrimSpace(citaDto.CustomerName),
+		CustomerPhone: strings.TrimSpace(citaDto.CustomerPhone),
+	}
+
+	if citaDto.UserID != 0 {
+		var user models.User
+		if err := gormDB.First(&user, citaDto.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("usuario no encontrado"), 404
+			}
+			return nil, err, 500
+		}
+		cliente.UserID = &user.ID
+	} else if cliente.CustomerName == "" || cliente.CustomerPhone == "" {
+		return nil, errors.New("debe indicar un usuario o el nombre y teléfono del cliente"), 400
+	}
+
+	return registrarCita(gormDB, &citaDto.Appointment, cliente)
+}
+
+// registrarCita crea una cita para el cliente indicado en cita (usuario o datos del cliente sin cuenta).
+//
+// El proceso es el siguiente:
+// 1. Valida que haya al menos un servicio y que la cita no sea en el pasado.
+// 2. Dentro de una transacción obtiene los servicios activos, verifica que el empleado esté habilitado para todos
+// y suma su tiempo estimado (con los tiempos propios del empleado) para calcular EndAt.
+// 3. Verifica el horario solicitado con reservarHorario (empleado activo, cierres, horario de atención y solapamientos).
+// 4. Crea la cita y los registros de AppointmentService.
+func registrarCita(gormDB *gorm.DB, citaDto *dto.Appointment, cita models.Appointment) (*models.Appointment, error, int) {
+	if len(citaDto.ServiceIDs) == 0 {
+		return nil, errors.New("debe seleccionar al menos un servicio"), 400
+	}
+
+	if citaDto.StartAt.IsZero() {
+		return nil, errors.New("la fecha de inicio es obligatoria"), 400
+	}
+
+	if citaDto.StartAt.Before(time.Now()) {
+		return nil, errors.New("no se puede agendar una cita en el pasado"), 400
+	}
+
+	err := gormDB.Transaction(func(tx *gorm.DB) error {
+		servicios, err := obtenerServiciosCita(tx, citaDto.ServiceIDs)
+		if err != nil {
+			return err
+		}
+
+		duracion, err := duracionEmpleado(tx, citaDto.EmployeeID, servicios)
+		if err != nil {
+			return err
+		}
+
+		inicio := citaDto.StartAt
+		fin := inicio.Add(duracion)
+
+		dia, err := reservarHorario(tx, citaDto.EmployeeID, inicio, fin, 0)
+		if err != nil {
+			return err
+		}
+
+		cita.StartAt = inicio
+		cita.EndAt = fin
+		cita.Status = models.CitaReservada
+		cita.DayID = dia.ID
+		cita.EmployeeID = citaDto.EmployeeID
+
+		if err := tx.Omit(clause.Associations).Create(&cita).Error; err != nil {
+			if esSolapamientoBD(err) {
+				return err
+			}
+			return errors.New("error al crear la cita")
+		}
+
+		cita.AppointmentServices = make([]models.AppointmentService, len(servicios))
+		for i, servicio := range servicios {
+			cita.AppointmentServices[i] = models.AppointmentService{
+				ServiceID:     servicio.ID,
+				AppointmentID: cita.ID,
+			}
+		}
+
+		if err := tx.Omit(clause.Associations).Create(&cita.AppointmentServices).Error; err != nil {
+			return errors.New("error al registrar los servicios de la cita")
+		}
+
+		for i := range cita.AppointmentServices {
+			cita.AppointmentServices[i].Service = servicios[i]
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if esSolapamientoBD(err) {
+			err = conflictoDesdeBD(gormDB, citaDto.EmployeeID, citaDto.StartAt, cita.EndAt, 0)
+		}
+		return nil, err, codigoError(err)
+	}
+
+	return &cita, nil, 201
+}
+
+// CancelarCita cancela una cita del usuario autenticado.
+// Solo se pueden cancelar citas reservadas o reprogramadas y con la anticipación mínima configurada.
+// La cita no se elimina: se marca como cancelada y deja de ocupar el horario del empleado.
+func CancelarCita(userID uint, citaID uint) (*models.Appointment, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var cita *models.Appointment
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		cita, err = bloquearCitaUsuario(tx, userID, citaID)
+		if err != nil {
+			return err
+		}
+
+		ahora := time.Now()
+		cita.Status = models.CitaCancelada
+		cita.CancelledAt = &ahora
+
+		if err := tx.Omit(clause.Associations).Save(cita).Error; err != nil {
+			return errors.New("no se pudo cancelar la cita")
+		}
+
+		return cargarServiciosCita(tx, cita)
+	})
+
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return cita, nil, 200
+}
+
+// ReprogramarCita cambia la fecha de inicio de una cita del usuario autenticado (ver moverCita).
+// Recibe el ID del usuario, el ID de la cita y un puntero a dto.Reschedule con la nueva fecha (y opcionalmente otro empleado).
+// Retorna la cita reprogramada, un error si ocurre algún problema y el código HTTP asociado.
+//
+// El proceso es el siguiente:
+// 1. Bloquea la cita y verifica que pertenezca al usuario, que sea modificable y que se respete la anticipación mínima.
+// 2. Verifica que el empleado esté habilitado para los servicios de la cita y recalcula el fin con su duración.
+// 3. Verifica el nuevo horario con reservarHorario, ignorando la propia cita en la verificación de solapamientos.
+// 4. Guarda el horario anterior en AppointmentHistory y actualiza la cita con el estado rescheduled.
+//
+// Todo ocurre en una sola transacción, por lo que la cita nunca queda sin horario ni con uno inválido.
+func ReprogramarCita(userID uint, citaID uint, reprogramarDto *dto.Reschedule) (*models.Appointment, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if reprogramarDto.StartAt.IsZero() {
+		return nil, errors.New("la nueva fecha de inicio es obligatoria"), 400
+	}
+
+	if reprogramarDto.StartAt.Before(time.Now()) {
+		return nil, errors.New("no se puede reprogramar una cita al pasado"), 400
+	}
+
+	var cita *models.Appointment
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		cita, err = bloquearCitaUsuario(tx, userID, citaID)
+		if err != nil {
+			return err
+		}
+
+		if err := moverCita(tx, cita, reprogramarDto.StartAt, reprogramarDto.EmployeeID, userID); err != nil {
+			return err
+		}
+
+		return cargarServiciosCita(tx, cita)
+	})
+
+	if err != nil {
+		if esSolapamientoBD(err) {
+			err = conflictoDesdeBD(gormDB, cita.EmployeeID, cita.StartAt, cita.EndAt, cita.ID)
+		}
+		return nil, err, codigoError(err)
+	}
+
+	return cita, nil, 200
+}
+
+// moverCita asigna un nuevo inicio (y opcionalmente otro empleado) a una cita ya bloqueada, verificando que
+// el empleado esté habilitado para sus servicios y el horario, y registrando el horario anterior en el historial.
+// El fin se recalcula con la duración de los servicios para el empleado que queda asignado.
+// Si empleadoID es 0 se conserva el empleado actual. La cita pasa a estado rescheduled solo si cambia su inicio.
+func moverCita(tx *gorm.DB, cita *models.Appointment, inicio time.Time, empleadoID uint, cambiadoPor uint) error {
+	if empleadoID == 0 {
+		empleadoID = cita.EmployeeID
+	}
+
+	servicios, err := serviciosReservados(tx, cita.ID)
+	if err != nil {
+		return err
+	}
+
+	duracion, err := duracionEmpleado(tx, empleadoID, servicios)
+	if err != nil {
+		return err
+	}
+	fin := inicio.Add(duracion)
+
+	dia, err := reservarHorario(tx, empleadoID, inicio, fin, cita.ID)
+	if err != nil {
+		return err
+	}
+
+	historial := models.AppointmentHistory{
+		AppointmentID:      cita.ID,
+		PreviousStartAt:    cita.StartAt,
+		PreviousEndAt:      cita.EndAt,
+		PreviousEmployeeID: cita.EmployeeID,
+		StartAt:            inicio,
+		EndAt:              fin,
+		EmployeeID:         empleadoID,
+		ChangedByID:        cambiadoPor,
+	}
+
+	if err := tx.Omit(clause.Associations).Create(&historial).Error; err != nil {
+		return errors.New("no se pudo registrar el historial de la cita")
+	}
+
+	if !inicio.Equal(cita.StartAt) {
+		cita.Status = models.CitaReprogramada
+	}
+	cita.StartAt = inicio
+	cita.EndAt = fin
+	cita.DayID = dia.ID
+	cita.EmployeeID = empleadoID
+
+	if err := tx.Omit(clause.Associations).Save(cita).Error; err != nil {
+		if esSolapamientoBD(err) {
+			return err
+		}
+		return errors.New("no se pudo reprogramar la cita")
+	}
+
+	return nil
+}
+
+// ObtenerCitas lista las citas aplicando los filtros indicados (rango de fechas, empleado, usuario y estado).
+// Las citas se devuelven ordenadas por fecha de inicio con sus servicios, empleado y usuario cargados.
+func ObtenerCitas(filtro *dto.AppointmentFilter) ([]models.Appointment, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if filtro.Status != "" && !models.EstadoCitaValido(filtro.Status) {
+		return nil, errors.New("estado de cita no válido"), 400
+	}
+
+	if !filtro.From.IsZero() && !filtro.To.IsZero() && filtro.To.Before(filtro.From) {
+		return nil, errors.New("la fecha final debe ser posterior a la inicial"), 400
+	}
+
+	query := gormDB.Preload("AppointmentServices.Service").Preload("Employee").Preload("User").Order("start_at")
+
+	if !filtro.From.IsZero() {
+		query = query.Where("start_at >= ?", filtro.From)
+	}
+	if !filtro.To.IsZero() {
+		query = query.Where("start_at < ?", filtro.To)
+	}
+	if filtro.EmployeeID != 0 {
+		query = query.Where("employee_id = ?", filtro.EmployeeID)
+	}
+	if filtro.UserID != 0 {
+		query = query.Where("user_id = ?", filtro.UserID)
+	}
+	if filtro.Status != "" {
+		query = query.Where("status = ?", filtro.Status)
+	}
+
+	var citas []models.Appointment
+	if err := query.Find(&citas).Error; err != nil {
+		return nil, err, 500
+	}
+
+	return citas, nil, 200
+}
+
+// ReasignarCita asigna la cita a otro empleado conservando su inicio (ver moverCita).
+// Se verifican el horario del nuevo empleado y sus solapamientos, y el cambio queda en el historial.
+func ReasignarCita(adminID uint, citaID uint, empleadoID uint) (*models.Appointment, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if empleadoID == 0 {
+		return nil, errors.New("el empleado es obligatorio"), 400
+	}
+
+	var cita *models.Appointment
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		cita, err = bloquearCita(tx, citaID)
+		if err != nil {
+			return err
+		}
+
+		if cita.EmployeeID == empleadoID {
+			return nuevoError(400, "la cita ya está asignada a ese empleado")
+		}
+
+		if err := moverCita(tx, cita, cita.StartAt, empleadoID, adminID); err != nil {
+			return err
+		}
+
+		return cargarServiciosCita(tx, cita)
+	})
+
+	if err != nil {
+		if esSolapamientoBD(err) {
+			err = conflictoDesdeBD(gormDB, empleadoID, cita.StartAt, cita.EndAt, cita.ID)
+		}
+		return nil, err, codigoError(err)
+	}
+
+	return cita, nil, 200
+}
+
+// MarcarCita registra el resultado de una cita: completada o cliente no asistió.
+// Solo se pueden marcar citas reservadas o reprogramadas cuya hora de inicio ya pasó.
+func MarcarCita(citaID uint, estado string) (*models.Appointment, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if estado != models.CitaCompletada && estado != models.CitaNoAsistio {
+		return nil, errors.New("estado de cita no válido"), 400
+	}
+
+	var cita *models.Appointment
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		cita, err = bloquearCita(tx, citaID)
+		if err != nil {
+			return err
+		}
+
+		if cita.StartAt.After(time.Now()) {
+			return nuevoError(400, "la cita todavía no ha comenzado")
+		}
+
+		cita.Status = estado
+		if err := tx.Omit(clause.Associations).Save(cita).Error; err != nil {
+			return errors.New("no se pudo actualizar el estado de la cita")
+		}
+
+		return cargarServiciosCita(tx, cita)
+	})
+
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return cita, nil, 200
+}
+
+// bloquearCita obtiene una cita modificable bloqueando su fila hasta el final de la transacción.
+// A diferencia de bloquearCitaUsuario no verifica el dueño ni la anticipación mínima (uso administrativo).
+func bloquearCita(tx *gorm.DB, citaID uint) (*models.Appointment, error) {
+	var cita models.Appointment
+	err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&cita, citaID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "cita no encontrada")
+		}
+		return nil, err
+	}
+
+	if !cita.Modificable() {
+		return nil, nuevoError(409, fmt.Sprintf("la cita no puede modificarse porque su estado es %s", cita.Status))
+	}
+
+	return &cita, nil
+}
+
+// bloquearCitaUsuario obtiene una cita del usuario bloqueando su fila hasta el final de la transacción,
+// y verifica que todavía pueda modificarse según su estado y la anticipación mínima configurada.
+func bloquearCitaUsuario(tx *gorm.DB, userID uint, citaID uint) (*models.Appointment, error) {
+	var cita models.Appointment
+	err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("user_id = ?", userID).
+		First(&cita, citaID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "cita no encontrada")
+		}
+		return nil, err
+	}
+
+	if !cita.Modificable() {
+		return nil, nuevoError(409, fmt.Sprintf("la cita no puede modificarse porque su estado es %s", cita.Status))
+	}
+
+	limite := time.Duration(configCitas.HorasLimiteCambios) * time.Hour
+	if time.Until(cita.StartAt) < limite {
+		return nil, nuevoError(403, fmt.Sprintf("las citas solo pueden modificarse con al menos %d horas de anticipación", configCitas.HorasLimiteCambios))
+	}
+
+	return &cita, nil
+}
+
+// reservarHorario verifica que el empleado pueda atender en el rango [inicio, fin) y retorna el día de atención.
+//
+// El proceso es el siguiente:
+// 1. Bloquea al empleado (SELECT ... FOR UPDATE) y verifica que esté activo.
+// 2. Busca el día activo que corresponde a la fecha, verifica que el rango no caiga en un cierre del local o del empleado
+// y que quede dentro del horario del empleado.
+// 3. Verifica que el empleado no tenga otra cita vigente que se cruce con el rango (ignorando excluirID).
+//
+// El bloqueo del empleado evita que dos reservas simultáneas pasen la verificación de solapamiento a la vez.
+// Como respaldo, la restricción de exclusión de la base de datos rechaza cualquier solapamiento que llegue a guardarse.
+func reservarHorario(tx *gorm.DB, empleadoID uint, inicio, fin time.Time, excluirID uint) (*models.Day, error) {
+	empleado, err := bloquearEmpleado(tx, empleadoID)
+	if err != nil {
+		return nil, err
+	}
+
+	dia, err := obtenerDia(tx, inicio)
+	if err != nil {
+		return nil, err
+	}
+
+	ventanas, err := horarioEmpleado(tx, dia, empleado.ID, inicio)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verificarCierres(tx, empleado.ID, inicio, fin); err != nil {
+		return nil, err
+	}
+
+	if !contieneIntervalo(ventanas, inicio, fin) {
+		return nil, nuevoError(400, "la cita está fuera del horario de atención")
+	}
+
+	if err := verificarSolapamiento(tx, empleado.ID, inicio, fin, excluirID); err != nil {
+		return nil, err
+	}
+
+	return dia, nil
+}
+
+// obtenerServiciosCita obtiene los servicios activos con los IDs indicados, ignorando duplicados.
+// Retorna un error si alguno de los servicios no existe o está inactivo.
+func obtenerServiciosCita(tx *gorm.DB, ids []uint) ([]models.Service, error) {
+	unicos := make([]uint, 0, len(ids))
+	vistos := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if !vistos[id] {
+			vistos[id] = true
+			unicos = append(unicos, id)
+		}
+	}
+
+	var servicios []models.Service
+	if err := tx.Scopes(models.ActiveService).Where("id IN ?", unicos).Find(&servicios).Error; err != nil {
+		return nil, err
+	}
+
+	if len(servicios) != len(unicos) {
+		return nil, nuevoError(400, "uno o más servicios no existen o están inactivos")
+	}
+
+	return servicios, nil
+}
+
+// serviciosReservados retorna los servicios de una cita, incluidos los que se desactivaron o eliminaron
+// después de reservarla.
+func serviciosReservados(tx *gorm.DB, citaID uint) ([]models.Service, error) {
+	var servicios []models.Service
+	err := tx.Unscoped().
+		Where("id IN (?)", tx.Model(&models.AppointmentService{}).Select("service_id").Where("appointment_id = ?", citaID)).
+		Find(&servicios).Error
+	return servicios, err
+}
+
+// cargarServiciosCita carga los servicios de la cita para la respuesta, incluidos los que se desactivaron
+// o eliminaron después de reservarla.
+func cargarServiciosCita(tx *gorm.DB, cita *models.Appointment) error {
+	return tx.Preload("Service", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("appointment_id = ?", cita.ID).
+		Find(&cita.AppointmentServices).Error
+}
+
+// bloquearEmpleado obtiene el empleado activo con el ID indicado bloqueando su fila hasta el final de la transacción.
+func bloquearEmpleado(tx *gorm.DB, empleadoID uint) (*models.Employee, error) {
+	var empleado models.Employee
+	err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Scopes(models.EmpleadoActivo).
+		First(&empleado, empleadoID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "empleado no encontrado o inactivo")
+		}
+		return nil, err
+	}
+	return &empleado, nil
+}
+
+// citaEnConflicto busca una cita vigente del empleado que se cruce con el rango [inicio, fin).
+// excluirID permite ignorar la propia cita cuando se está modificando una existente.
+// Retorna nil si no hay conflicto.
+func citaEnConflicto(tx *gorm.DB, empleadoID uint, inicio, fin time.Time, excluirID uint) (*models.Appointment, error) {
+	query := tx.Scopes(models.CitaVigente).
+		Where("employee_id = ? AND start_at < ? AND end_at > ?", empleadoID, fin, inicio)
+	if excluirID != 0 {
+		query = query.Where("id <> ?", excluirID)
+	}
+
+	var cita models.Appointment
+	if err := query.Order("start_at").First(&cita).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cita, nil
+}
+
+// verificarSolapamiento retorna un ConflictoCitaError si el empleado ya tiene una cita en el rango [inicio, fin).
+func verificarSolapamiento(tx *gorm.DB, empleadoID uint, inicio, fin time.Time, excluirID uint) error {
+	conflicto, err := citaEnConflicto(tx, empleadoID, inicio, fin, excluirID)
+	if err != nil {
+		return err
+	}
+
+	if conflicto != nil {
+		return &ConflictoCitaError{
+			AppointmentID: conflicto.ID,
+			StartAt:       conflicto.StartAt,
+			EndAt:         conflicto.EndAt,
+		}
+	}
+
+	return nil
+}
+
+// conflictoDesdeBD construye el error de conflicto cuando la restricción de exclusión rechazó la cita.
+// Como la transacción ya fue abortada, la cita que provoca el conflicto se busca fuera de ella.
+func conflictoDesdeBD(gormDB *gorm.DB, empleadoID uint, inicio, fin time.Time, excluirID uint) error {
+	if err := verificarSolapamiento(gormDB, empleadoID, inicio, fin, excluirID); err != nil {
+		return err
+	}
+	return nuevoError(409, "el empleado ya tiene una cita en ese horario")
+}
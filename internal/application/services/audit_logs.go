@@ -0,0 +1,28 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+
+	"gorm.io/gorm"
+)
+
+// registrarAuditoria guarda una acción sobre la cuenta del usuario. actorID es quien la realizó;
+// cero indica que la realizó el sistema (por ejemplo, un restablecimiento de contraseña por token).
+func registrarAuditoria(tx *gorm.DB, userID, actorID uint, accion, detalle string) error {
+	registro := models.AuditLog{
+		UserID: userID,
+		Action: accion,
+		Detail: recortar(detalle, 255),
+	}
+	if actorID != 0 {
+		registro.ActorID = &actorID
+	}
+	return tx.Create(&registro).Error
+}
+
+// obtenerAuditoria lista las acciones registradas sobre la cuenta del usuario, de la más reciente a la más antigua
+func obtenerAuditoria(tx *gorm.DB, userID uint) ([]models.AuditLog, error) {
+	var registros []models.AuditLog
+	err := tx.Where("user_id = ?", userID).Order("created_at DESC").Find(&registros).Error
+	return registros, err
+}
@@ -5,35 +5,63 @@ import (
 	"backend_reservation/pkg/database/models"
 	"backend_reservation/pkg/utils"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrCredencialesInvalidas es el error único de login para emails inexistentes y contraseñas incorrectas,
+// de modo que la respuesta no revele qué cuentas existen.
+var ErrCredencialesInvalidas = errors.New("credenciales inválidas")
+
 // Login autentica a un usuario en la base de datos.
-// Recibe un puntero a LoginDTO con los datos del usuario a autenticar.
+// Recibe un puntero a LoginDTO con los datos del usuario a autenticar y la IP desde la que se intenta el acceso.
 // Retorna un puntero al modelo User autenticado o un error si ocurre algún problema.
 //
 // El proceso es el siguiente:
-// 1. Conecta a la base de datos.
-// 2. Busca un usuario con el email proporcionado.
-func Login(loginDto *dto.LoginDTO) (*models.User, error) {
+// 1. Rechaza el intento con BloqueoAccesoError si el email o la IP están bloqueados por intentos fallidos.
+// 2. Conecta a la base de datos y busca un usuario con el email proporcionado.
+// 3. Verifica la contraseña; si el usuario no existe se compara contra un hash ficticio para que
+// el tiempo de respuesta sea el mismo. En ambos casos se retorna ErrCredencialesInvalidas y se registra el fallo.
+// 4. Tras un login correcto se olvidan los fallos del email (salvo que falte el segundo factor)
+// y se verifica que la cuenta no esté suspendida (ErrCuentaSuspendida).
+func Login(loginDto *dto.LoginDTO, ip string) (*models.User, error) {
+	cuenta, origen := claveEmail(loginDto.Email), claveIP(ip)
+
+	if espera := accesos.bloqueo(time.Now(), cuenta, origen); espera > 0 {
+		return nil, &BloqueoAccesoError{ReintentarEn: espera}
+	}
+
 	gormDB, err := ConnectDB()
 	if err != nil {
 		return nil, err
 	}
 
 	var user models.User
+	hash := hashFicticio()
 
 	result := gormDB.Where("email = ?", loginDto.Email).First(&user)
-	if result.Error != nil {
-		if result.Error.Error() == "record not found" {
-			return nil, errors.New("usuario no encontrado")
-		}
+	if result.Error == nil {
+		hash = user.Password
+	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, result.Error
 	}
 
-	if !utils.ComparePassword(user.Password, loginDto.Password) {
-		return nil, errors.New("contraseña incorrecta")
+	if !utils.ComparePassword(hash, loginDto.Password) || result.Error != nil {
+		ahora := time.Now()
+		accesos.fallo(cuenta, configAccesos.IntentosCuenta, ahora)
+		accesos.fallo(origen, configAccesos.IntentosIP, ahora)
+		return nil, ErrCredencialesInvalidas
+	}
+
+	// Con el segundo factor activado los fallos se olvidan al completar el login (CompletarLoginSegundoFactor),
+	// para que acertar la contraseña no reinicie el conteo de los códigos fallidos
+	if !user.SegundoFactorActivo() {
+		accesos.olvidar(cuenta)
+	}
+
+	if user.Suspendido() {
+		return nil, ErrCuentaSuspendida
 	}
 
 	return &user, nil
@@ -50,7 +78,8 @@ func Login(loginDto *dto.LoginDTO) (*models.User, error) {
 // 4. Hashea la contraseña proporcionada.
 // 5. Obtiene el rol "user" desde la base de datos.
 // 6. Crea el usuario con los datos proporcionados y el rol obtenido.
-// 7. Retorna el usuario creado o un error si ocurre algún problema.
+// 7. Envía los códigos para verificar el email y el teléfono.
+// 8. Retorna el usuario creado o un error si ocurre algún problema.
 func Register(registerDto *dto.RegisterDTO) (*models.User, error) {
 	gormDB, err := ConnectDB()
 	if err != nil {
@@ -75,7 +104,7 @@ func Register(registerDto *dto.RegisterDTO) (*models.User, error) {
 
 	// Obtener el rol del usuario con código "user"
 	role := models.Role{}
-	resultRole := gormDB.Where("code = ?", "user").First(&role)
+	resultRole := gormDB.Where("code = ?", models.RolUsuario).First(&role)
 	if resultRole.Error != nil {
 		return nil, errors.New("error al obtener el rol del usuario")
 	}
@@ -98,6 +127,8 @@ func Register(registerDto *dto.RegisterDTO) (*models.User, error) {
 		return nil, errors.New("no se pudo crear el usuario")
 	}
 
+	enviarCodigosRegistro(gormDB, &user)
+
 	return &user, nil
 }
 
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// intervalo representa un rango de tiempo semiabierto [inicio, fin)
+type intervalo struct {
+	inicio time.Time
+	fin    time.Time
+}
+
+func (i intervalo) solapa(inicio, fin time.Time) bool {
+	return i.inicio.Before(fin) && inicio.Before(i.fin)
+}
+
+// DisponibilidadEmpleado agrupa las horas de inicio disponibles de un empleado
+type DisponibilidadEmpleado struct {
+	EmployeeID uint
+	Name       string
+	Slots      []time.Time
+}
+
+// ObtenerDisponibilidad calcula las horas de inicio en las que se pueden agendar los servicios indicados.
+// Recibe un puntero a dto.AvailabilityQuery con la fecha, los servicios, el empleado (opcional) y la granularidad.
+// Retorna la disponibilidad por empleado, un error si ocurre algún problema y el código HTTP asociado.
+//
+// El proceso es el siguiente:
+// 1. Verifica que los servicios solicitados existan y estén activos.
+// 2. Obtiene el día de atención; si el local no atiende ese día no hay disponibilidad.
+// 3. Obtiene los empleados activos (o solo el indicado) habilitados para todos los servicios,
+// la duración total para cada uno, sus citas y los cierres que afectan la fecha.
+// 4. Descuenta los cierres del horario de cada empleado y lo recorre en pasos de la granularidad configurada,
+// descartando las horas que ya pasaron o que se cruzan con otra cita.
+func ObtenerDisponibilidad(consulta *dto.AvailabilityQuery) ([]DisponibilidadEmpleado, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if len(consulta.ServiceIDs) == 0 {
+		return nil, errors.New("debe seleccionar al menos un servicio"), 400
+	}
+
+	if consulta.Date.IsZero() {
+		return nil, errors.New("la fecha es obligatoria"), 400
+	}
+
+	granularidad := configCitas.MinutosIntervalo
+	if consulta.Granularity != 0 {
+		if consulta.Granularity < 5 || consulta.Granularity > 240 {
+			return nil, errors.New("la granularidad debe estar entre 5 y 240 minutos"), 400
+		}
+		granularidad = consulta.Granularity
+	}
+	paso := time.Duration(granularidad) * time.Minute
+
+	servicios, err := obtenerServiciosCita(gormDB, consulta.ServiceIDs)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	disponibilidad := []DisponibilidadEmpleado{}
+
+	dia, err := obtenerDia(gormDB, consulta.Date)
+	if err != nil {
+		var errServicio *ErrorServicio
+		if errors.As(err, &errServicio) {
+			return disponibilidad, nil, 200
+		}
+		return nil, err, 500
+	}
+
+	query := gormDB.Scopes(models.EmpleadoActivo).Order("name")
+	if consulta.EmployeeID != 0 {
+		query = query.Where("id = ?", consulta.EmployeeID)
+	}
+
+	var empleados []models.Employee
+	if err := query.Find(&empleados).Error; err != nil {
+		return nil, err, 500
+	}
+
+	if consulta.EmployeeID != 0 && len(empleados) == 0 {
+		return nil, errors.New("empleado no encontrado o inactivo"), 404
+	}
+
+	empleadoIDs := make([]uint, len(empleados))
+	for i, empleado := range empleados {
+		empleadoIDs[i] = empleado.ID
+	}
+
+	duraciones, err := duracionesEmpleados(gormDB, empleadoIDs, servicios)
+	if err != nil {
+		return nil, err, 500
+	}
+
+	// Los horarios de los empleados pueden salir del horario general, por lo que se consideran las citas de todo el día
+	anio, mes, diaMes := consulta.Date.Date()
+	inicioDia := time.Date(anio, mes, diaMes, 0, 0, 0, 0, consulta.Date.Location())
+	ocupados, err := citasOcupadas(gormDB, empleadoIDs, inicioDia, inicioDia.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err, 500
+	}
+
+	cerrados, err := cierresEmpleados(gormDB, empleadoIDs, inicioDia, inicioDia.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err, 500
+	}
+
+	ahora := time.Now()
+	for _, empleado := range empleados {
+		duracion, habilitado := duraciones[empleado.ID]
+		if !habilitado {
+			continue
+		}
+
+		ventanas, err := horarioEmpleado(gormDB, dia, empleado.ID, consulta.Date)
+		if err != nil {
+			return nil, err, 500
+		}
+
+		disponibilidad = append(disponibilidad, DisponibilidadEmpleado{
+			EmployeeID: empleado.ID,
+			Name:       empleado.Name,
+			Slots:      calcularHuecos(restarIntervalos(ventanas, cerrados[empleado.ID]), ocupados[empleado.ID], duracion, paso, ahora),
+		})
+	}
+
+	return disponibilidad, nil, 200
+}
+
+// obtenerDia busca el día de atención activo que corresponde a la fecha indicada.
+func obtenerDia(tx *gorm.DB, fecha time.Time) (*models.Day, error) {
+	var dia models.Day
+	if err := tx.Scopes(models.DiaActivo).Where("code = ?", models.CodigoDia(fecha)).First(&dia).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(400, "no se atiende el día seleccionado")
+		}
+		return nil, err
+	}
+	return &dia, nil
+}
+
+// citasOcupadas obtiene, agrupadas por empleado, las citas vigentes que se cruzan con el rango [desde, hasta).
+func citasOcupadas(tx *gorm.DB, empleadoIDs []uint, desde, hasta time.Time) (map[uint][]intervalo, error) {
+	ocupados := make(map[uint][]intervalo)
+	if len(empleadoIDs) == 0 {
+		return ocupados, nil
+	}
+
+	var citas []models.Appointment
+	err := tx.Scopes(models.CitaVigente).
+		Where("employee_id IN ? AND start_at < ? AND end_at > ?", empleadoIDs, hasta, desde).
+		Find(&citas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cita := range citas {
+		ocupados[cita.EmployeeID] = append(ocupados[cita.EmployeeID], intervalo{inicio: cita.StartAt, fin: cita.EndAt})
+	}
+
+	return ocupados, nil
+}
+
+// contieneIntervalo indica si el rango [inicio, fin) cabe completo dentro de alguna de las ventanas.
+func contieneIntervalo(ventanas []intervalo, inicio, fin time.Time) bool {
+	for _, ventana := range ventanas {
+		if !inicio.Before(ventana.inicio) && !fin.After(ventana.fin) {
+			return true
+		}
+	}
+	return false
+}
+
+// calcularHuecos recorre cada ventana en pasos de tamaño paso y devuelve las horas de inicio
+// en las que cabe un servicio de la duración indicada sin cruzarse con los intervalos ocupados.
+// Se descartan las horas anteriores a desde.
+func calcularHuecos(ventanas, ocupados []intervalo, duracion, paso time.Duration, desde time.Time) []time.Time {
+	huecos := []time.Time{}
+
+	for _, ventana := range ventanas {
+		for inicio := ventana.inicio; !inicio.Add(duracion).After(ventana.fin); inicio = inicio.Add(paso) {
+			if inicio.Before(desde) {
+				continue
+			}
+
+			fin := inicio.Add(duracion)
+			libre := true
+			for _, ocupado := range ocupados {
+				if ocupado.solapa(inicio, fin) {
+					libre = false
+					break
+				}
+			}
+
+			if libre {
+				huecos = append(huecos, inicio)
+			}
+		}
+	}
+
+	return huecos
+}
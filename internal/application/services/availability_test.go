@@ -0,0 +1,145 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// hora construye una hora del día de prueba en UTC
+func hora(h, m int) time.Time {
+	return time.Date(2026, 3, 10, h, m, 0, 0, time.UTC)
+}
+
+func horasIguales(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCalcularHuecos(t *testing.T) {
+	jornada := []intervalo{{inicio: hora(9, 0), fin: hora(11, 0)}}
+	antes := hora(0, 0)
+
+	casos := []struct {
+		nombre   string
+		ventanas []intervalo
+		ocupados []intervalo
+		duracion time.Duration
+		paso     time.Duration
+		desde    time.Time
+		esperado []time.Time
+	}{
+		{
+			nombre:   "jornada libre",
+			ventanas: jornada,
+			duracion: 30 * time.Minute,
+			paso:     30 * time.Minute,
+			desde:    antes,
+			esperado: []time.Time{hora(9, 0), hora(9, 30), hora(10, 0), hora(10, 30)},
+		},
+		{
+			// El último hueco termina justo al cierre; un paso más ya no cabe
+			nombre:   "granularidad al final de la ventana",
+			ventanas: jornada,
+			duracion: 45 * time.Minute,
+			paso:     15 * time.Minute,
+			desde:    antes,
+			esperado: []time.Time{hora(9, 0), hora(9, 15), hora(9, 30), hora(9, 45), hora(10, 0), hora(10, 15)},
+		},
+		{
+			nombre:   "servicio más largo que la ventana",
+			ventanas: jornada,
+			duracion: 3 * time.Hour,
+			paso:     15 * time.Minute,
+			desde:    antes,
+			esperado: []time.Time{},
+		},
+		{
+			nombre:   "horas pasadas",
+			ventanas: jornada,
+			duracion: 30 * time.Minute,
+			paso:     30 * time.Minute,
+			desde:    hora(9, 40),
+			esperado: []time.Time{hora(10, 0), hora(10, 30)},
+		},
+		{
+			// Los intervalos son semiabiertos: una cita que termina a las 9:30 no bloquea el hueco de las 9:30
+			nombre:   "citas adyacentes",
+			ventanas: jornada,
+			ocupados: []intervalo{{inicio: hora(9, 0), fin: hora(9, 30)}, {inicio: hora(10, 0), fin: hora(10, 30)}},
+			duracion: 30 * time.Minute,
+			paso:     30 * time.Minute,
+			desde:    antes,
+			esperado: []time.Time{hora(9, 30), hora(10, 30)},
+		},
+		{
+			nombre:   "cita que se cruza parcialmente",
+			ventanas: jornada,
+			ocupados: []intervalo{{inicio: hora(9, 45), fin: hora(10, 15)}},
+			duracion: 30 * time.Minute,
+			paso:     15 * time.Minute,
+			desde:    antes,
+			esperado: []time.Time{hora(9, 0), hora(9, 15), hora(10, 15), hora(10, 30)},
+		},
+		{
+			nombre:   "turno partido por un descanso",
+			ventanas: restarIntervalos(jornada, []intervalo{{inicio: hora(10, 0), fin: hora(10, 15)}}),
+			duracion: 30 * time.Minute,
+			paso:     15 * time.Minute,
+			desde:    antes,
+			esperado: []time.Time{hora(9, 0), hora(9, 15), hora(9, 30), hora(10, 15), hora(10, 30)},
+		},
+		{
+			nombre:   "cierre que cubre toda la ventana",
+			ventanas: restarIntervalos(jornada, []intervalo{{inicio: hora(8, 0), fin: hora(12, 0)}}),
+			duracion: 30 * time.Minute,
+			paso:     15 * time.Minute,
+			desde:    antes,
+			esperado: []time.Time{},
+		},
+	}
+
+	for _, caso := range casos {
+		huecos := calcularHuecos(caso.ventanas, caso.ocupados, caso.duracion, caso.paso, caso.desde)
+		if !horasIguales(huecos, caso.esperado) {
+			t.Errorf("%s: calcularHuecos = %v, se esperaba %v", caso.nombre, huecos, caso.esperado)
+		}
+	}
+}
+
+func TestContieneIntervalo(t *testing.T) {
+	ventanas := []intervalo{
+		{inicio: hora(9, 0), fin: hora(12, 0)},
+		{inicio: hora(13, 0), fin: hora(18, 0)},
+	}
+
+	casos := []struct {
+		nombre   string
+		inicio   time.Time
+		fin      time.Time
+		esperado bool
+	}{
+		{nombre: "dentro de la mañana", inicio: hora(9, 30), fin: hora(10, 30), esperado: true},
+		{nombre: "ocupa la ventana completa", inicio: hora(9, 0), fin: hora(12, 0), esperado: true},
+		{nombre: "termina justo al cierre", inicio: hora(17, 30), fin: hora(18, 0), esperado: true},
+		{nombre: "pasa del cierre", inicio: hora(17, 30), fin: hora(18, 15), esperado: false},
+		{nombre: "empieza antes de la apertura", inicio: hora(8, 45), fin: hora(9, 15), esperado: false},
+		{nombre: "atraviesa el descanso", inicio: hora(11, 30), fin: hora(13, 30), esperado: false},
+	}
+
+	for _, caso := range casos {
+		if got := contieneIntervalo(ventanas, caso.inicio, caso.fin); got != caso.esperado {
+			t.Errorf("%s: contieneIntervalo = %v, se esperaba %v", caso.nombre, got, caso.esperado)
+		}
+	}
+
+	if contieneIntervalo(nil, hora(9, 0), hora(10, 0)) {
+		t.Error("sin ventanas no debería caber ningún intervalo")
+	}
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/ical"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ResultadoCierre es el cierre guardado junto con las citas pendientes que quedaron dentro de él,
+// para que el administrador pueda reprogramarlas.
+type ResultadoCierre struct {
+	Cierre         models.Closure
+	CitasAfectadas []models.Appointment
+}
+
+// ResultadoImportacion resume la importación de un archivo iCalendar
+type ResultadoImportacion struct {
+	Creados        int
+	Actualizados   int
+	Cierres        []models.Closure
+	CitasAfectadas []models.Appointment
+	Rechazados     []EventoRechazado
+}
+
+// EventoRechazado es un evento del archivo iCalendar que no se importó, con el motivo
+type EventoRechazado struct {
+	UID     string
+	Resumen string
+	Motivo  string
+}
+
+// ObtenerCierres lista los cierres que se cruzan con el rango del filtro, ordenados por inicio.
+func ObtenerCierres(filtro *dto.ClosureFilter) ([]models.Closure, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	query := gormDB.Preload("Employee").Order("start_at")
+	if !filtro.From.IsZero() {
+		query = query.Where("end_at > ?", filtro.From)
+	}
+	if !filtro.To.IsZero() {
+		query = query.Where("start_at < ?", filtro.To)
+	}
+	if filtro.EmployeeID != 0 {
+		query = query.Where("employee_id IS NULL OR employee_id = ?", filtro.EmployeeID)
+	}
+
+	var cierres []models.Closure
+	if err := query.Find(&cierres).Error; err != nil {
+		return nil, err, 500
+	}
+
+	return cierres, nil, 200
+}
+
+func ObtenerCierre(id uint) (*models.Closure, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var cierre models.Closure
+	if err := gormDB.Preload("Employee").First(&cierre, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el cierre"), 404
+		}
+		return nil, err, 500
+	}
+
+	return &cierre, nil, 200
+}
+
+// CrearCierre registra un cierre del local o de un empleado.
+// Las citas ya agendadas dentro del cierre no se cancelan: se devuelven en el resultado para reprogramarlas.
+func CrearCierre(cierreDto *dto.Closure) (*ResultadoCierre, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	cierre := models.Closure{Source: models.OrigenManual}
+	if err := aplicarCierre(gormDB, &cierre, cierreDto); err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if err := gormDB.Omit("Employee").Create(&cierre).Error; err != nil {
+		return nil, errors.New("No se pudo crear el cierre"), 500
+	}
+
+	citas, err := citasEnCierre(gormDB, &cierre)
+	if err != nil {
+		return nil, err, 500
+	}
+
+	return &ResultadoCierre{Cierre: cierre, CitasAfectadas: citas}, nil, 201
+}
+
+// ActualizarCierre modifica los datos enviados de un cierre, conservando los demás, y devuelve
+// las citas que quedan dentro del nuevo rango.
+func ActualizarCierre(id uint, cierreDto *dto.ClosureUpdate) (*ResultadoCierre, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var cierre models.Closure
+	if err := gormDB.First(&cierre, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el cierre"), 404
+		}
+		return nil, err, 500
+	}
+
+	datos := fusionarCierre(&cierre, cierreDto)
+	if err := aplicarCierre(gormDB, &cierre, &datos); err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if err := gormDB.Omit("Employee").Save(&cierre).Error; err != nil {
+		return nil, errors.New("Hubo un error al actualizar"), 500
+	}
+
+	citas, err := citasEnCierre(gormDB, &cierre)
+	if err != nil {
+		return nil, err, 500
+	}
+
+	return &ResultadoCierre{Cierre: cierre, CitasAfectadas: citas}, nil, 200
+}
+
+func EliminarCierre(id uint) (bool, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err, 500
+	}
+
+	result := gormDB.Delete(&models.Closure{}, id)
+	if result.Error != nil {
+		return false, errors.New("No se pudo eliminar el cierre"), 500
+	}
+
+	if result.RowsAffected == 0 {
+		return false, errors.New("No se encontró el cierre"), 404
+	}
+
+	return true, nil, 200
+}
+
+// ImportarCierres crea un cierre por cada evento del archivo iCalendar recibido.
+// Si empleadoID es nil los cierres aplican a todo el local. Si kind está vacío se usa holiday.
+// Los eventos con un UID ya importado para el mismo alcance actualizan el cierre existente en lugar de duplicarlo.
+// Los eventos que no se pueden importar (ver motivoRechazo) se informan en Rechazados y no impiden importar los demás.
+func ImportarCierres(archivo io.Reader, empleadoID *uint, kind string) (*ResultadoImportacion, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if kind == "" {
+		kind = models.CierreFeriado
+	}
+	if !models.TipoCierreValido(kind) {
+		return nil, errors.New("tipo de cierre no válido"), 400
+	}
+
+	if empleadoID != nil {
+		if err := existeEmpleado(gormDB, *empleadoID); err != nil {
+			return nil, err, codigoError(err)
+		}
+	}
+
+	eventos, err := ical.Parsear(archivo, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("archivo iCalendar no válido: %v", err), 400
+	}
+
+	if len(eventos) == 0 {
+		return nil, errors.New("el archivo no contiene eventos"), 400
+	}
+
+	resultado := &ResultadoImportacion{Cierres: []models.Closure{}, Rechazados: []EventoRechazado{}}
+	vistas := make(map[uint]bool)
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		for _, evento := range eventos {
+			if motivo := motivoRechazo(evento); motivo != "" {
+				resultado.Rechazados = append(resultado.Rechazados, EventoRechazado{
+					UID:     evento.UID,
+					Resumen: evento.Resumen,
+					Motivo:  motivo,
+				})
+				continue
+			}
+
+			var cierre models.Closure
+			existe := false
+			if evento.UID != "" {
+				query := tx.Where("source = ? AND uid = ?", models.OrigenICS, evento.UID)
+				if empleadoID != nil {
+					query = query.Where("employee_id = ?", *empleadoID)
+				} else {
+					query = query.Where("employee_id IS NULL")
+				}
+
+				err := query.First(&cierre).Error
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				existe = err == nil
+			}
+
+			cierre.EmployeeID = empleadoID
+			cierre.Kind = kind
+			cierre.Description = recortar(strings.TrimSpace(evento.Resumen), 255)
+			cierre.StartAt = evento.Inicio
+			cierre.EndAt = evento.Fin
+			cierre.Source = models.OrigenICS
+			cierre.UID = evento.UID
+
+			if err := tx.Omit("Employee").Save(&cierre).Error; err != nil {
+				return err
+			}
+
+			if existe {
+				resultado.Actualizados++
+			} else {
+				resultado.Creados++
+			}
+			resultado.Cierres = append(resultado.Cierres, cierre)
+
+			citas, err := citasEnCierre(tx, &cierre)
+			if err != nil {
+				return err
+			}
+			for _, cita := range citas {
+				if !vistas[cita.ID] {
+					vistas[cita.ID] = true
+					resultado.CitasAfectadas = append(resultado.CitasAfectadas, cita)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.New("No se pudieron importar los cierres"), 500
+	}
+
+	return resultado, nil, 200
+}
+
+// motivoRechazo indica por qué un evento iCalendar no se puede importar como cierre, o una cadena vacía si se puede.
+// Los eventos repetidos se rechazan porque solo se importaría su primera ocurrencia.
+func motivoRechazo(evento ical.Evento) string {
+	if evento.Recurrente {
+		return "los eventos repetidos no están soportados; exporte cada ocurrencia como un evento"
+	}
+	if !evento.Fin.After(evento.Inicio) {
+		return "el fin del evento debe ser posterior al inicio"
+	}
+	return ""
+}
+
+// aplicarCierre valida los datos del DTO y los copia en el cierre.
+// Si no se indica el tipo, se usa closure para el local y time_off para un empleado.
+func aplicarCierre(gormDB *gorm.DB, cierre *models.Closure, cierreDto *dto.Closure) error {
+	kind := cierreDto.Kind
+	if kind == "" {
+		kind = models.CierreLocal
+		if cierreDto.EmployeeID != nil {
+			kind = models.CierrePermiso
+		}
+	}
+	if !models.TipoCierreValido(kind) {
+		return nuevoError(400, "tipo de cierre no válido")
+	}
+
+	if cierreDto.StartAt.IsZero() || cierreDto.EndAt.IsZero() {
+		return nuevoError(400, "el inicio y el fin del cierre son obligatorios")
+	}
+	if !cierreDto.EndAt.After(cierreDto.StartAt) {
+		return nuevoError(400, "el fin del cierre debe ser posterior al inicio")
+	}
+
+	if cierreDto.EmployeeID != nil {
+		if err := existeEmpleado(gormDB, *cierreDto.EmployeeID); err != nil {
+			return err
+		}
+	}
+
+	cierre.EmployeeID = cierreDto.EmployeeID
+	cierre.Employee = nil
+	cierre.Kind = kind
+	cierre.Description = recortar(strings.TrimSpace(cierreDto.Description), 255)
+	cierre.StartAt = cierreDto.StartAt
+	cierre.EndAt = cierreDto.EndAt
+	return nil
+}
+
+// fusionarCierre arma los datos completos del cierre a partir de los actuales y los campos enviados en la actualización
+func fusionarCierre(cierre *models.Closure, cambios *dto.ClosureUpdate) dto.Closure {
+	datos := dto.Closure{
+		EmployeeID:  cierre.EmployeeID,
+		Kind:        cierre.Kind,
+		Description: cierre.Description,
+		StartAt:     cierre.StartAt,
+		EndAt:       cierre.EndAt,
+	}
+
+	if cambios.EmployeeID != nil {
+		datos.EmployeeID = cambios.EmployeeID
+		if *cambios.EmployeeID == 0 {
+			datos.EmployeeID = nil
+		}
+	}
+	if cambios.Kind != nil {
+		datos.Kind = *cambios.Kind
+	}
+	if cambios.Description != nil {
+		datos.Description = *cambios.Description
+	}
+	if cambios.StartAt != nil {
+		datos.StartAt = *cambios.StartAt
+	}
+	if cambios.EndAt != nil {
+		datos.EndAt = *cambios.EndAt
+	}
+
+	return datos
+}
+
+// citasEnCierre obtiene las citas pendientes que se cruzan con el cierre
+func citasEnCierre(tx *gorm.DB, cierre *models.Closure) ([]models.Appointment, error) {
+	query := tx.Where("start_at < ? AND end_at > ?", cierre.EndAt, cierre.StartAt)
+	if cierre.EmployeeID != nil {
+		query = query.Where("employee_id = ?", *cierre.EmployeeID)
+	}
+	return citasFuturas(query)
+}
+
+// cierresEmpleados obtiene, agrupados por empleado, los cierres que se cruzan con el rango [desde, hasta).
+// Los cierres de todo el local se incluyen para cada uno de los empleados.
+func cierresEmpleados(tx *gorm.DB, empleadoIDs []uint, desde, hasta time.Time) (map[uint][]intervalo, error) {
+	cerrados := make(map[uint][]intervalo)
+	if len(empleadoIDs) == 0 {
+		return cerrados, nil
+	}
+
+	var cierres []models.Closure
+	err := tx.Where("employee_id IS NULL OR employee_id IN ?", empleadoIDs).
+		Where("start_at < ? AND end_at > ?", hasta, desde).
+		Find(&cierres).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cierre := range cierres {
+		bloque := intervalo{inicio: cierre.StartAt, fin: cierre.EndAt}
+		if cierre.EmployeeID != nil {
+			cerrados[*cierre.EmployeeID] = append(cerrados[*cierre.EmployeeID], bloque)
+			continue
+		}
+		for _, empleadoID := range empleadoIDs {
+			cerrados[empleadoID] = append(cerrados[empleadoID], bloque)
+		}
+	}
+
+	return cerrados, nil
+}
+
+// verificarCierres retorna un error si el rango [inicio, fin) se cruza con un cierre del local o del empleado.
+func verificarCierres(tx *gorm.DB, empleadoID uint, inicio, fin time.Time) error {
+	var cierre models.Closure
+	err := tx.Where("employee_id IS NULL OR employee_id = ?", empleadoID).
+		Where("start_at < ? AND end_at > ?", fin, inicio).
+		Order("start_at").
+		First(&cierre).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	mensaje := "el horario solicitado coincide con un cierre"
+	if cierre.Description != "" {
+		mensaje += ": " + cierre.Description
+	}
+	return nuevoError(400, mensaje)
+}
+
+// recortar limita el texto a max caracteres
+func recortar(texto string, max int) string {
+	runas := []rune(texto)
+	if len(runas) <= max {
+		return texto
+	}
+	return string(runas[:max])
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/ical"
+	"testing"
+	"time"
+)
+
+func TestFusionarCierre(t *testing.T) {
+	inicio := time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC)
+	empleadoID := uint(3)
+	cierre := models.Closure{
+		EmployeeID:  &empleadoID,
+		Kind:        models.CierrePermiso,
+		Description: "Trámite",
+		StartAt:     inicio,
+		EndAt:       inicio.Add(4 * time.Hour),
+	}
+
+	// Sin cambios se conservan todos los datos
+	datos := fusionarCierre(&cierre, &dto.ClosureUpdate{})
+	if datos.EmployeeID != cierre.EmployeeID || datos.Kind != cierre.Kind || datos.Description != cierre.Description ||
+		!datos.StartAt.Equal(cierre.StartAt) || !datos.EndAt.Equal(cierre.EndAt) {
+		t.Errorf("una actualización vacía modificó el cierre: %+v", datos)
+	}
+
+	// Solo cambia el fin; el resto se conserva
+	fin := inicio.Add(8 * time.Hour)
+	datos = fusionarCierre(&cierre, &dto.ClosureUpdate{EndAt: &fin})
+	if !datos.EndAt.Equal(fin) || !datos.StartAt.Equal(inicio) || datos.Description != "Trámite" {
+		t.Errorf("datos = %+v, se esperaba solo el fin modificado", datos)
+	}
+
+	// EmployeeID 0 convierte el cierre en uno de todo el local
+	sinEmpleado := uint(0)
+	datos = fusionarCierre(&cierre, &dto.ClosureUpdate{EmployeeID: &sinEmpleado})
+	if datos.EmployeeID != nil {
+		t.Errorf("EmployeeID = %v, se esperaba nil", *datos.EmployeeID)
+	}
+}
+
+func TestMotivoRechazo(t *testing.T) {
+	inicio := time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)
+
+	casos := []struct {
+		nombre    string
+		evento    ical.Evento
+		rechazado bool
+	}{
+		{nombre: "válido", evento: ical.Evento{Inicio: inicio, Fin: inicio.AddDate(0, 0, 1)}},
+		{nombre: "repetido", evento: ical.Evento{Inicio: inicio, Fin: inicio.AddDate(0, 0, 1), Recurrente: true}, rechazado: true},
+		{nombre: "sin duración", evento: ical.Evento{Inicio: inicio, Fin: inicio}, rechazado: true},
+	}
+
+	for _, caso := range casos {
+		if motivo := motivoRechazo(caso.evento); (motivo != "") != caso.rechazado {
+			t.Errorf("%s: motivoRechazo = %q", caso.nombre, motivo)
+		}
+	}
+}
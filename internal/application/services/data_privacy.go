@@ -0,0 +1,199 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// nombreBorrado es el nombre con el que queda una cuenta cuyos datos personales fueron borrados
+const nombreBorrado = "Usuario eliminado"
+
+// ExportacionDatos reúne los datos personales de un usuario: su perfil, sus citas (con los servicios
+// y el historial de reprogramaciones), sus sesiones y las acciones registradas sobre su cuenta.
+type ExportacionDatos struct {
+	Usuario    models.User
+	Citas      []models.Appointment
+	Sesiones   []models.Session
+	Auditoria  []models.AuditLog
+	GeneradoEn time.Time
+}
+
+// ExportarDatosUsuario reúne los datos personales del usuario y registra la exportación en la auditoría.
+// actorID es quien la solicita: el propio usuario o un administrador. Las cuentas eliminadas también se
+// pueden exportar, salvo que sus datos ya hayan sido borrados.
+func ExportarDatosUsuario(actorID, userID uint) (*ExportacionDatos, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var user models.User
+	if err := gormDB.Unscoped().Preload("Role").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado"), 404
+		}
+		return nil, err, 500
+	}
+
+	if user.Borrado() {
+		return nil, errors.New("los datos de la cuenta fueron borrados"), 409
+	}
+
+	exportacion := ExportacionDatos{Usuario: user, GeneradoEn: time.Now()}
+
+	err = gormDB.Preload("AppointmentServices.Service").Preload("Employee").Preload("History").
+		Where("user_id = ?", user.ID).Order("start_at").Find(&exportacion.Citas).Error
+	if err != nil {
+		return nil, errors.New("No se pudieron obtener las citas"), 500
+	}
+
+	if err := gormDB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&exportacion.Sesiones).Error; err != nil {
+		return nil, errors.New("No se pudieron obtener las sesiones"), 500
+	}
+
+	if err := registrarAuditoria(gormDB, user.ID, actorID, models.AuditoriaExportacion, ""); err != nil {
+		return nil, errors.New("No se pudo registrar la exportación"), 500
+	}
+
+	// La auditoría se lee después de registrar la exportación para que la incluya
+	exportacion.Auditoria, err = obtenerAuditoria(gormDB, user.ID)
+	if err != nil {
+		return nil, errors.New("No se pudo obtener la auditoría de la cuenta"), 500
+	}
+
+	return &exportacion, nil, 200
+}
+
+// BorrarMisDatos anonimiza la cuenta del usuario autenticado tras validar su contraseña (ver anonimizarUsuario)
+func BorrarMisDatos(userID uint, borrarDto *dto.DeleteAccountDTO) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	if !utils.ComparePassword(user.Password, borrarDto.Password) {
+		return errContrasenaActual, 403
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		return anonimizarUsuario(tx, user, user.ID)
+	})
+	if err != nil {
+		return errors.New("No se pudieron borrar los datos de la cuenta"), 500
+	}
+
+	return nil, 200
+}
+
+// BorrarDatosUsuario anonimiza la cuenta de un usuario, aunque ya esté eliminada (ver anonimizarUsuario).
+// Un administrador no puede borrar su propia cuenta por esta vía.
+func BorrarDatosUsuario(adminID, id uint) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if adminID == id {
+		return nil, errors.New("no puede borrar los datos de su propia cuenta"), 403
+	}
+
+	var usuario models.User
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Preload("Role").First(&usuario, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nuevoError(404, "usuario no encontrado")
+			}
+			return err
+		}
+
+		if usuario.Borrado() {
+			return nuevoError(409, "los datos de la cuenta ya fueron borrados")
+		}
+
+		return anonimizarUsuario(tx, &usuario, adminID)
+	})
+	if err != nil {
+		if codigo := codigoError(err); codigo != 500 {
+			return nil, err, codigo
+		}
+		return nil, errors.New("No se pudieron borrar los datos de la cuenta"), 500
+	}
+
+	if err := gormDB.Unscoped().Preload("Role").First(&usuario, id).Error; err != nil {
+		return nil, err, 500
+	}
+
+	return &usuario, nil, 200
+}
+
+// anonimizarUsuario reemplaza los datos personales de la cuenta por valores ficticios y la elimina (soft delete).
+// Las citas se conservan para la contabilidad, pero sin los datos de contacto y con las futuras canceladas.
+// Se cierran las sesiones y se borran los códigos, tokens y sesiones, que guardan emails, teléfonos e IPs.
+// La auditoría se conserva porque no contiene datos personales.
+func anonimizarUsuario(tx *gorm.DB, user *models.User, actorID uint) error {
+	ahora := time.Now()
+	emailAnterior := user.Email
+
+	err := tx.Model(&models.Appointment{}).
+		Where("user_id = ? AND start_at > ? AND status IN ?", user.ID, ahora, []string{models.CitaReservada, models.CitaReprogramada}).
+		Updates(map[string]any{"status": models.CitaCancelada, "cancelled_at": ahora}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&models.Appointment{}).Where("user_id = ?", user.ID).
+		Updates(map[string]any{"customer_name": "", "customer_phone": ""}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := revocarSesionesUsuario(tx, user.ID); err != nil {
+		return err
+	}
+
+	for _, modelo := range []any{&models.Session{}, &models.RefreshToken{}, &models.VerificationCode{}, &models.RecoveryCode{}, &models.PasswordReset{}} {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(modelo).Error; err != nil {
+			return err
+		}
+	}
+
+	cambios := map[string]any{
+		// El email y el teléfono son únicos, por eso llevan el ID del usuario
+		"name":              nombreBorrado,
+		"email":             fmt.Sprintf("erased-%d@erased.invalid", user.ID),
+		"phone":             fmt.Sprintf("erased-%d", user.ID),
+		"password":          "",
+		"email_verified_at": nil,
+		"phone_verified_at": nil,
+		"suspension_reason": "",
+		"totp_secret":       "",
+		"totp_enabled_at":   nil,
+		"totp_last_step":    0,
+		"erased_at":         ahora,
+	}
+	if !user.DeletedAt.Valid {
+		cambios["deleted_at"] = ahora
+	}
+	if err := tx.Unscoped().Model(user).Updates(cambios).Error; err != nil {
+		return err
+	}
+
+	if err := registrarAuditoria(tx, user.ID, actorID, models.AuditoriaBorrado, ""); err != nil {
+		return err
+	}
+
+	accesos.olvidar(claveEmail(emailAnterior))
+	cacheRoles.invalidarUsuario(user.ID)
+	return nil
+}
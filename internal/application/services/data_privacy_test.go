@@ -0,0 +1,81 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// TestAnonimizarUsuario borra los datos personales de un usuario con una sesión abierta y un código pendiente.
+// Requiere TEST_DATABASE_URL con una base PostgreSQL; los cambios se deshacen al terminar.
+func TestAnonimizarUsuario(t *testing.T) {
+	tx := transaccionDePrueba(t)
+
+	ahora := time.Now()
+	user := models.User{
+		Name:            "Ana Pérez",
+		Email:           "ana.borrado@example.com",
+		Phone:           "+5491155550000",
+		Password:        "hash",
+		EmailVerifiedAt: &ahora,
+	}
+	crearUsuarioDePrueba(t, tx, &user)
+
+	familia := "familia-borrado"
+	registros := []any{
+		&models.RefreshToken{UserID: user.ID, TokenHash: hashToken("refresh-borrado"), FamilyID: familia, AccessJti: "jti-borrado", ExpiresAt: ahora.Add(time.Hour)},
+		&models.Session{UserID: user.ID, FamilyID: familia, IP: "203.0.113.7", LastSeenAt: ahora, ExpiresAt: ahora.Add(time.Hour)},
+		&models.VerificationCode{UserID: user.ID, Channel: models.VerificacionTelefono, Destination: user.Phone, CodeHash: hashToken("123456"), ExpiresAt: ahora.Add(time.Hour)},
+	}
+	for _, registro := range registros {
+		if err := tx.Omit(clause.Associations).Create(registro).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := anonimizarUsuario(tx, &user, user.ID); err != nil {
+		t.Fatalf("anonimizarUsuario: %v", err)
+	}
+
+	var borrado models.User
+	if err := tx.Unscoped().First(&borrado, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if borrado.Name != nombreBorrado || borrado.Email != fmt.Sprintf("erased-%d@erased.invalid", user.ID) ||
+		borrado.Phone != fmt.Sprintf("erased-%d", user.ID) || borrado.Password != "" {
+		t.Errorf("quedaron datos personales: %+v", borrado)
+	}
+	if !borrado.Borrado() || !borrado.DeletedAt.Valid || borrado.EmailVerificado() {
+		t.Errorf("la cuenta debería quedar borrada y eliminada: %+v", borrado)
+	}
+
+	for _, modelo := range []any{&models.Session{}, &models.RefreshToken{}, &models.VerificationCode{}} {
+		var total int64
+		if err := tx.Unscoped().Model(modelo).Where("user_id = ?", user.ID).Count(&total).Error; err != nil {
+			t.Fatal(err)
+		}
+		if total != 0 {
+			t.Errorf("quedaron %d registros de %T", total, modelo)
+		}
+	}
+
+	var revocado int64
+	if err := tx.Model(&models.RevokedToken{}).Where("jti = ?", "jti-borrado").Count(&revocado).Error; err != nil {
+		t.Fatal(err)
+	}
+	if revocado != 1 {
+		t.Error("el token de acceso de la sesión no quedó revocado")
+	}
+
+	var auditoria int64
+	err := tx.Model(&models.AuditLog{}).Where("user_id = ? AND action = ?", user.ID, models.AuditoriaBorrado).Count(&auditoria).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auditoria != 1 {
+		t.Error("el borrado no quedó en la auditoría")
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ObtenerDias() ([]models.Day, error) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var dias []models.Day
+	if err := gormDB.Order("id").Find(&dias).Error; err != nil {
+		return nil, err
+	}
+
+	return dias, nil
+}
+
+func ObtenerDia(id uint) (*models.Day, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var dia models.Day
+	if err := gormDB.First(&dia, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el día"), 404
+		}
+		return nil, err, 500
+	}
+
+	return &dia, nil, 200
+}
+
+// CrearDia registra un día de atención.
+// El código debe ser uno de los días de la semana (lunes, martes, ...) y no estar registrado;
+// el horario es obligatorio y EndAt debe ser posterior a StartAt.
+func CrearDia(diaDto *dto.Day) (*models.Day, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	code := strings.ToLower(strings.TrimSpace(diaDto.Code))
+	if !models.CodigoDiaValido(code) {
+		return nil, errors.New("el código debe ser un día de la semana (domingo, lunes, martes, miercoles, jueves, viernes o sabado)"), 400
+	}
+
+	if existe, err := codigoDiaEnUso(gormDB, code, 0); err != nil {
+		return nil, err, 500
+	} else if existe {
+		return nil, errors.New("ya existe un día con ese código"), 409
+	}
+
+	descripcion := strings.TrimSpace(diaDto.Description)
+	if descripcion == "" {
+		return nil, errors.New("la descripción del día es obligatoria"), 400
+	}
+
+	horario, err := horarioDia(diaDto.StartAt, diaDto.EndAt)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	dia := models.Day{
+		Code:        code,
+		Description: descripcion,
+		StartAt:     horario.inicio,
+		EndAt:       horario.fin,
+		Status:      true,
+	}
+
+	if err := gormDB.Create(&dia).Error; err != nil {
+		return nil, errors.New("No se pudo crear el día"), 500
+	}
+
+	return &dia, nil, 201
+}
+
+// ActualizarDia actualiza los datos de un día de atención.
+// Solo se modifican los campos que no están vacíos en el DTO; el horario resultante se valida completo.
+func ActualizarDia(id uint, diaDto *dto.Day) (*models.Day, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var dia models.Day
+	if err := gormDB.First(&dia, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el día"), 404
+		}
+		return nil, err, 500
+	}
+
+	if code := strings.ToLower(strings.TrimSpace(diaDto.Code)); code != "" {
+		if !models.CodigoDiaValido(code) {
+			return nil, errors.New("el código debe ser un día de la semana (domingo, lunes, martes, miercoles, jueves, viernes o sabado)"), 400
+		}
+		if existe, err := codigoDiaEnUso(gormDB, code, dia.ID); err != nil {
+			return nil, err, 500
+		} else if existe {
+			return nil, errors.New("ya existe un día con ese código"), 409
+		}
+		dia.Code = code
+	}
+
+	if descripcion := strings.TrimSpace(diaDto.Description); descripcion != "" {
+		dia.Description = descripcion
+	}
+
+	inicio, fin := dia.StartAt.In(time.Local).Format("15:04"), dia.EndAt.In(time.Local).Format("15:04")
+	if diaDto.StartAt != "" {
+		inicio = diaDto.StartAt
+	}
+	if diaDto.EndAt != "" {
+		fin = diaDto.EndAt
+	}
+
+	horario, err := horarioDia(inicio, fin)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+	dia.StartAt = horario.inicio
+	dia.EndAt = horario.fin
+
+	if err := gormDB.Save(&dia).Error; err != nil {
+		return nil, errors.New("Hubo un error al actualizar"), 500
+	}
+
+	return &dia, nil, 200
+}
+
+// ActivarDesactivarDia cambia el estado del día.
+// Si el día se va a desactivar y tiene citas futuras, la operación se rechaza con un CitasAfectadasError
+// que incluye esas citas, salvo que forzar sea true.
+func ActivarDesactivarDia(id uint, forzar bool) (*models.Day, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var dia models.Day
+	if err := gormDB.First(&dia, id).Error; err != nil {
+		return nil, errors.New("No se pudo encontrar el día"), 404
+	}
+
+	if dia.Status && !forzar {
+		if err := verificarCitasFuturasDia(gormDB, dia.ID, "el día tiene citas futuras; use force=true para desactivarlo"); err != nil {
+			return nil, err, codigoError(err)
+		}
+	}
+
+	dia.Status = !dia.Status
+
+	if err := gormDB.Save(&dia).Error; err != nil {
+		return nil, errors.New("No se pudo actualizar el status del día"), 500
+	}
+
+	return &dia, nil, 200
+}
+
+// EliminarDia elimina (soft delete) un día de atención.
+// Al igual que la desactivación, se rechaza si tiene citas futuras salvo que forzar sea true.
+func EliminarDia(id uint, forzar bool) (bool, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err, 500
+	}
+
+	var dia models.Day
+	if err := gormDB.First(&dia, id).Error; err != nil {
+		return false, errors.New("No se pudo encontrar el día"), 404
+	}
+
+	if !forzar {
+		if err := verificarCitasFuturasDia(gormDB, dia.ID, "el día tiene citas futuras; use force=true para eliminarlo"); err != nil {
+			return false, err, codigoError(err)
+		}
+	}
+
+	if err := gormDB.Delete(&dia).Error; err != nil {
+		return false, errors.New("No se pudo eliminar el día"), 500
+	}
+
+	return true, nil, 200
+}
+
+// horarioDia valida el horario HH:MM de un día y lo guarda sobre una fecha de referencia,
+// ya que de StartAt y EndAt solo se usan la hora y los minutos.
+func horarioDia(inicio, fin string) (intervalo, error) {
+	if inicio == "" || fin == "" {
+		return intervalo{}, nuevoError(400, "la hora de inicio y de fin son obligatorias")
+	}
+
+	horario, err := intervaloEnFecha(time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local), inicio, fin)
+	if err != nil {
+		return intervalo{}, nuevoError(400, err.Error())
+	}
+
+	if !horario.fin.After(horario.inicio) {
+		return intervalo{}, nuevoError(400, "la hora de fin debe ser posterior a la hora de inicio")
+	}
+
+	return horario, nil
+}
+
+// verificarCitasFuturasDia retorna un CitasAfectadasError con el mensaje indicado si el día tiene citas futuras.
+func verificarCitasFuturasDia(gormDB *gorm.DB, diaID uint, mensaje string) error {
+	citas, err := citasFuturas(gormDB.Where("day_id = ?", diaID))
+	if err != nil {
+		return err
+	}
+
+	if len(citas) > 0 {
+		return &CitasAfectadasError{Mensaje: mensaje, Citas: citas}
+	}
+
+	return nil
+}
+
+// codigoDiaEnUso indica si otro día (distinto de excluirID) ya usa el código indicado.
+// Se incluyen los días eliminados porque el índice único de code también los considera.
+func codigoDiaEnUso(gormDB *gorm.DB, code string, excluirID uint) (bool, error) {
+	var total int64
+	err := gormDB.Unscoped().Model(&models.Day{}).
+		Where("code = ? AND id <> ?", code, excluirID).
+		Count(&total).Error
+	return total > 0, err
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/migrations"
+	"backend_reservation/pkg/database/models"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// transaccionDePrueba abre una transacción sobre la base PostgreSQL de TEST_DATABASE_URL que se deshace al terminar la prueba.
+// Si la variable no está definida la prueba se omite.
+func transaccionDePrueba(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no está definida")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("no se pudo conectar: %v", err)
+	}
+	if err := migrations.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// crearUsuarioDePrueba crea un usuario con el rol de usuario dentro de la transacción
+func crearUsuarioDePrueba(t *testing.T, tx *gorm.DB, user *models.User) {
+	t.Helper()
+	var rol models.Role
+	if err := tx.Where("code = ?", models.RolUsuario).First(&rol).Error; err != nil {
+		t.Fatal(err)
+	}
+	user.RoleID = rol.ID
+	if err := tx.Omit(clause.Associations).Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ObtenerServiciosEmpleado lista los servicios para los que está habilitado un empleado
+func ObtenerServiciosEmpleado(empleadoID uint) ([]models.EmployeeService, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if err := gormDB.First(&models.Employee{}, empleadoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el empleado"), 404
+		}
+		return nil, err, 500
+	}
+
+	var habilidades []models.EmployeeService
+	if err := gormDB.Preload("Service").Where("employee_id = ?", empleadoID).Find(&habilidades).Error; err != nil {
+		return nil, err, 500
+	}
+
+	return habilidades, nil, 200
+}
+
+// AsignarServicioEmpleado habilita a un empleado para un servicio o actualiza su tiempo estimado.
+// Si EstimatedTime es nil se usa el tiempo estimado general del servicio.
+func AsignarServicioEmpleado(empleadoID, servicioID uint, habilidadDto *dto.EmployeeService) (*models.EmployeeService, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if habilidadDto.EstimatedTime != nil && *habilidadDto.EstimatedTime == 0 {
+		return nil, errors.New("el tiempo estimado debe ser mayor a cero"), 400
+	}
+
+	var empleado models.Employee
+	if err := gormDB.First(&empleado, empleadoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el empleado"), 404
+		}
+		return nil, err, 500
+	}
+
+	var servicio models.Service
+	if err := gormDB.First(&servicio, servicioID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el servicio"), 404
+		}
+		return nil, err, 500
+	}
+
+	habilidad := models.EmployeeService{
+		EmployeeID:    empleado.ID,
+		ServiceID:     servicio.ID,
+		EstimatedTime: habilidadDto.EstimatedTime,
+	}
+
+	err = gormDB.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "employee_id"}, {Name: "service_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"estimated_time", "updated_at"}),
+	}).Create(&habilidad).Error
+	if err != nil {
+		return nil, errors.New("No se pudo asignar el servicio al empleado"), 500
+	}
+
+	habilidad.Service = servicio
+	return &habilidad, nil, 200
+}
+
+// QuitarServicioEmpleado deshabilita a un empleado para un servicio.
+// El registro se elimina definitivamente para que pueda volver a asignarse sin chocar con el índice único.
+func QuitarServicioEmpleado(empleadoID, servicioID uint) (bool, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err, 500
+	}
+
+	result := gormDB.Unscoped().
+		Where("employee_id = ? AND service_id = ?", empleadoID, servicioID).
+		Delete(&models.EmployeeService{})
+	if result.Error != nil {
+		return false, errors.New("No se pudo quitar el servicio del empleado"), 500
+	}
+
+	if result.RowsAffected == 0 {
+		return false, errors.New("el empleado no tiene asignado ese servicio"), 404
+	}
+
+	return true, nil, 200
+}
+
+// duracionesEmpleados calcula la duración total de los servicios para cada empleado habilitado en todos ellos,
+// aplicando el tiempo estimado propio de cada empleado cuando existe.
+// Si empleadoIDs está vacío se consideran todos los empleados. Los empleados no habilitados no aparecen en el resultado.
+func duracionesEmpleados(tx *gorm.DB, empleadoIDs []uint, servicios []models.Service) (map[uint]time.Duration, error) {
+	servicioIDs := make([]uint, len(servicios))
+	for i, servicio := range servicios {
+		servicioIDs[i] = servicio.ID
+	}
+
+	query := tx.Where("service_id IN ?", servicioIDs)
+	if len(empleadoIDs) > 0 {
+		query = query.Where("employee_id IN ?", empleadoIDs)
+	}
+
+	var habilidades []models.EmployeeService
+	if err := query.Find(&habilidades).Error; err != nil {
+		return nil, err
+	}
+
+	porEmpleado := make(map[uint]map[uint]models.EmployeeService)
+	for _, habilidad := range habilidades {
+		if porEmpleado[habilidad.EmployeeID] == nil {
+			porEmpleado[habilidad.EmployeeID] = make(map[uint]models.EmployeeService)
+		}
+		porEmpleado[habilidad.EmployeeID][habilidad.ServiceID] = habilidad
+	}
+
+	duraciones := make(map[uint]time.Duration)
+	for empleadoID, habilitados := range porEmpleado {
+		if len(habilitados) != len(servicios) {
+			continue
+		}
+
+		var minutos uint
+		for _, servicio := range servicios {
+			minutos += habilitados[servicio.ID].Duracion(servicio)
+		}
+		duraciones[empleadoID] = time.Duration(minutos) * time.Minute
+	}
+
+	return duraciones, nil
+}
+
+// duracionEmpleado retorna la duración total de los servicios para el empleado indicado,
+// o un error si el empleado no está habilitado para alguno de ellos.
+func duracionEmpleado(tx *gorm.DB, empleadoID uint, servicios []models.Service) (time.Duration, error) {
+	duraciones, err := duracionesEmpleados(tx, []uint{empleadoID}, servicios)
+	if err != nil {
+		return 0, err
+	}
+
+	duracion, ok := duraciones[empleadoID]
+	if !ok {
+		return 0, nuevoError(400, "el empleado no está habilitado para uno o más de los servicios solicitados")
+	}
+
+	return duracion, nil
+}
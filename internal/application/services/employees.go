@@ -0,0 +1,228 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ObtenerEmpleados() ([]models.Employee, error) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var empleados []models.Employee
+	if err := gormDB.Preload("Role").Order("name").Find(&empleados).Error; err != nil {
+		return nil, err
+	}
+
+	return empleados, nil
+}
+
+func ObtenerEmpleado(id uint) (*models.Employee, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var empleado models.Employee
+	if err := gormDB.Preload("Role").First(&empleado, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el empleado"), 404
+		}
+		return nil, err, 500
+	}
+
+	return &empleado, nil, 200
+}
+
+// CrearEmpleado registra un empleado con el rol indicado.
+// El nombre es obligatorio y único; el rol debe existir.
+func CrearEmpleado(empleadoDto *dto.Employee) (*models.Employee, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	nombre := strings.TrimSpace(empleadoDto.Name)
+	if nombre == "" {
+		return nil, errors.New("el nombre del empleado es obligatorio"), 400
+	}
+
+	role, err := obtenerRol(gormDB, empleadoDto.RoleID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if existe, err := nombreEmpleadoEnUso(gormDB, nombre, 0); err != nil {
+		return nil, err, 500
+	} else if existe {
+		return nil, errors.New("ya existe un empleado con ese nombre"), 409
+	}
+
+	empleado := models.Employee{
+		Name:   nombre,
+		RoleID: role.ID,
+		Role:   *role,
+		Status: true,
+	}
+
+	result := gormDB.Omit("Role").Create(&empleado)
+	if result.Error != nil {
+		return nil, errors.New("No se pudo crear el empleado"), 500
+	}
+
+	return &empleado, nil, 201
+}
+
+// ActualizarEmpleado actualiza el nombre y/o el rol de un empleado.
+// Solo se modifican los campos que no están vacíos en el DTO.
+func ActualizarEmpleado(id uint, empleadoDto *dto.Employee) (*models.Employee, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var empleado models.Employee
+	if err := gormDB.Preload("Role").First(&empleado, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("No se encontró el empleado"), 404
+		}
+		return nil, err, 500
+	}
+
+	if nombre := strings.TrimSpace(empleadoDto.Name); nombre != "" {
+		if existe, err := nombreEmpleadoEnUso(gormDB, nombre, empleado.ID); err != nil {
+			return nil, err, 500
+		} else if existe {
+			return nil, errors.New("ya existe un empleado con ese nombre"), 409
+		}
+		empleado.Name = nombre
+	}
+
+	if empleadoDto.RoleID != 0 {
+		role, err := obtenerRol(gormDB, empleadoDto.RoleID)
+		if err != nil {
+			return nil, err, codigoError(err)
+		}
+		empleado.RoleID = role.ID
+		empleado.Role = *role
+	}
+
+	if err := gormDB.Omit("Role").Save(&empleado).Error; err != nil {
+		return nil, errors.New("Hubo un error al actualizar"), 500
+	}
+
+	return &empleado, nil, 200
+}
+
+// ActivarDesactivarEmpleado cambia el estado del empleado.
+// Si el empleado se va a desactivar y tiene citas futuras, la operación se rechaza con un CitasAfectadasError
+// que incluye esas citas, salvo que forzar sea true.
+func ActivarDesactivarEmpleado(id uint, forzar bool) (*models.Employee, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var empleado models.Employee
+	if err := gormDB.Preload("Role").First(&empleado, id).Error; err != nil {
+		return nil, errors.New("No se pudo encontrar el empleado"), 404
+	}
+
+	if empleado.Status && !forzar {
+		if err := verificarCitasFuturasEmpleado(gormDB, empleado.ID, "el empleado tiene citas futuras; use force=true para desactivarlo"); err != nil {
+			return nil, err, codigoError(err)
+		}
+	}
+
+	empleado.Status = !empleado.Status
+
+	if err := gormDB.Omit("Role").Save(&empleado).Error; err != nil {
+		return nil, errors.New("No se pudo actualizar el status del empleado"), 500
+	}
+
+	return &empleado, nil, 200
+}
+
+// EliminarEmpleado elimina (soft delete) un empleado.
+// Al igual que la desactivación, se rechaza si tiene citas futuras salvo que forzar sea true.
+func EliminarEmpleado(id uint, forzar bool) (bool, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err, 500
+	}
+
+	var empleado models.Employee
+	if err := gormDB.First(&empleado, id).Error; err != nil {
+		return false, errors.New("No se pudo encontrar el empleado"), 404
+	}
+
+	if !forzar {
+		if err := verificarCitasFuturasEmpleado(gormDB, empleado.ID, "el empleado tiene citas futuras; use force=true para eliminarlo"); err != nil {
+			return false, err, codigoError(err)
+		}
+	}
+
+	if err := gormDB.Delete(&empleado).Error; err != nil {
+		return false, errors.New("No se pudo eliminar el empleado"), 500
+	}
+
+	return true, nil, 200
+}
+
+// citasFuturas obtiene las citas pendientes (reservadas o reprogramadas) que aún no comienzan,
+// aplicando el filtro adicional indicado en query.
+func citasFuturas(query *gorm.DB) ([]models.Appointment, error) {
+	var citas []models.Appointment
+	err := query.Where("status IN ? AND start_at > ?", []string{models.CitaReservada, models.CitaReprogramada}, time.Now()).
+		Order("start_at").
+		Find(&citas).Error
+	return citas, err
+}
+
+// verificarCitasFuturasEmpleado retorna un CitasAfectadasError con el mensaje indicado si el empleado tiene citas futuras.
+func verificarCitasFuturasEmpleado(gormDB *gorm.DB, empleadoID uint, mensaje string) error {
+	citas, err := citasFuturas(gormDB.Where("employee_id = ?", empleadoID))
+	if err != nil {
+		return err
+	}
+
+	if len(citas) > 0 {
+		return &CitasAfectadasError{Mensaje: mensaje, Citas: citas}
+	}
+
+	return nil
+}
+
+// obtenerRol busca el rol con el ID indicado
+func obtenerRol(gormDB *gorm.DB, roleID uint) (*models.Role, error) {
+	if roleID == 0 {
+		return nil, nuevoError(400, "el rol es obligatorio")
+	}
+
+	var role models.Role
+	if err := gormDB.First(&role, roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "rol no encontrado")
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// nombreEmpleadoEnUso indica si otro empleado (distinto de excluirID) ya usa el nombre indicado.
+// Se incluyen los empleados eliminados porque el índice único de name también los considera.
+func nombreEmpleadoEnUso(gormDB *gorm.DB, nombre string, excluirID uint) (bool, error) {
+	var total int64
+	err := gormDB.Unscoped().Model(&models.Employee{}).
+		Where("name = ? AND id <> ?", nombre, excluirID).
+		Count(&total).Error
+	return total > 0, err
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrorServicio asocia un mensaje de error con el código HTTP que el handler debe devolver.
+type ErrorServicio struct {
+	Codigo  int
+	Mensaje string
+}
+
+func (e *ErrorServicio) Error() string {
+	return e.Mensaje
+}
+
+func nuevoError(codigo int, mensaje string) error {
+	return &ErrorServicio{Codigo: codigo, Mensaje: mensaje}
+}
+
+// codigoError obtiene el código HTTP de un error devuelto por la capa de servicios.
+// Los errores que no son ErrorServicio se consideran errores internos.
+func codigoError(err error) int {
+	var errServicio *ErrorServicio
+	if errors.As(err, &errServicio) {
+		return errServicio.Codigo
+	}
+
+	var conflicto *ConflictoCitaError
+	if errors.As(err, &conflicto) {
+		return 409
+	}
+
+	var afectadas *CitasAfectadasError
+	if errors.As(err, &afectadas) {
+		return 409
+	}
+
+	var bloqueo *BloqueoAccesoError
+	if errors.As(err, &bloqueo) {
+		return 429
+	}
+
+	return 500
+}
+
+// ConflictoCitaError indica que el empleado ya tiene una cita que se cruza con el horario solicitado.
+type ConflictoCitaError struct {
+	AppointmentID uint
+	StartAt       time.Time
+	EndAt         time.Time
+}
+
+func (e *ConflictoCitaError) Error() string {
+	return "el empleado ya tiene una cita en ese horario"
+}
+
+// esSolapamientoBD indica si el error proviene de la restricción de exclusión de citas en PostgreSQL.
+func esSolapamientoBD(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01" && pqErr.Constraint == models.RestriccionSolapamientoCitas
+}
+
+// CitasAfectadasError indica que la operación no se realizó porque afectaría citas futuras.
+// Citas contiene las citas que impiden la operación para que puedan reprogramarse.
+type CitasAfectadasError struct {
+	Mensaje string
+	Citas   []models.Appointment
+}
+
+func (e *CitasAfectadasError) Error() string {
+	return e.Mensaje
+}
+
+// BloqueoAccesoError indica que el login está bloqueado temporalmente por demasiados intentos fallidos.
+// ReintentarEn es el tiempo que falta para que termine el bloqueo.
+type BloqueoAccesoError struct {
+	ReintentarEn time.Duration
+}
+
+func (e *BloqueoAccesoError) Error() string {
+	return "demasiados intentos fallidos, intente más tarde"
+}
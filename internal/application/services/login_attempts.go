@@ -0,0 +1,148 @@
+package services
+
+import (
+	"backend_reservation/pkg/utils"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConfigAccesos define la protección contra ataques de fuerza bruta en el login.
+// Se permiten IntentosCuenta intentos fallidos por email e IntentosIP por dirección IP;
+// cada fallo posterior bloquea el acceso durante BloqueoBase, duplicando la espera en cada
+// nuevo fallo hasta BloqueoMaximo. Los fallos se olvidan tras VentanaIntentos sin nuevos fallos.
+type ConfigAccesos struct {
+	IntentosCuenta  int
+	IntentosIP      int
+	BloqueoBase     time.Duration
+	BloqueoMaximo   time.Duration
+	VentanaIntentos time.Duration
+}
+
+var configAccesos = ConfigAccesos{
+	IntentosCuenta:  5,
+	IntentosIP:      20,
+	BloqueoBase:     time.Minute,
+	BloqueoMaximo:   time.Hour,
+	VentanaIntentos: 24 * time.Hour,
+}
+
+// InitAccesos aplica la configuración de la protección del login. Los valores en cero conservan los valores por defecto.
+func InitAccesos(config ConfigAccesos) {
+	if config.IntentosCuenta > 0 {
+		configAccesos.IntentosCuenta = config.IntentosCuenta
+	}
+	if config.IntentosIP > 0 {
+		configAccesos.IntentosIP = config.IntentosIP
+	}
+	if config.BloqueoBase > 0 {
+		configAccesos.BloqueoBase = config.BloqueoBase
+	}
+	if config.BloqueoMaximo > 0 {
+		configAccesos.BloqueoMaximo = config.BloqueoMaximo
+	}
+	if config.VentanaIntentos > 0 {
+		configAccesos.VentanaIntentos = config.VentanaIntentos
+	}
+}
+
+// maxEntradasAccesos es la cantidad de claves a partir de la cual se descartan las entradas vencidas
+const maxEntradasAccesos = 10000
+
+// hashFicticio es un hash bcrypt con el que se compara la contraseña cuando el email no existe,
+// para que la respuesta tarde lo mismo que con un usuario real.
+var hashFicticio = sync.OnceValue(func() string {
+	hash, _ := utils.HashPassword("contraseña-ficticia")
+	return hash
+})
+
+// intentosFallidos es el registro de fallos de un email o de una IP
+type intentosFallidos struct {
+	fallos         int
+	ultimoFallo    time.Time
+	bloqueadoHasta time.Time
+}
+
+// registroAccesos lleva en memoria los intentos fallidos de login por clave ("email:..." o "ip:...")
+type registroAccesos struct {
+	mu       sync.Mutex
+	intentos map[string]*intentosFallidos
+}
+
+var accesos = &registroAccesos{intentos: make(map[string]*intentosFallidos)}
+
+func claveEmail(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func claveIP(ip string) string {
+	return "ip:" + ip
+}
+
+// bloqueo retorna el tiempo que falta para que termine el bloqueo más largo entre las claves indicadas.
+// Retorna cero si ninguna está bloqueada.
+func (r *registroAccesos) bloqueo(ahora time.Time, claves ...string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var espera time.Duration
+	for _, clave := range claves {
+		if intento, ok := r.intentos[clave]; ok && intento.bloqueadoHasta.After(ahora) {
+			espera = max(espera, intento.bloqueadoHasta.Sub(ahora))
+		}
+	}
+	return espera
+}
+
+// fallo registra un intento fallido para la clave y, si supera los intentos permitidos,
+// la bloquea con una espera que se duplica en cada fallo adicional.
+func (r *registroAccesos) fallo(clave string, permitidos int, ahora time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.intentos) >= maxEntradasAccesos {
+		r.limpiar(ahora)
+	}
+
+	intento, ok := r.intentos[clave]
+	if !ok || ahora.Sub(intento.ultimoFallo) > configAccesos.VentanaIntentos {
+		intento = &intentosFallidos{}
+		r.intentos[clave] = intento
+	}
+
+	intento.fallos++
+	intento.ultimoFallo = ahora
+
+	if exceso := intento.fallos - permitidos; exceso > 0 {
+		intento.bloqueadoHasta = ahora.Add(esperaBloqueo(exceso))
+	}
+}
+
+// olvidar elimina el registro de la clave, por ejemplo tras un login exitoso o un desbloqueo manual
+func (r *registroAccesos) olvidar(clave string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.intentos, clave)
+}
+
+// limpiar descarta las entradas que ya no están bloqueadas y cuyo último fallo salió de la ventana.
+// Debe llamarse con el candado tomado.
+func (r *registroAccesos) limpiar(ahora time.Time) {
+	for clave, intento := range r.intentos {
+		if !intento.bloqueadoHasta.After(ahora) && ahora.Sub(intento.ultimoFallo) > configAccesos.VentanaIntentos {
+			delete(r.intentos, clave)
+		}
+	}
+}
+
+// esperaBloqueo calcula la duración del bloqueo para el n-ésimo fallo por encima del límite:
+// BloqueoBase * 2^(n-1), sin superar BloqueoMaximo.
+func esperaBloqueo(exceso int) time.Duration {
+	espera := float64(configAccesos.BloqueoBase) * math.Pow(2, float64(exceso-1))
+	if espera > float64(configAccesos.BloqueoMaximo) {
+		return configAccesos.BloqueoMaximo
+	}
+	return time.Duration(espera)
+}
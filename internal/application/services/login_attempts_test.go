@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistroAccesosBloqueo(t *testing.T) {
+	registro := &registroAccesos{intentos: make(map[string]*intentosFallidos)}
+	ahora := time.Now()
+	clave := claveEmail(" Ana@Example.com ")
+
+	for range configAccesos.IntentosCuenta {
+		registro.fallo(clave, configAccesos.IntentosCuenta, ahora)
+	}
+	if espera := registro.bloqueo(ahora, clave); espera != 0 {
+		t.Fatalf("no debería bloquearse dentro de los intentos permitidos, espera %v", espera)
+	}
+
+	registro.fallo(clave, configAccesos.IntentosCuenta, ahora)
+	if espera := registro.bloqueo(ahora, claveEmail("ana@example.com")); espera != configAccesos.BloqueoBase {
+		t.Errorf("primer bloqueo = %v, se esperaba %v (el email se normaliza)", espera, configAccesos.BloqueoBase)
+	}
+
+	registro.fallo(clave, configAccesos.IntentosCuenta, ahora)
+	if espera := registro.bloqueo(ahora, clave); espera != 2*configAccesos.BloqueoBase {
+		t.Errorf("segundo bloqueo = %v, se esperaba %v", espera, 2*configAccesos.BloqueoBase)
+	}
+
+	if espera := registro.bloqueo(ahora.Add(3*configAccesos.BloqueoBase), clave); espera != 0 {
+		t.Errorf("el bloqueo debería terminar, espera %v", espera)
+	}
+
+	registro.olvidar(clave)
+	if _, ok := registro.intentos[clave]; ok {
+		t.Error("olvidar no eliminó los intentos")
+	}
+}
+
+func TestRegistroAccesosVentana(t *testing.T) {
+	registro := &registroAccesos{intentos: make(map[string]*intentosFallidos)}
+	ahora := time.Now()
+	clave := claveIP("203.0.113.7")
+
+	for range configAccesos.IntentosIP {
+		registro.fallo(clave, configAccesos.IntentosIP, ahora)
+	}
+
+	// Pasada la ventana, los fallos anteriores se olvidan
+	despues := ahora.Add(configAccesos.VentanaIntentos + time.Minute)
+	registro.fallo(clave, configAccesos.IntentosIP, despues)
+	if fallos := registro.intentos[clave].fallos; fallos != 1 {
+		t.Errorf("fallos = %d, se esperaba 1", fallos)
+	}
+}
+
+func TestEsperaBloqueo(t *testing.T) {
+	casos := []struct {
+		exceso   int
+		esperada time.Duration
+	}{
+		{1, configAccesos.BloqueoBase},
+		{2, 2 * configAccesos.BloqueoBase},
+		{3, 4 * configAccesos.BloqueoBase},
+		{100, configAccesos.BloqueoMaximo},
+	}
+
+	for _, caso := range casos {
+		if espera := esperaBloqueo(caso.exceso); espera != caso.esperada {
+			t.Errorf("esperaBloqueo(%d) = %v, se esperaba %v", caso.exceso, espera, caso.esperada)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/notificador"
+	"backend_reservation/pkg/utils"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// largoMinimoContrasena es la cantidad mínima de caracteres de una contraseña nueva
+const largoMinimoContrasena = 8
+
+// SolicitarRestablecimiento envía al usuario un token de un solo uso para restablecer su contraseña.
+// Para no revelar qué emails están registrados, no retorna error si el email no existe o la cuenta está suspendida.
+//
+// El proceso es el siguiente:
+// 1. Busca el usuario por email; si no existe o está suspendido termina sin error.
+// 2. En segundo plano, invalida los tokens de restablecimiento anteriores que sigan sin usar.
+// 3. Genera un token aleatorio, guarda su hash con la fecha de expiración y lo envía con el notificador.
+func SolicitarRestablecimiento(forgotDto *dto.ForgotPasswordDTO) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	email := strings.TrimSpace(forgotDto.Email)
+	if email == "" {
+		return errors.New("el email es obligatorio"), 400
+	}
+
+	var user models.User
+	if err := gormDB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 200
+		}
+		return err, 500
+	}
+
+	if user.Suspendido() {
+		return nil, 200
+	}
+
+	// El token se genera y se envía en segundo plano para que la respuesta tarde lo mismo
+	// exista o no el email, y el tiempo de respuesta no revele qué emails están registrados
+	go enviarRestablecimiento(gormDB, user)
+
+	return nil, 200
+}
+
+// enviarRestablecimiento invalida los tokens de restablecimiento sin usar del usuario, genera uno nuevo
+// y lo envía con el notificador. Los errores solo se registran en el log: el usuario puede volver a solicitarlo.
+// Las solicitudes que exceden el límite de envíos (el mismo de los códigos de verificación) se descartan,
+// para que no se pueda saturar el email del usuario ni invalidar continuamente su enlace.
+func enviarRestablecimiento(gormDB *gorm.DB, user models.User) {
+	permitido, err := enviosPermitidos(gormDB.Model(&models.PasswordReset{}).Where("user_id = ?", user.ID), time.Now())
+	if err != nil {
+		slog.Error("no se pudieron contar los restablecimientos enviados", "user_id", user.ID, "error", err)
+		return
+	}
+	if !permitido {
+		slog.Warn("restablecimiento de contraseña descartado por límite", "user_id", user.ID)
+		return
+	}
+
+	token, err := generarTokenAleatorio()
+	if err != nil {
+		slog.Error("no se pudo generar el token de restablecimiento", "user_id", user.ID, "error", err)
+		return
+	}
+
+	ahora := time.Now()
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", ahora).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Omit(clause.Associations).Create(&models.PasswordReset{
+			UserID:    user.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: ahora.Add(configTokens.DuracionRestablecimiento),
+		}).Error
+	})
+	if err != nil {
+		slog.Error("no se pudo guardar el token de restablecimiento", "user_id", user.ID, "error", err)
+		return
+	}
+
+	if err := notificador.Enviar(notificador.Mensaje{
+		Canal:   notificador.CanalEmail,
+		Destino: user.Email,
+		Asunto:  "Restablecer contraseña",
+		Cuerpo:  mensajeRestablecimiento(token),
+	}); err != nil {
+		slog.Error("no se pudo enviar el token de restablecimiento", "user_id", user.ID, "error", err)
+	}
+}
+
+// RestablecerContrasena cambia la contraseña del usuario dueño del token y marca el token como usado.
+// Además cierra todas las sesiones abiertas del usuario.
+func RestablecerContrasena(resetDto *dto.ResetPasswordDTO) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	if resetDto.Token == "" {
+		return errors.New("el token es obligatorio"), 400
+	}
+
+	if len([]rune(resetDto.Password)) < largoMinimoContrasena {
+		return fmt.Errorf("la contraseña debe tener al menos %d caracteres", largoMinimoContrasena), 400
+	}
+
+	hashedPassword, err := utils.HashPassword(resetDto.Password)
+	if err != nil {
+		return err, 500
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		var restablecimiento models.PasswordReset
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("token_hash = ?", hashToken(resetDto.Token)).
+			First(&restablecimiento).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nuevoError(400, "token de restablecimiento no válido o expirado")
+			}
+			return err
+		}
+
+		if !restablecimiento.Vigente() {
+			return nuevoError(400, "token de restablecimiento no válido o expirado")
+		}
+
+		if _, err := buscarUsuario(tx, restablecimiento.UserID); err != nil {
+			return nuevoError(400, "token de restablecimiento no válido o expirado")
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", restablecimiento.UserID).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&restablecimiento).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		if err := registrarAuditoria(tx, restablecimiento.UserID, 0, models.AuditoriaRestablecimiento, ""); err != nil {
+			return err
+		}
+
+		return revocarSesionesUsuario(tx, restablecimiento.UserID)
+	})
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	return nil, 200
+}
+
+// mensajeRestablecimiento arma el cuerpo del mensaje con el enlace (o el token) de restablecimiento
+func mensajeRestablecimiento(token string) string {
+	vigencia := fmt.Sprintf("El enlace vence en %d minutos.", int(configTokens.DuracionRestablecimiento.Minutes()))
+
+	if configTokens.URLRestablecimiento == "" {
+		return fmt.Sprintf("Use el siguiente código para restablecer su contraseña: %s\n%s", token, vigencia)
+	}
+
+	enlace := configTokens.URLRestablecimiento
+	separador := "?"
+	if strings.Contains(enlace, "?") {
+		separador = "&"
+	}
+
+	return fmt.Sprintf("Para restablecer su contraseña ingrese a: %s%stoken=%s\n%s", enlace, separador, url.QueryEscape(token), vigencia)
+}
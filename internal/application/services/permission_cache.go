@@ -0,0 +1,245 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"sync"
+	"time"
+)
+
+// ConfigPermisos define cuánto tiempo se conservan en memoria el rol y el estado de cada usuario,
+// los permisos de cada rol y si un token de acceso fue revocado antes de volver a consultarlos en la base de datos.
+// Las invalidaciones explícitas solo alcanzan a esta instancia: en otras, los cambios se ven al vencer la caché.
+type ConfigPermisos struct {
+	DuracionCache time.Duration
+}
+
+var configPermisos = ConfigPermisos{
+	DuracionCache: 5 * time.Minute,
+}
+
+// InitPermisos aplica la configuración de la caché de permisos. Los valores en cero conservan los valores por defecto.
+func InitPermisos(config ConfigPermisos) {
+	if config.DuracionCache > 0 {
+		configPermisos.DuracionCache = config.DuracionCache
+	}
+}
+
+// maxEntradasCache es la cantidad de entradas a partir de la cual una caché descarta las vencidas
+// y, si sigue llena, las más próximas a vencer
+const maxEntradasCache = 10000
+
+// entradaCache es un valor guardado en una cacheTTL junto con su vencimiento
+type entradaCache[V any] struct {
+	valor  V
+	expira time.Time
+}
+
+// cacheTTL guarda valores en memoria durante DuracionCache con un máximo de maxEntradasCache entradas
+type cacheTTL[K comparable, V any] struct {
+	mu       sync.Mutex
+	entradas map[K]entradaCache[V]
+}
+
+func nuevaCacheTTL[K comparable, V any]() *cacheTTL[K, V] {
+	return &cacheTTL[K, V]{entradas: make(map[K]entradaCache[V])}
+}
+
+// obtener retorna el valor guardado para la clave si no venció
+func (c *cacheTTL[K, V]) obtener(clave K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entrada, ok := c.entradas[clave]
+	if !ok || !entrada.expira.After(time.Now()) {
+		var cero V
+		return cero, false
+	}
+	return entrada.valor, true
+}
+
+// guardar guarda el valor para la clave durante DuracionCache
+func (c *cacheTTL[K, V]) guardar(clave K, valor V) {
+	ahora := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entradas[clave]; !ok && len(c.entradas) >= maxEntradasCache {
+		c.liberar(ahora)
+	}
+	c.entradas[clave] = entradaCache[V]{valor: valor, expira: ahora.Add(configPermisos.DuracionCache)}
+}
+
+// liberar descarta las entradas vencidas y, si no alcanza, la mitad de las entradas con el vencimiento más próximo
+func (c *cacheTTL[K, V]) liberar(ahora time.Time) {
+	for clave, entrada := range c.entradas {
+		if !entrada.expira.After(ahora) {
+			delete(c.entradas, clave)
+		}
+	}
+	if len(c.entradas) < maxEntradasCache {
+		return
+	}
+
+	limite := ahora.Add(configPermisos.DuracionCache / 2)
+	for clave, entrada := range c.entradas {
+		if entrada.expira.Before(limite) {
+			delete(c.entradas, clave)
+		}
+	}
+	// Si todas las entradas son recientes se descartan todas
+	if len(c.entradas) >= maxEntradasCache {
+		clear(c.entradas)
+	}
+}
+
+// borrar descarta el valor guardado para la clave
+func (c *cacheTTL[K, V]) borrar(clave K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entradas, clave)
+}
+
+// estadoUsuario es lo que se guarda en la caché de cada usuario: su rol y si su cuenta está suspendida
+type estadoUsuario struct {
+	roleID     uint
+	suspendido bool
+}
+
+// cachePermisos guarda en memoria el rol y el estado de los usuarios y los permisos de los roles.
+// Las entradas vencen tras DuracionCache y se invalidan explícitamente cuando cambian los permisos
+// de un rol o el rol o el estado de un usuario. cambios registra cuándo se cambió el rol de cada usuario
+// para descartar el rol que traen los tokens emitidos antes del cambio.
+type cachePermisos struct {
+	roles    *cacheTTL[uint, map[string]struct{}]
+	usuarios *cacheTTL[uint, estadoUsuario]
+
+	mu      sync.RWMutex
+	cambios map[uint]time.Time
+}
+
+var cacheRoles = &cachePermisos{
+	roles:    nuevaCacheTTL[uint, map[string]struct{}](),
+	usuarios: nuevaCacheTTL[uint, estadoUsuario](),
+	cambios:  make(map[uint]time.Time),
+}
+
+// TienePermiso indica si el rol del usuario tiene otorgado el permiso indicado
+func TienePermiso(userID uint, codigo string) (bool, error) {
+	roleID, err := cacheRoles.rolDeUsuario(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return RolTienePermiso(roleID, codigo)
+}
+
+// RolTienePermiso indica si el rol tiene otorgado el permiso indicado.
+// Se usa con el rol que viene en los claims del token para evitar buscar el rol del usuario.
+func RolTienePermiso(roleID uint, codigo string) (bool, error) {
+	codigos, err := cacheRoles.permisosDeRol(roleID)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := codigos[codigo]
+	return ok, nil
+}
+
+// RolCambiadoDesde indica si el rol del usuario cambió en el momento indicado o después,
+// por ejemplo después de emitir un token: en ese caso el rol del token ya no es confiable.
+func RolCambiadoDesde(userID uint, emitido time.Time) bool {
+	cacheRoles.mu.RLock()
+	defer cacheRoles.mu.RUnlock()
+
+	cambio, ok := cacheRoles.cambios[userID]
+	return ok && !cambio.Before(emitido.Truncate(time.Second))
+}
+
+// rolDeUsuario obtiene el rol del usuario (ver estadoDeUsuario)
+func (c *cachePermisos) rolDeUsuario(userID uint) (uint, error) {
+	estado, err := c.estadoDeUsuario(userID)
+	return estado.roleID, err
+}
+
+// estadoDeUsuario obtiene el rol y el estado del usuario desde la caché o, si no están o vencieron,
+// desde la base de datos. Los usuarios eliminados o inexistentes no se guardan en la caché.
+func (c *cachePermisos) estadoDeUsuario(userID uint) (estadoUsuario, error) {
+	if estado, ok := c.usuarios.obtener(userID); ok {
+		return estado, nil
+	}
+
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return estadoUsuario{}, err
+	}
+
+	usuario, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return estadoUsuario{}, err
+	}
+
+	estado := estadoUsuario{roleID: usuario.RoleID, suspendido: usuario.Suspendido()}
+	c.usuarios.guardar(userID, estado)
+	return estado, nil
+}
+
+// permisosDeRol obtiene los permisos del rol desde la caché o, si no están o vencieron, desde la base de datos
+func (c *cachePermisos) permisosDeRol(roleID uint) (map[string]struct{}, error) {
+	if codigos, ok := c.roles.obtener(roleID); ok {
+		return codigos, nil
+	}
+
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var lista []string
+	err = gormDB.Model(&models.Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Where("role_permissions.role_id = ?", roleID).
+		Pluck("permissions.code", &lista).Error
+	if err != nil {
+		return nil, err
+	}
+
+	codigos := make(map[string]struct{}, len(lista))
+	for _, codigo := range lista {
+		codigos[codigo] = struct{}{}
+	}
+
+	c.roles.guardar(roleID, codigos)
+
+	return codigos, nil
+}
+
+// invalidarRol descarta los permisos del rol guardados en la caché, por ejemplo al otorgar o revocar un permiso
+func (c *cachePermisos) invalidarRol(roleID uint) {
+	c.roles.borrar(roleID)
+}
+
+// olvidarUsuario descarta el rol y el estado del usuario guardados en la caché, por ejemplo al suspender su cuenta
+func (c *cachePermisos) olvidarUsuario(userID uint) {
+	c.usuarios.borrar(userID)
+}
+
+// invalidarUsuario descarta el rol del usuario guardado en la caché y registra el cambio
+// para que no se use el rol de los tokens emitidos antes. Los cambios más antiguos que la
+// duración del token de acceso se descartan, porque ya no quedan tokens vigentes emitidos antes de ellos.
+func (c *cachePermisos) invalidarUsuario(userID uint) {
+	ahora := time.Now()
+
+	c.usuarios.borrar(userID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, cambio := range c.cambios {
+		if ahora.Sub(cambio) > configTokens.DuracionAcceso {
+			delete(c.cambios, id)
+		}
+	}
+	c.cambios[userID] = ahora
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	cache := nuevaCacheTTL[uint, string]()
+
+	if _, ok := cache.obtener(1); ok {
+		t.Fatal("una caché vacía no debería tener entradas")
+	}
+
+	cache.guardar(1, "uno")
+	if valor, ok := cache.obtener(1); !ok || valor != "uno" {
+		t.Fatalf("obtener(1) = %q, %v; se esperaba \"uno\", true", valor, ok)
+	}
+
+	cache.borrar(1)
+	if _, ok := cache.obtener(1); ok {
+		t.Error("la entrada borrada sigue en la caché")
+	}
+
+	cache.entradas[2] = entradaCache[string]{valor: "vencida", expira: time.Now().Add(-time.Second)}
+	if _, ok := cache.obtener(2); ok {
+		t.Error("la entrada vencida no debería devolverse")
+	}
+}
+
+func TestCacheTTLLimite(t *testing.T) {
+	cache := nuevaCacheTTL[int, bool]()
+
+	for i := range maxEntradasCache * 2 {
+		cache.guardar(i, true)
+		if len(cache.entradas) > maxEntradasCache {
+			t.Fatalf("la caché superó el máximo: %d entradas", len(cache.entradas))
+		}
+	}
+
+	if _, ok := cache.obtener(maxEntradasCache*2 - 1); !ok {
+		t.Error("la última entrada guardada debería estar en la caché")
+	}
+}
+
+func TestInvalidarUsuario(t *testing.T) {
+	cache := &cachePermisos{
+		roles:    nuevaCacheTTL[uint, map[string]struct{}](),
+		usuarios: nuevaCacheTTL[uint, estadoUsuario](),
+		cambios:  make(map[uint]time.Time),
+	}
+	anterior := cacheRoles
+	cacheRoles = cache
+	t.Cleanup(func() { cacheRoles = anterior })
+
+	emitido := time.Now().Add(-time.Minute)
+	cache.usuarios.guardar(7, estadoUsuario{roleID: 3})
+
+	if RolCambiadoDesde(7, emitido) {
+		t.Error("el rol no cambió y RolCambiadoDesde indica que sí")
+	}
+
+	cache.invalidarUsuario(7)
+
+	if _, ok := cache.usuarios.obtener(7); ok {
+		t.Error("invalidarUsuario no descartó el estado del usuario")
+	}
+	if !RolCambiadoDesde(7, emitido) {
+		t.Error("el token emitido antes del cambio de rol debería descartar su rol")
+	}
+	if RolCambiadoDesde(7, time.Now().Add(time.Minute)) {
+		t.Error("un token emitido después del cambio de rol conserva su rol")
+	}
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/utils"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errContrasenaActual se retorna cuando la contraseña actual enviada para confirmar un cambio no coincide
+var errContrasenaActual = nuevoError(403, "la contraseña actual es incorrecta")
+
+// ActualizarPerfil actualiza el nombre y/o el teléfono del usuario.
+// Si el teléfono cambia, deja de estar verificado y se envía un código al número nuevo.
+func ActualizarPerfil(userID uint, perfilDto *dto.UpdateProfileDTO) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	cambios := map[string]any{}
+
+	if perfilDto.Name != nil {
+		nombre := strings.TrimSpace(*perfilDto.Name)
+		if nombre == "" {
+			return nil, errors.New("el nombre no puede estar vacío"), 400
+		}
+		if len([]rune(nombre)) > 255 {
+			return nil, errors.New("el nombre no puede superar los 255 caracteres"), 400
+		}
+		cambios["name"] = nombre
+	}
+
+	telefonoNuevo := false
+	if perfilDto.Phone != nil {
+		telefono := strings.TrimSpace(*perfilDto.Phone)
+		if telefono == "" {
+			return nil, errors.New("el teléfono no puede estar vacío"), 400
+		}
+		if telefono != user.Phone {
+			if enUso, err := datoEnUso(gormDB, "phone", telefono, user.ID); err != nil {
+				return nil, err, 500
+			} else if enUso {
+				return nil, errors.New("el teléfono ya está registrado"), 409
+			}
+			cambios["phone"] = telefono
+			cambios["phone_verified_at"] = nil
+			telefonoNuevo = true
+		}
+	}
+
+	if len(cambios) == 0 {
+		return user, nil, 200
+	}
+
+	if err := gormDB.Model(user).Updates(cambios).Error; err != nil {
+		return nil, errors.New("No se pudo actualizar el perfil"), 500
+	}
+
+	user, err = buscarUsuario(gormDB, userID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if telefonoNuevo {
+		if err := enviarCodigoVerificacion(gormDB, user, models.VerificacionTelefono); err != nil {
+			return nil, errors.New("Se actualizó el perfil, pero no se pudo enviar el código de verificación del teléfono"), 500
+		}
+	}
+
+	return user, nil, 200
+}
+
+// SolicitarCambioEmail envía un código de confirmación al email nuevo.
+// El email del usuario no cambia hasta confirmar el código con ConfirmarCambioEmail.
+func SolicitarCambioEmail(userID uint, emailDto *dto.ChangeEmailDTO) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	email := strings.TrimSpace(emailDto.Email)
+	if email == "" {
+		return errors.New("el email es obligatorio"), 400
+	}
+	if direccion, err := mail.ParseAddress(email); err != nil || direccion.Address != email {
+		return errors.New("el email no es válido"), 400
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	if !utils.ComparePassword(user.Password, emailDto.Password) {
+		return errContrasenaActual, 403
+	}
+
+	if strings.EqualFold(email, user.Email) {
+		return errors.New("el email nuevo es igual al actual"), 400
+	}
+
+	if enUso, err := datoEnUso(gormDB, "email", email, user.ID); err != nil {
+		return err, 500
+	} else if enUso {
+		return errors.New("el email ya está registrado"), 409
+	}
+
+	if err := enviarCodigo(gormDB, user.ID, models.VerificacionNuevoEmail, email); err != nil {
+		return errors.New("No se pudo enviar el código de confirmación"), 500
+	}
+
+	return nil, 200
+}
+
+// ConfirmarCambioEmail aplica el cambio de email pendiente si el código es correcto.
+// El email nuevo queda verificado, ya que el código llegó a esa dirección.
+func ConfirmarCambioEmail(userID uint, codigo string) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if strings.TrimSpace(codigo) == "" {
+		return nil, errors.New("el código es obligatorio"), 400
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		user, err := bloquearUsuario(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var registro models.VerificationCode
+		err = tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("user_id = ? AND channel = ? AND used_at IS NULL", user.ID, models.VerificacionNuevoEmail).
+			Order("created_at DESC").
+			First(&registro).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errCodigoNoValido
+			}
+			return err
+		}
+
+		if time.Now().After(registro.ExpiresAt) || registro.Attempts >= intentosCodigoVerificacion {
+			return errCodigoNoValido
+		}
+
+		if subtle.ConstantTimeCompare([]byte(registro.CodeHash), []byte(hashToken(strings.TrimSpace(codigo)))) != 1 {
+			return &intentoFallido{codigoID: registro.ID}
+		}
+
+		// El email pudo registrarse por otro usuario desde que se envió el código
+		if enUso, err := datoEnUso(tx, "email", registro.Destination, user.ID); err != nil {
+			return err
+		} else if enUso {
+			return nuevoError(409, "el email ya está registrado")
+		}
+
+		ahora := time.Now()
+		if err := tx.Model(&registro).Update("used_at", ahora).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(user).Updates(map[string]any{
+			"email":             registro.Destination,
+			"email_verified_at": ahora,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return registrarAuditoria(tx, user.ID, user.ID, models.AuditoriaEmail, "")
+	})
+
+	var fallido *intentoFallido
+	if errors.As(err, &fallido) {
+		gormDB.Model(&models.VerificationCode{}).Where("id = ?", fallido.codigoID).
+			UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		return nil, errCodigoNoValido, 400
+	}
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return user, nil, 200
+}
+
+// CambiarContrasena cambia la contraseña del usuario tras validar la actual.
+// Se cierran las demás sesiones del usuario; sesionActual (si no es cero) se conserva.
+func CambiarContrasena(userID, sesionActual uint, contrasenaDto *dto.ChangePasswordDTO) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	if len([]rune(contrasenaDto.NewPassword)) < largoMinimoContrasena {
+		return fmt.Errorf("la contraseña debe tener al menos %d caracteres", largoMinimoContrasena), 400
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	if !utils.ComparePassword(user.Password, contrasenaDto.CurrentPassword) {
+		return errContrasenaActual, 403
+	}
+
+	hashedPassword, err := utils.HashPassword(contrasenaDto.NewPassword)
+	if err != nil {
+		return err, 500
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		if err := registrarAuditoria(tx, user.ID, user.ID, models.AuditoriaContrasena, ""); err != nil {
+			return err
+		}
+		return revocarOtrasSesiones(tx, user.ID, sesionActual)
+	})
+	if err != nil {
+		return errors.New("No se pudo cambiar la contraseña"), 500
+	}
+
+	return nil, 200
+}
+
+// EliminarCuenta elimina (soft delete) la cuenta del usuario tras validar su contraseña.
+// Se cancelan sus citas futuras y se cierran todas sus sesiones.
+func EliminarCuenta(userID uint, eliminarDto *dto.DeleteAccountDTO) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	if !utils.ComparePassword(user.Password, eliminarDto.Password) {
+		return errContrasenaActual, 403
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := cancelarCitasFuturasUsuario(tx, user.ID); err != nil {
+			return err
+		}
+
+		if err := revocarSesionesUsuario(tx, user.ID); err != nil {
+			return err
+		}
+
+		if err := registrarAuditoria(tx, user.ID, user.ID, models.AuditoriaEliminacion, ""); err != nil {
+			return err
+		}
+
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		return errors.New("No se pudo eliminar la cuenta"), 500
+	}
+
+	return nil, 200
+}
+
+// cancelarCitasFuturasUsuario cancela las citas reservadas o reprogramadas del usuario que aún no comienzan
+func cancelarCitasFuturasUsuario(tx *gorm.DB, userID uint) error {
+	ahora := time.Now()
+	return tx.Model(&models.Appointment{}).
+		Where("user_id = ? AND start_at > ? AND status IN ?", userID, ahora, []string{models.CitaReservada, models.CitaReprogramada}).
+		Updates(map[string]any{"status": models.CitaCancelada, "cancelled_at": ahora}).Error
+}
+
+// datoEnUso indica si otro usuario (incluidos los eliminados, por la restricción única) ya usa el valor en la columna
+func datoEnUso(tx *gorm.DB, columna, valor string, excluirID uint) (bool, error) {
+	var total int64
+	err := tx.Unscoped().Model(&models.User{}).Where(columna+" = ? AND id <> ?", valor, excluirID).Count(&total).Error
+	return total > 0, err
+}
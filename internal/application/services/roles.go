@@ -0,0 +1,250 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// codigoRolValido limita los códigos de rol a minúsculas, dígitos, guiones y guiones bajos
+var codigoRolValido = regexp.MustCompile(`^[a-z0-9_-]{2,50}$`)
+
+// RolPermisos es un rol junto con los permisos que tiene otorgados
+type RolPermisos struct {
+	Rol      models.Role
+	Permisos []models.Permission
+}
+
+// ObtenerPermisos lista el catálogo de permisos
+func ObtenerPermisos() ([]models.Permission, error) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var permisos []models.Permission
+	if err := gormDB.Order("code").Find(&permisos).Error; err != nil {
+		return nil, err
+	}
+
+	return permisos, nil
+}
+
+// ObtenerRoles lista los roles con sus permisos
+func ObtenerRoles() ([]RolPermisos, error) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []models.Role
+	if err := gormDB.Order("code").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	var otorgados []models.RolePermission
+	if err := gormDB.Preload("Permission").Find(&otorgados).Error; err != nil {
+		return nil, err
+	}
+
+	permisos := make(map[uint][]models.Permission)
+	for _, otorgado := range otorgados {
+		permisos[otorgado.RoleID] = append(permisos[otorgado.RoleID], otorgado.Permission)
+	}
+
+	resultado := make([]RolPermisos, len(roles))
+	for i, role := range roles {
+		resultado[i] = RolPermisos{Rol: role, Permisos: permisos[role.ID]}
+	}
+
+	return resultado, nil
+}
+
+// ObtenerRol retorna un rol con sus permisos
+func ObtenerRol(id uint) (*RolPermisos, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	rol, err := rolPermisos(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return rol, nil, 200
+}
+
+// CrearRol crea un rol y le otorga los permisos indicados
+func CrearRol(rolDto *dto.Role) (*RolPermisos, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	code := strings.ToLower(strings.TrimSpace(rolDto.Code))
+	if !codigoRolValido.MatchString(code) {
+		return nil, errors.New("el código del rol debe tener entre 2 y 50 letras minúsculas, dígitos, guiones o guiones bajos"), 400
+	}
+
+	descripcion := strings.TrimSpace(rolDto.Description)
+	if descripcion == "" {
+		return nil, errors.New("la descripción del rol es obligatoria"), 400
+	}
+
+	var rol *RolPermisos
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		var total int64
+		if err := tx.Unscoped().Model(&models.Role{}).Where("code = ?", code).Count(&total).Error; err != nil {
+			return err
+		}
+		if total > 0 {
+			return nuevoError(409, "ya existe un rol con ese código")
+		}
+
+		role := models.Role{Code: code, Description: descripcion}
+		if err := tx.Create(&role).Error; err != nil {
+			return err
+		}
+
+		for _, codigo := range rolDto.Permissions {
+			permiso, err := buscarPermiso(tx, codigo)
+			if err != nil {
+				return err
+			}
+			if err := otorgarPermiso(tx, role.ID, permiso.ID); err != nil {
+				return err
+			}
+		}
+
+		rol, err = rolPermisos(tx, role.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return rol, nil, 201
+}
+
+// OtorgarPermiso otorga un permiso a un rol. Otorgar un permiso que el rol ya tiene no produce cambios.
+func OtorgarPermiso(roleID uint, codigo string) (*RolPermisos, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	role, err := obtenerRol(gormDB, roleID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	permiso, err := buscarPermiso(gormDB, codigo)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if err := otorgarPermiso(gormDB, role.ID, permiso.ID); err != nil {
+		return nil, errors.New("No se pudo otorgar el permiso"), 500
+	}
+	cacheRoles.invalidarRol(role.ID)
+
+	rol, err := rolPermisos(gormDB, role.ID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return rol, nil, 200
+}
+
+// RevocarPermiso quita un permiso a un rol.
+// El rol admin conserva siempre todos los permisos, y un usuario no puede quitarle a su propio rol
+// el permiso para administrar roles, para evitar quedarse sin acceso.
+func RevocarPermiso(adminID, roleID uint, codigo string) (*RolPermisos, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	role, err := obtenerRol(gormDB, roleID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if role.Code == models.RolAdmin {
+		return nil, errors.New("el rol admin tiene siempre todos los permisos"), 409
+	}
+
+	permiso, err := buscarPermiso(gormDB, codigo)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if permiso.Code == models.PermisoRolesEscribir {
+		admin, err := buscarUsuario(gormDB, adminID)
+		if err != nil {
+			return nil, err, codigoError(err)
+		}
+		if admin.RoleID == role.ID {
+			return nil, errors.New("no puede quitarle a su propio rol el permiso para administrar roles"), 403
+		}
+	}
+
+	result := gormDB.Where("role_id = ? AND permission_id = ?", role.ID, permiso.ID).Delete(&models.RolePermission{})
+	if result.Error != nil {
+		return nil, errors.New("No se pudo revocar el permiso"), 500
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("el rol no tiene ese permiso"), 404
+	}
+	cacheRoles.invalidarRol(role.ID)
+
+	rol, err := rolPermisos(gormDB, role.ID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return rol, nil, 200
+}
+
+// otorgarPermiso registra el permiso del rol si aún no lo tiene
+func otorgarPermiso(tx *gorm.DB, roleID, permisoID uint) error {
+	otorgado := models.RolePermission{RoleID: roleID, PermissionID: permisoID}
+	return tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&otorgado).Error
+}
+
+// buscarPermiso busca un permiso por su código
+func buscarPermiso(tx *gorm.DB, codigo string) (*models.Permission, error) {
+	var permiso models.Permission
+	if err := tx.Where("code = ?", strings.TrimSpace(codigo)).First(&permiso).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "permiso no encontrado: "+codigo)
+		}
+		return nil, err
+	}
+	return &permiso, nil
+}
+
+// rolPermisos obtiene un rol junto con sus permisos ordenados por código
+func rolPermisos(tx *gorm.DB, roleID uint) (*RolPermisos, error) {
+	role, err := obtenerRol(tx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var permisos []models.Permission
+	err = tx.Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Where("role_permissions.role_id = ?", role.ID).
+		Order("permissions.code").
+		Find(&permisos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &RolPermisos{Rol: *role, Permisos: permisos}, nil
+}
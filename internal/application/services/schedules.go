@@ -0,0 +1,369 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ObtenerHorarioEmpleado lista los bloques del horario semanal de un empleado ordenados por día y hora
+func ObtenerHorarioEmpleado(empleadoID uint) ([]models.EmployeeSchedule, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if err := existeEmpleado(gormDB, empleadoID); err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	var bloques []models.EmployeeSchedule
+	if err := gormDB.Where("employee_id = ?", empleadoID).Order("weekday, start_time").Find(&bloques).Error; err != nil {
+		return nil, err, 500
+	}
+
+	return bloques, nil, 200
+}
+
+// ActualizarHorarioEmpleado reemplaza el horario semanal completo de un empleado.
+// Cada bloque debe indicar el día de la semana (0 = domingo), el tipo (shift o break) y un rango HH:MM válido.
+// Los turnos de un mismo día no pueden cruzarse. Si el empleado queda sin turnos para un día,
+// ese día se usa el horario general del local.
+func ActualizarHorarioEmpleado(empleadoID uint, horarioDto *dto.WeeklySchedule) ([]models.EmployeeSchedule, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if err := existeEmpleado(gormDB, empleadoID); err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	porDia := make(map[time.Weekday][]dto.ScheduleBlock)
+	for _, bloque := range horarioDto.Blocks {
+		if bloque.Weekday < time.Sunday || bloque.Weekday > time.Saturday {
+			return nil, errors.New("el día de la semana debe estar entre 0 (domingo) y 6 (sábado)"), 400
+		}
+		porDia[bloque.Weekday] = append(porDia[bloque.Weekday], bloque)
+	}
+
+	for _, bloquesDia := range porDia {
+		if err := validarBloques(bloquesDia, false); err != nil {
+			return nil, err, 400
+		}
+	}
+
+	bloques := make([]models.EmployeeSchedule, len(horarioDto.Blocks))
+	for i, bloque := range horarioDto.Blocks {
+		bloques[i] = models.EmployeeSchedule{
+			EmployeeID: empleadoID,
+			Weekday:    bloque.Weekday,
+			Kind:       bloque.Kind,
+			StartTime:  bloque.StartTime,
+			EndTime:    bloque.EndTime,
+		}
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("employee_id = ?", empleadoID).Delete(&models.EmployeeSchedule{}).Error; err != nil {
+			return err
+		}
+
+		if len(bloques) == 0 {
+			return nil
+		}
+
+		return tx.Omit("Employee").Create(&bloques).Error
+	})
+
+	if err != nil {
+		return nil, errors.New("No se pudo actualizar el horario del empleado"), 500
+	}
+
+	sort.Slice(bloques, func(i, j int) bool {
+		if bloques[i].Weekday != bloques[j].Weekday {
+			return bloques[i].Weekday < bloques[j].Weekday
+		}
+		return bloques[i].StartTime < bloques[j].StartTime
+	})
+
+	return bloques, nil, 200
+}
+
+// ObtenerExcepcionesEmpleado lista las excepciones de horario de un empleado en el rango de fechas indicado.
+// Las fechas vacías no limitan el rango.
+func ObtenerExcepcionesEmpleado(empleadoID uint, desde, hasta string) ([]models.EmployeeScheduleOverride, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if err := existeEmpleado(gormDB, empleadoID); err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	query := gormDB.Where("employee_id = ?", empleadoID)
+
+	if desde != "" {
+		if _, err := time.Parse("2006-01-02", desde); err != nil {
+			return nil, errors.New("fecha inicial no válida, formato esperado YYYY-MM-DD"), 400
+		}
+		query = query.Where("date >= ?", desde)
+	}
+
+	if hasta != "" {
+		if _, err := time.Parse("2006-01-02", hasta); err != nil {
+			return nil, errors.New("fecha final no válida, formato esperado YYYY-MM-DD"), 400
+		}
+		query = query.Where("date <= ?", hasta)
+	}
+
+	var excepciones []models.EmployeeScheduleOverride
+	if err := query.Order("date, start_time").Find(&excepciones).Error; err != nil {
+		return nil, err, 500
+	}
+
+	return excepciones, nil, 200
+}
+
+// GuardarExcepcionEmpleado reemplaza el horario del empleado en una fecha concreta.
+// Los bloques pueden ser turnos y descansos; un bloque off (o una lista sin turnos) marca el día como libre.
+func GuardarExcepcionEmpleado(empleadoID uint, excepcionDto *dto.ScheduleOverride) ([]models.EmployeeScheduleOverride, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if err := existeEmpleado(gormDB, empleadoID); err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	fecha, err := time.Parse("2006-01-02", excepcionDto.Date)
+	if err != nil {
+		return nil, errors.New("fecha no válida, formato esperado YYYY-MM-DD"), 400
+	}
+
+	if err := validarBloques(excepcionDto.Blocks, true); err != nil {
+		return nil, err, 400
+	}
+
+	excepciones := []models.EmployeeScheduleOverride{}
+	tieneTurnos := false
+	for _, bloque := range excepcionDto.Blocks {
+		if bloque.Kind == models.BloqueLibre {
+			continue
+		}
+		if bloque.Kind == models.BloqueTurno {
+			tieneTurnos = true
+		}
+		excepciones = append(excepciones, models.EmployeeScheduleOverride{
+			EmployeeID: empleadoID,
+			Date:       fecha,
+			Kind:       bloque.Kind,
+			StartTime:  bloque.StartTime,
+			EndTime:    bloque.EndTime,
+		})
+	}
+
+	// Un día sin turnos se registra como un único bloque libre
+	if !tieneTurnos {
+		excepciones = []models.EmployeeScheduleOverride{{
+			EmployeeID: empleadoID,
+			Date:       fecha,
+			Kind:       models.BloqueLibre,
+		}}
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("employee_id = ? AND date = ?", empleadoID, excepcionDto.Date).
+			Delete(&models.EmployeeScheduleOverride{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Employee").Create(&excepciones).Error
+	})
+
+	if err != nil {
+		return nil, errors.New("No se pudo guardar la excepción de horario"), 500
+	}
+
+	return excepciones, nil, 200
+}
+
+// EliminarExcepcionEmpleado elimina las excepciones de horario del empleado en la fecha indicada,
+// de modo que vuelve a aplicarse su horario semanal.
+func EliminarExcepcionEmpleado(empleadoID uint, fecha string) (bool, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err, 500
+	}
+
+	if _, err := time.Parse("2006-01-02", fecha); err != nil {
+		return false, errors.New("fecha no válida, formato esperado YYYY-MM-DD"), 400
+	}
+
+	result := gormDB.Unscoped().Where("employee_id = ? AND date = ?", empleadoID, fecha).
+		Delete(&models.EmployeeScheduleOverride{})
+	if result.Error != nil {
+		return false, errors.New("No se pudo eliminar la excepción de horario"), 500
+	}
+
+	if result.RowsAffected == 0 {
+		return false, errors.New("el empleado no tiene excepciones en esa fecha"), 404
+	}
+
+	return true, nil, 200
+}
+
+// horarioEmpleado devuelve los intervalos en los que el empleado puede atender en la fecha indicada.
+//
+// El horario se determina en este orden:
+// 1. Si el empleado tiene excepciones para la fecha, se usan solo esas excepciones (un bloque off significa día libre).
+// 2. Si no, se usan los turnos y descansos de su horario semanal para ese día de la semana.
+// 3. Si no tiene turnos ese día, se usa el horario general del día de atención (menos sus descansos semanales).
+func horarioEmpleado(tx *gorm.DB, dia *models.Day, empleadoID uint, fecha time.Time) ([]intervalo, error) {
+	var turnos, descansos []intervalo
+
+	var excepciones []models.EmployeeScheduleOverride
+	if err := tx.Where("employee_id = ? AND date = ?", empleadoID, fecha.Format("2006-01-02")).Find(&excepciones).Error; err != nil {
+		return nil, err
+	}
+
+	if len(excepciones) > 0 {
+		for _, excepcion := range excepciones {
+			if excepcion.Kind == models.BloqueLibre {
+				return nil, nil
+			}
+			bloque, err := intervaloEnFecha(fecha, excepcion.StartTime, excepcion.EndTime)
+			if err != nil {
+				return nil, err
+			}
+			if excepcion.Kind == models.BloqueDescanso {
+				descansos = append(descansos, bloque)
+			} else {
+				turnos = append(turnos, bloque)
+			}
+		}
+		return restarIntervalos(turnos, descansos), nil
+	}
+
+	var semanales []models.EmployeeSchedule
+	if err := tx.Where("employee_id = ? AND weekday = ?", empleadoID, fecha.Weekday()).Find(&semanales).Error; err != nil {
+		return nil, err
+	}
+
+	for _, semanal := range semanales {
+		bloque, err := intervaloEnFecha(fecha, semanal.StartTime, semanal.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		if semanal.Kind == models.BloqueDescanso {
+			descansos = append(descansos, bloque)
+		} else {
+			turnos = append(turnos, bloque)
+		}
+	}
+
+	if len(turnos) == 0 {
+		apertura, cierre := dia.Ventana(fecha)
+		turnos = []intervalo{{inicio: apertura, fin: cierre}}
+	}
+
+	return restarIntervalos(turnos, descansos), nil
+}
+
+// validarBloques verifica el tipo y el rango horario de los bloques de un mismo día,
+// y que los turnos no se crucen entre sí. permitirLibre habilita el tipo off (solo en excepciones).
+func validarBloques(bloques []dto.ScheduleBlock, permitirLibre bool) error {
+	var turnos []intervalo
+	referencia := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, bloque := range bloques {
+		switch bloque.Kind {
+		case models.BloqueTurno, models.BloqueDescanso:
+		case models.BloqueLibre:
+			if permitirLibre {
+				continue
+			}
+			return errors.New("el tipo off solo se permite en excepciones")
+		default:
+			return fmt.Errorf("tipo de bloque no válido: %q", bloque.Kind)
+		}
+
+		rango, err := intervaloEnFecha(referencia, bloque.StartTime, bloque.EndTime)
+		if err != nil {
+			return err
+		}
+
+		if !rango.fin.After(rango.inicio) {
+			return fmt.Errorf("la hora de fin debe ser posterior a la de inicio (%s - %s)", bloque.StartTime, bloque.EndTime)
+		}
+
+		if bloque.Kind == models.BloqueTurno {
+			for _, turno := range turnos {
+				if turno.solapa(rango.inicio, rango.fin) {
+					return fmt.Errorf("el turno %s - %s se cruza con otro turno del mismo día", bloque.StartTime, bloque.EndTime)
+				}
+			}
+			turnos = append(turnos, rango)
+		}
+	}
+
+	return nil
+}
+
+// intervaloEnFecha construye el intervalo [inicio, fin) en la fecha indicada a partir de horas en formato HH:MM
+func intervaloEnFecha(fecha time.Time, inicio, fin string) (intervalo, error) {
+	horaInicio, err := time.Parse("15:04", inicio)
+	if err != nil {
+		return intervalo{}, fmt.Errorf("hora no válida %q, formato esperado HH:MM", inicio)
+	}
+
+	horaFin, err := time.Parse("15:04", fin)
+	if err != nil {
+		return intervalo{}, fmt.Errorf("hora no válida %q, formato esperado HH:MM", fin)
+	}
+
+	anio, mes, dia := fecha.Date()
+	return intervalo{
+		inicio: time.Date(anio, mes, dia, horaInicio.Hour(), horaInicio.Minute(), 0, 0, fecha.Location()),
+		fin:    time.Date(anio, mes, dia, horaFin.Hour(), horaFin.Minute(), 0, 0, fecha.Location()),
+	}, nil
+}
+
+// restarIntervalos quita de cada intervalo base las partes que se cruzan con los intervalos a quitar
+func restarIntervalos(base, quitar []intervalo) []intervalo {
+	resultado := base
+	for _, q := range quitar {
+		var siguiente []intervalo
+		for _, b := range resultado {
+			if !b.solapa(q.inicio, q.fin) {
+				siguiente = append(siguiente, b)
+				continue
+			}
+			if b.inicio.Before(q.inicio) {
+				siguiente = append(siguiente, intervalo{inicio: b.inicio, fin: q.inicio})
+			}
+			if q.fin.Before(b.fin) {
+				siguiente = append(siguiente, intervalo{inicio: q.fin, fin: b.fin})
+			}
+		}
+		resultado = siguiente
+	}
+	return resultado
+}
+
+// existeEmpleado verifica que el empleado exista (sin importar su estado)
+func existeEmpleado(gormDB *gorm.DB, empleadoID uint) error {
+	if err := gormDB.First(&models.Employee{}, empleadoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nuevoError(404, "No se encontró el empleado")
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"testing"
+)
+
+func TestRestarIntervalos(t *testing.T) {
+	jornada := []intervalo{{inicio: hora(9, 0), fin: hora(18, 0)}}
+
+	casos := []struct {
+		nombre   string
+		base     []intervalo
+		quitar   []intervalo
+		esperado []intervalo
+	}{
+		{
+			nombre:   "sin nada que quitar",
+			base:     jornada,
+			esperado: jornada,
+		},
+		{
+			nombre:   "descanso que parte el turno",
+			base:     jornada,
+			quitar:   []intervalo{{inicio: hora(13, 0), fin: hora(14, 0)}},
+			esperado: []intervalo{{inicio: hora(9, 0), fin: hora(13, 0)}, {inicio: hora(14, 0), fin: hora(18, 0)}},
+		},
+		{
+			nombre:   "cierre al inicio",
+			base:     jornada,
+			quitar:   []intervalo{{inicio: hora(8, 0), fin: hora(10, 0)}},
+			esperado: []intervalo{{inicio: hora(10, 0), fin: hora(18, 0)}},
+		},
+		{
+			nombre:   "cierre que cubre toda la jornada",
+			base:     jornada,
+			quitar:   []intervalo{{inicio: hora(0, 0), fin: hora(23, 59)}},
+			esperado: nil,
+		},
+		{
+			// Los intervalos son semiabiertos: lo que solo toca el borde no recorta nada
+			nombre:   "cierre adyacente",
+			base:     jornada,
+			quitar:   []intervalo{{inicio: hora(18, 0), fin: hora(20, 0)}},
+			esperado: jornada,
+		},
+		{
+			nombre: "varios cierres sobre dos turnos",
+			base:   []intervalo{{inicio: hora(9, 0), fin: hora(12, 0)}, {inicio: hora(14, 0), fin: hora(18, 0)}},
+			quitar: []intervalo{{inicio: hora(11, 0), fin: hora(15, 0)}, {inicio: hora(16, 0), fin: hora(16, 30)}},
+			esperado: []intervalo{
+				{inicio: hora(9, 0), fin: hora(11, 0)},
+				{inicio: hora(15, 0), fin: hora(16, 0)},
+				{inicio: hora(16, 30), fin: hora(18, 0)},
+			},
+		},
+	}
+
+	for _, caso := range casos {
+		resultado := restarIntervalos(caso.base, caso.quitar)
+		if len(resultado) != len(caso.esperado) {
+			t.Errorf("%s: restarIntervalos = %v, se esperaba %v", caso.nombre, resultado, caso.esperado)
+			continue
+		}
+		for i := range resultado {
+			if !resultado[i].inicio.Equal(caso.esperado[i].inicio) || !resultado[i].fin.Equal(caso.esperado[i].fin) {
+				t.Errorf("%s: restarIntervalos = %v, se esperaba %v", caso.nombre, resultado, caso.esperado)
+				break
+			}
+		}
+	}
+}
+
+func TestValidarBloques(t *testing.T) {
+	turno := func(inicio, fin string) dto.ScheduleBlock {
+		return dto.ScheduleBlock{Kind: models.BloqueTurno, StartTime: inicio, EndTime: fin}
+	}
+	descanso := dto.ScheduleBlock{Kind: models.BloqueDescanso, StartTime: "13:00", EndTime: "14:00"}
+	libre := dto.ScheduleBlock{Kind: models.BloqueLibre}
+
+	casos := []struct {
+		nombre        string
+		bloques       []dto.ScheduleBlock
+		permitirLibre bool
+		valido        bool
+	}{
+		{nombre: "turno con descanso", bloques: []dto.ScheduleBlock{turno("09:00", "18:00"), descanso}, valido: true},
+		{nombre: "turnos adyacentes", bloques: []dto.ScheduleBlock{turno("09:00", "13:00"), turno("13:00", "18:00")}, valido: true},
+		{nombre: "turnos que se cruzan", bloques: []dto.ScheduleBlock{turno("09:00", "13:30"), turno("13:00", "18:00")}},
+		{nombre: "fin anterior al inicio", bloques: []dto.ScheduleBlock{turno("18:00", "09:00")}},
+		{nombre: "sin duración", bloques: []dto.ScheduleBlock{turno("09:00", "09:00")}},
+		{nombre: "hora no válida", bloques: []dto.ScheduleBlock{turno("9am", "18:00")}},
+		{nombre: "tipo desconocido", bloques: []dto.ScheduleBlock{{Kind: "vacation", StartTime: "09:00", EndTime: "18:00"}}},
+		{nombre: "día libre en el horario semanal", bloques: []dto.ScheduleBlock{libre}},
+		{nombre: "día libre en una excepción", bloques: []dto.ScheduleBlock{libre}, permitirLibre: true, valido: true},
+	}
+
+	for _, caso := range casos {
+		err := validarBloques(caso.bloques, caso.permitirLibre)
+		if (err == nil) != caso.valido {
+			t.Errorf("%s: validarBloques = %v, se esperaba válido = %v", caso.nombre, err, caso.valido)
+		}
+	}
+}
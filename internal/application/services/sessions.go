@@ -0,0 +1,164 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// intervaloActividad es el tiempo mínimo entre dos actualizaciones de LastSeenAt de una misma sesión,
+// para no escribir en la base de datos en cada solicitud
+const intervaloActividad = time.Minute
+
+// actividadSesiones recuerda cuándo se actualizó por última vez LastSeenAt de cada sesión
+var actividadSesiones = struct {
+	mu      sync.Mutex
+	ultimas map[uint]time.Time
+}{ultimas: make(map[uint]time.Time)}
+
+// ObtenerSesiones lista las sesiones activas del usuario, de la más reciente a la más antigua
+func ObtenerSesiones(userID uint) ([]models.Session, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if _, err := buscarUsuario(gormDB, userID); err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	var sesiones []models.Session
+	if err := gormDB.Scopes(models.SesionActiva).Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&sesiones).Error; err != nil {
+		return nil, err, 500
+	}
+
+	return sesiones, nil, 200
+}
+
+// CerrarSesionRemota cierra una sesión activa del usuario: revoca su familia de tokens de renovación
+// y los tokens de acceso emitidos con ella
+func CerrarSesionRemota(userID, sesionID uint) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		var sesion models.Session
+		err := tx.Scopes(models.SesionActiva).Where("id = ? AND user_id = ?", sesionID, userID).First(&sesion).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nuevoError(404, "sesión no encontrada")
+			}
+			return err
+		}
+
+		return revocarFamilia(tx, sesion.FamilyID)
+	})
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	return nil, 200
+}
+
+// CerrarSesionesUsuario cierra todas las sesiones del usuario
+func CerrarSesionesUsuario(userID uint) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	if _, err := buscarUsuario(gormDB, userID); err != nil {
+		return err, codigoError(err)
+	}
+
+	if err := gormDB.Transaction(func(tx *gorm.DB) error {
+		return revocarSesionesUsuario(tx, userID)
+	}); err != nil {
+		return errors.New("No se pudieron cerrar las sesiones"), 500
+	}
+
+	return nil, 200
+}
+
+// RegistrarActividadSesion actualiza la última actividad de la sesión, como máximo una vez por intervaloActividad
+func RegistrarActividadSesion(sesionID uint) error {
+	ahora := time.Now()
+
+	actividadSesiones.mu.Lock()
+	if ultima, ok := actividadSesiones.ultimas[sesionID]; ok && ahora.Sub(ultima) < intervaloActividad {
+		actividadSesiones.mu.Unlock()
+		return nil
+	}
+	for id, ultima := range actividadSesiones.ultimas {
+		if ahora.Sub(ultima) >= intervaloActividad {
+			delete(actividadSesiones.ultimas, id)
+		}
+	}
+	actividadSesiones.ultimas[sesionID] = ahora
+	actividadSesiones.mu.Unlock()
+
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err
+	}
+
+	return gormDB.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", sesionID).Update("last_seen_at", ahora).Error
+}
+
+// registrarSesion crea la sesión de la familia o, si ya existe, actualiza el dispositivo, la última actividad y su expiración
+func registrarSesion(tx *gorm.DB, userID uint, familia string, dispositivo Dispositivo, expiraEn time.Time) (*models.Session, error) {
+	sesion := models.Session{
+		UserID:     userID,
+		FamilyID:   familia,
+		UserAgent:  recortar(dispositivo.UserAgent, 512),
+		IP:         recortar(dispositivo.IP, 64),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  expiraEn,
+	}
+
+	err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "family_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_agent", "ip", "last_seen_at", "expires_at", "updated_at"}),
+	}).Create(&sesion).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &sesion, nil
+}
+
+// revocarOtrasSesiones cierra todas las sesiones del usuario excepto la indicada (si no es cero)
+func revocarOtrasSesiones(tx *gorm.DB, userID, excepto uint) error {
+	if excepto == 0 {
+		return revocarSesionesUsuario(tx, userID)
+	}
+
+	var actual models.Session
+	if err := tx.Where("id = ? AND user_id = ?", excepto, userID).First(&actual).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return revocarSesionesUsuario(tx, userID)
+		}
+		return err
+	}
+
+	var familias []string
+	err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND family_id <> ?", userID, actual.FamilyID).
+		Distinct().Pluck("family_id", &familias).Error
+	if err != nil {
+		return err
+	}
+
+	for _, familia := range familias {
+		if err := revocarFamilia(tx, familia); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/firmador"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConfigTokens define la duración de los tokens de acceso, de renovación y de restablecimiento de contraseña.
+// URLRestablecimiento es el enlace del frontend al que se agrega el token de restablecimiento;
+// si está vacío, el mensaje incluye solo el token.
+type ConfigTokens struct {
+	DuracionAcceso           time.Duration
+	DuracionRefresh          time.Duration
+	DuracionRestablecimiento time.Duration
+	URLRestablecimiento      string
+}
+
+var configTokens = ConfigTokens{
+	DuracionAcceso:           15 * time.Minute,
+	DuracionRefresh:          30 * 24 * time.Hour,
+	DuracionRestablecimiento: time.Hour,
+}
+
+// InitTokens aplica la configuración de los tokens. Los valores en cero conservan los valores por defecto.
+func InitTokens(config ConfigTokens) {
+	if config.DuracionAcceso > 0 {
+		configTokens.DuracionAcceso = config.DuracionAcceso
+	}
+	if config.DuracionRefresh > 0 {
+		configTokens.DuracionRefresh = config.DuracionRefresh
+	}
+	if config.DuracionRestablecimiento > 0 {
+		configTokens.DuracionRestablecimiento = config.DuracionRestablecimiento
+	}
+	if config.URLRestablecimiento != "" {
+		configTokens.URLRestablecimiento = config.URLRestablecimiento
+	}
+}
+
+// Tokens es el par de tokens emitido al iniciar sesión o al renovar la sesión
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Dispositivo identifica el cliente desde el que se inicia o renueva una sesión
+type Dispositivo struct {
+	UserAgent string
+	IP        string
+}
+
+// EmitirTokens inicia una nueva sesión para el usuario en el dispositivo indicado: firma un token
+// de acceso de corta duración y crea un token de renovación de una familia nueva.
+func EmitirTokens(user *models.User, dispositivo Dispositivo) (*Tokens, error) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens *Tokens
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		tokens, _, err = emitirTokens(tx, user, firmador.GenerarID(), dispositivo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RenovarTokens rota el token de renovación recibido y emite un nuevo par de tokens.
+//
+// El proceso es el siguiente:
+// 1. Busca y bloquea el token de renovación por su hash.
+// 2. Si el token ya fue rotado, se asume que fue robado: se revoca toda su familia junto con sus tokens de acceso.
+// 3. Verifica que el token no esté revocado ni expirado y que la cuenta siga activa.
+// 4. Emite un nuevo par de tokens en la misma familia, actualiza la sesión y marca el token usado como reemplazado.
+func RenovarTokens(refreshToken string, dispositivo Dispositivo) (*Tokens, *models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, nil, err, 500
+	}
+
+	if refreshToken == "" {
+		return nil, nil, errors.New("el refresh token es obligatorio"), 400
+	}
+
+	var tokens *Tokens
+	var user *models.User
+	reutilizado := false
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		tokens, user, reutilizado, err = rotarRefresh(tx, refreshToken, dispositivo)
+		return err
+	})
+
+	if reutilizado {
+		return nil, nil, errors.New("refresh token reutilizado; se cerraron las sesiones asociadas"), 401
+	}
+	if errors.Is(err, ErrCuentaSuspendida) {
+		return nil, nil, err, 403
+	}
+	if err != nil {
+		return nil, nil, err, codigoError(err)
+	}
+
+	return tokens, user, nil, 200
+}
+
+// rotarRefresh reemplaza el token de renovación por un nuevo par de tokens dentro de la transacción (ver RenovarTokens).
+// Si el token ya había sido rotado revoca su familia y retorna reutilizado en true sin error,
+// para que la revocación se confirme.
+func rotarRefresh(tx *gorm.DB, refreshToken string, dispositivo Dispositivo) (*Tokens, *models.User, bool, error) {
+	var actual models.RefreshToken
+	err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Where("token_hash = ?", hashToken(refreshToken)).
+		First(&actual).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, false, nuevoError(401, "refresh token no válido")
+		}
+		return nil, nil, false, err
+	}
+
+	if actual.ReplacedByID != nil {
+		return nil, nil, true, revocarFamilia(tx, actual.FamilyID)
+	}
+
+	if !actual.Vigente() {
+		return nil, nil, false, nuevoError(401, "refresh token expirado o revocado")
+	}
+
+	user, err := buscarUsuario(tx, actual.UserID)
+	if err != nil {
+		return nil, nil, false, nuevoError(401, "refresh token no válido")
+	}
+	if user.Suspendido() {
+		return nil, nil, false, ErrCuentaSuspendida
+	}
+
+	tokens, nuevo, err := emitirTokens(tx, user, actual.FamilyID, dispositivo)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	err = tx.Model(&actual).Updates(map[string]any{
+		"revoked_at":     time.Now(),
+		"replaced_by_id": nuevo.ID,
+	}).Error
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return tokens, user, false, nil
+}
+
+// CerrarSesion revoca el token de renovación recibido (y el resto de su familia)
+// y el token de acceso identificado por accessJti, si se indica.
+func CerrarSesion(refreshToken, accessJti string, accessExpiraEn time.Time) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	if refreshToken == "" && accessJti == "" {
+		return errors.New("debe enviar el refresh token o el token de acceso"), 400
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if refreshToken != "" {
+			var actual models.RefreshToken
+			if err := tx.Where("token_hash = ?", hashToken(refreshToken)).First(&actual).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nuevoError(401, "refresh token no válido")
+				}
+				return err
+			}
+			if err := revocarFamilia(tx, actual.FamilyID); err != nil {
+				return err
+			}
+		}
+
+		if accessJti != "" {
+			return revocarAcceso(tx, accessJti, accessExpiraEn)
+		}
+		return nil
+	})
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	return nil, 200
+}
+
+// IniciarLimpiezaTokens elimina periódicamente, cada intervalo, los tokens revocados que ya expiraron
+// (ver LimpiarTokensRevocados). Si el intervalo no es positivo se usa una hora.
+// Retorna una función que detiene la limpieza, por ejemplo al apagar el servidor.
+func IniciarLimpiezaTokens(intervalo time.Duration) func() {
+	if intervalo <= 0 {
+		intervalo = time.Hour
+	}
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := LimpiarTokensRevocados(); err != nil {
+					slog.Error("no se pudieron eliminar los tokens revocados expirados", "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// LimpiarTokensRevocados elimina de la lista de revocados los jti que ya expiraron: un token expirado
+// se rechaza de todos modos, por lo que no necesita seguir en la lista.
+func LimpiarTokensRevocados() error {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err
+	}
+
+	return gormDB.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error
+}
+
+// revocarSesionesUsuario revoca todas las familias de tokens de renovación del usuario
+// (y los tokens de acceso emitidos con ellas) y cierra sus sesiones
+func revocarSesionesUsuario(tx *gorm.DB, userID uint) error {
+	var familias []string
+	err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Distinct().Pluck("family_id", &familias).Error
+	if err != nil {
+		return err
+	}
+
+	for _, familia := range familias {
+		if err := revocarFamilia(tx, familia); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revocaciones guarda en memoria si cada jti consultado fue revocado (ver ConfigPermisos)
+var revocaciones = nuevaCacheTTL[string, bool]()
+
+// TokenRevocado indica si el token de acceso con el jti indicado fue revocado.
+// La respuesta se guarda en caché; revocarAcceso la actualiza al revocar el token.
+func TokenRevocado(jti string) (bool, error) {
+	if revocado, ok := revocaciones.obtener(jti); ok {
+		return revocado, nil
+	}
+
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err
+	}
+
+	var total int64
+	if err := gormDB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&total).Error; err != nil {
+		return false, err
+	}
+
+	revocaciones.guardar(jti, total > 0)
+	return total > 0, nil
+}
+
+// emitirTokens firma un token de acceso con un jti nuevo y guarda un token de renovación en la familia indicada.
+// También registra o actualiza la sesión de la familia; su ID viaja en el claim "sid" del token de acceso.
+func emitirTokens(tx *gorm.DB, user *models.User, familia string, dispositivo Dispositivo) (*Tokens, *models.RefreshToken, error) {
+	ahora := time.Now()
+	jti := firmador.GenerarID()
+
+	sesion, err := registrarSesion(tx, user.ID, familia, dispositivo, ahora.Add(configTokens.DuracionRefresh))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	claims := map[string]string{
+		"user_id": strconv.Itoa(int(user.ID)),
+		"jti":     jti,
+		"sid":     strconv.Itoa(int(sesion.ID)),
+		// El rol permite verificar los permisos sin buscar al usuario (ver RolCambiadoDesde)
+		"role_id": strconv.Itoa(int(user.RoleID)),
+	}
+	// Los tokens v4.public no van cifrados: cualquiera que los tenga puede leer su contenido,
+	// por lo que los datos personales solo se incluyen en los tokens v4.local
+	if firmador.Modo() == firmador.ModoLocal {
+		claims["email"] = user.Email
+		claims["name"] = user.Name
+	}
+
+	accessToken, err := firmador.FirmarToken(claims, configTokens.DuracionAcceso)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := generarTokenAleatorio()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registro := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		FamilyID:  familia,
+		AccessJti: jti,
+		ExpiresAt: ahora.Add(configTokens.DuracionRefresh),
+	}
+	if err := tx.Omit(clause.Associations).Create(&registro).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &Tokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  ahora.Add(configTokens.DuracionAcceso),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: registro.ExpiresAt,
+	}, &registro, nil
+}
+
+// revocarFamilia revoca todos los tokens de renovación de la familia
+// y los tokens de acceso emitidos con ellos que aún podrían estar vigentes, y cierra su sesión.
+func revocarFamilia(tx *gorm.DB, familia string) error {
+	var registros []models.RefreshToken
+	if err := tx.Where("family_id = ?", familia).Find(&registros).Error; err != nil {
+		return err
+	}
+
+	ahora := time.Now()
+	for _, registro := range registros {
+		if expira := registro.CreatedAt.Add(configTokens.DuracionAcceso); expira.After(ahora) {
+			if err := revocarAcceso(tx, registro.AccessJti, expira); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familia).
+		Update("revoked_at", ahora).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familia).
+		Update("revoked_at", ahora).Error
+}
+
+// revocarAcceso agrega el jti a la lista de tokens de acceso revocados.
+// La caché se marca antes de confirmar la transacción: si se deshace, el token queda rechazado hasta que la caché venza.
+func revocarAcceso(tx *gorm.DB, jti string, expiraEn time.Time) error {
+	revocaciones.guardar(jti, true)
+
+	if expiraEn.IsZero() {
+		expiraEn = time.Now().Add(configTokens.DuracionAcceso)
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{Jti: jti, ExpiresAt: expiraEn}).Error
+}
+
+// generarTokenAleatorio genera un token opaco de 256 bits codificado en base64 URL
+func generarTokenAleatorio() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashToken calcula el hash SHA-256 (hexadecimal) con el que se guardan los tokens en la base de datos
+func hashToken(token string) string {
+	suma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(suma[:])
+}
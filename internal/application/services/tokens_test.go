@@ -0,0 +1,82 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/firmador"
+	"testing"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// TestRefreshReutilizadoRevocaFamilia reutiliza un token de renovación ya rotado y verifica que se revoque
+// toda la familia: los tokens de renovación, los tokens de acceso emitidos con ellos y la sesión.
+// Requiere TEST_DATABASE_URL con una base PostgreSQL; los cambios se deshacen al terminar.
+func TestRefreshReutilizadoRevocaFamilia(t *testing.T) {
+	tx := transaccionDePrueba(t)
+
+	t.Setenv("PASETO_MODE", firmador.ModoLocal)
+	t.Setenv("PASETO_KEYS_FILE", "")
+	t.Setenv("SECRET_KEY_ID", "prueba")
+	t.Setenv("SECRET_KEY", paseto.NewV4SymmetricKey().ExportHex())
+	if err := firmador.RecargarLlaves(); err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{Name: "Luis Gómez", Email: "luis.refresh@example.com", Phone: "+5491155551111", Password: "hash"}
+	crearUsuarioDePrueba(t, tx, &user)
+
+	familia := "familia-reuso"
+	dispositivo := Dispositivo{UserAgent: "prueba", IP: "203.0.113.8"}
+	inicial, _, err := emitirTokens(tx, &user, familia, dispositivo)
+	if err != nil {
+		t.Fatalf("emitirTokens: %v", err)
+	}
+
+	rotado, _, reutilizado, err := rotarRefresh(tx, inicial.RefreshToken, dispositivo)
+	if err != nil || reutilizado {
+		t.Fatalf("la primera rotación debería funcionar: reutilizado = %v, err = %v", reutilizado, err)
+	}
+
+	// El token inicial ya fue reemplazado: volver a usarlo revoca la familia
+	tokens, _, reutilizado, err := rotarRefresh(tx, inicial.RefreshToken, dispositivo)
+	if err != nil || !reutilizado || tokens != nil {
+		t.Fatalf("se esperaba detectar la reutilización: reutilizado = %v, err = %v", reutilizado, err)
+	}
+
+	var vigentes int64
+	if err := tx.Model(&models.RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", familia).Count(&vigentes).Error; err != nil {
+		t.Fatal(err)
+	}
+	if vigentes != 0 {
+		t.Errorf("quedaron %d tokens de renovación vigentes en la familia", vigentes)
+	}
+
+	var sesion models.Session
+	if err := tx.Where("family_id = ?", familia).First(&sesion).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sesion.RevokedAt == nil {
+		t.Error("la sesión de la familia no quedó cerrada")
+	}
+
+	var jtis []string
+	if err := tx.Model(&models.RefreshToken{}).Where("family_id = ?", familia).Pluck("access_jti", &jtis).Error; err != nil {
+		t.Fatal(err)
+	}
+	var revocados int64
+	if err := tx.Model(&models.RevokedToken{}).Where("jti IN ?", jtis).Count(&revocados).Error; err != nil {
+		t.Fatal(err)
+	}
+	if revocados != int64(len(jtis)) {
+		t.Errorf("se revocaron %d de %d tokens de acceso", revocados, len(jtis))
+	}
+
+	// El token de la rotación legítima también queda inutilizable
+	if _, _, _, err := rotarRefresh(tx, rotado.RefreshToken, dispositivo); codigoError(err) != 401 {
+		t.Errorf("el token rotado debería rechazarse con 401, err = %v", err)
+	}
+
+	if _, _, _, err := rotarRefresh(tx, "desconocido", dispositivo); codigoError(err) != 401 {
+		t.Errorf("un token desconocido debería rechazarse con 401, err = %v", err)
+	}
+}
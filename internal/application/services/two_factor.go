@@ -0,0 +1,476 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/firmador"
+	"backend_reservation/pkg/totp"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// propositoDesafio2FA identifica a los tokens de desafío del segundo paso del login.
+	// Estos tokens no sirven como tokens de acceso.
+	propositoDesafio2FA = "2fa"
+	// duracionDesafio2FA es el tiempo que tiene el usuario para ingresar el código tras la contraseña
+	duracionDesafio2FA = 5 * time.Minute
+	// cantidadCodigosRecuperacion es la cantidad de códigos de recuperación que se generan
+	cantidadCodigosRecuperacion = 10
+	// maxFallosDesafio es la cantidad de códigos incorrectos que admite un token de desafío antes de revocarse
+	maxFallosDesafio = 5
+)
+
+// ConfigSegundoFactor agrupa la configuración de la autenticación en dos pasos
+type ConfigSegundoFactor struct {
+	// Emisor es el nombre que muestran las apps de autenticación junto a la cuenta
+	Emisor string
+	// ObligatorioAdmin exige que los roles con acceso a la administración tengan activado el segundo factor
+	ObligatorioAdmin bool
+}
+
+var configSegundoFactor = ConfigSegundoFactor{Emisor: "Reservas"}
+
+// InitSegundoFactor aplica la configuración de la autenticación en dos pasos
+func InitSegundoFactor(cfg ConfigSegundoFactor) {
+	if cfg.Emisor != "" {
+		configSegundoFactor.Emisor = cfg.Emisor
+	}
+	configSegundoFactor.ObligatorioAdmin = cfg.ObligatorioAdmin
+}
+
+// SegundoFactorExigido indica si el usuario debe tener activado el segundo factor para usar las rutas de admin.
+// Se exige a todo rol con acceso a la administración, no solo al rol admin.
+func SegundoFactorExigido(userID uint) (bool, error) {
+	if !configSegundoFactor.ObligatorioAdmin {
+		return false, nil
+	}
+
+	roleID, err := cacheRoles.rolDeUsuario(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return rolAdministrativo(roleID)
+}
+
+// rolAdministrativo indica si el rol tiene algún permiso de las rutas de administración
+func rolAdministrativo(roleID uint) (bool, error) {
+	codigos, err := cacheRoles.permisosDeRol(roleID)
+	if err != nil {
+		return false, err
+	}
+
+	return permisosAdministrativos(codigos), nil
+}
+
+// permisosAdministrativos indica si alguno de los permisos pertenece a las rutas de administración.
+// El único permiso que no es de administración es el de reservar citas.
+func permisosAdministrativos(codigos map[string]struct{}) bool {
+	for codigo := range codigos {
+		if codigo != models.PermisoCitasReservar {
+			return true
+		}
+	}
+	return false
+}
+
+// TieneSegundoFactor indica si el usuario tiene activada la autenticación en dos pasos
+func TieneSegundoFactor(userID uint) (bool, error) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.SegundoFactorActivo(), nil
+}
+
+// IniciarSegundoFactor genera un secreto TOTP nuevo para el usuario y retorna el secreto y el URI otpauth
+// para registrarlo en la app de autenticación. El segundo factor no se activa hasta confirmar un código con ActivarSegundoFactor.
+func IniciarSegundoFactor(userID uint) (string, string, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return "", "", err, 500
+	}
+
+	user, err := buscarUsuario(gormDB, userID)
+	if err != nil {
+		return "", "", err, codigoError(err)
+	}
+
+	if user.SegundoFactorActivo() {
+		return "", "", errors.New("la autenticación en dos pasos ya está activada"), 409
+	}
+
+	secreto, err := totp.GenerarSecreto()
+	if err != nil {
+		return "", "", err, 500
+	}
+
+	err = gormDB.Model(user).Updates(map[string]any{
+		"totp_secret":    secreto,
+		"totp_last_step": 0,
+	}).Error
+	if err != nil {
+		return "", "", errors.New("No se pudo generar el secreto"), 500
+	}
+
+	return secreto, totp.URI(configSegundoFactor.Emisor, user.Email, secreto), nil, 200
+}
+
+// ActivarSegundoFactor confirma el secreto generado con IniciarSegundoFactor usando un código de la app
+// y activa la autenticación en dos pasos. Retorna los códigos de recuperación, que solo se muestran esta vez.
+func ActivarSegundoFactor(userID uint, codigo string) ([]string, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var codigos []string
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		user, err := bloquearUsuario(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if user.SegundoFactorActivo() {
+			return nuevoError(409, "la autenticación en dos pasos ya está activada")
+		}
+		if user.TOTPSecret == "" {
+			return nuevoError(400, "primero debe generar el secreto de autenticación")
+		}
+
+		paso, ok := totp.Validar(user.TOTPSecret, codigo, time.Now(), 1)
+		if !ok {
+			return nuevoError(400, "código no válido")
+		}
+
+		err = tx.Model(user).Updates(map[string]any{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  paso,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		codigos, err = generarCodigosRecuperacion(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return codigos, nil, 200
+}
+
+// DesactivarSegundoFactor desactiva la autenticación en dos pasos tras validar un código TOTP o de recuperación.
+// Si el segundo factor es obligatorio para los administradores, un administrador no puede desactivarlo.
+func DesactivarSegundoFactor(userID uint, codigo string) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		user, err := bloquearUsuario(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.SegundoFactorActivo() {
+			return nuevoError(409, "la autenticación en dos pasos no está activada")
+		}
+		if configSegundoFactor.ObligatorioAdmin {
+			administrativo, err := rolAdministrativo(user.RoleID)
+			if err != nil {
+				return err
+			}
+			if administrativo {
+				return nuevoError(403, "la autenticación en dos pasos es obligatoria para los roles con acceso a la administración")
+			}
+		}
+
+		if err := verificarSegundoFactor(tx, user, codigo); err != nil {
+			return err
+		}
+
+		err = tx.Model(user).Updates(map[string]any{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	return nil, 200
+}
+
+// RegenerarCodigosRecuperacion reemplaza los códigos de recuperación del usuario tras validar un código TOTP o de recuperación
+func RegenerarCodigosRecuperacion(userID uint, codigo string) ([]string, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var codigos []string
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		user, err := bloquearUsuario(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.SegundoFactorActivo() {
+			return nuevoError(409, "la autenticación en dos pasos no está activada")
+		}
+
+		if err := verificarSegundoFactor(tx, user, codigo); err != nil {
+			return err
+		}
+
+		codigos, err = generarCodigosRecuperacion(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return codigos, nil, 200
+}
+
+// CrearDesafioSegundoFactor emite el token de desafío que el cliente debe enviar junto con el código
+// en el segundo paso del login. El token es de un solo uso y de corta duración.
+func CrearDesafioSegundoFactor(user *models.User) (string, time.Time, error) {
+	expiraEn := time.Now().Add(duracionDesafio2FA)
+	token, err := firmador.FirmarToken(map[string]string{
+		"user_id": strconv.Itoa(int(user.ID)),
+		"purpose": propositoDesafio2FA,
+		"jti":     firmador.GenerarID(),
+	}, duracionDesafio2FA)
+	return token, expiraEn, err
+}
+
+// CompletarLoginSegundoFactor valida el token de desafío y el código (TOTP o de recuperación)
+// y retorna el usuario autenticado. El token de desafío queda revocado.
+// Los códigos incorrectos cuentan como intentos fallidos de login del email y de la IP (ver Login),
+// y el paso se rechaza con BloqueoAccesoError mientras alguno de ellos esté bloqueado.
+// Tras maxFallosDesafio códigos incorrectos el token de desafío se revoca y hay que volver a ingresar la contraseña.
+func CompletarLoginSegundoFactor(desafio, codigo, ip string) (*models.User, error, int) {
+	origen := claveIP(ip)
+	if espera := accesos.bloqueo(time.Now(), origen); espera > 0 {
+		return nil, &BloqueoAccesoError{ReintentarEn: espera}, 429
+	}
+
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if desafio == "" || codigo == "" {
+		return nil, errors.New("el token de desafío y el código son obligatorios"), 400
+	}
+
+	token, err := firmador.VerificarToken(desafio)
+	if err != nil {
+		return nil, errors.New("token de desafío no válido o expirado"), 401
+	}
+
+	proposito, _ := token.GetString("purpose")
+	jti, _ := token.GetJti()
+	userID, err := token.GetString("user_id")
+	if proposito != propositoDesafio2FA || jti == "" || err != nil {
+		return nil, errors.New("token de desafío no válido o expirado"), 401
+	}
+
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, errors.New("token de desafío no válido o expirado"), 401
+	}
+
+	expiraEn, _ := token.GetExpiration()
+
+	var user *models.User
+	codigoIncorrecto := false
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		var revocados int64
+		if err := tx.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&revocados).Error; err != nil {
+			return err
+		}
+		if revocados > 0 {
+			return nuevoError(401, "token de desafío no válido o expirado")
+		}
+
+		user, err = bloquearUsuario(tx, uint(id))
+		if err != nil {
+			return nuevoError(401, "token de desafío no válido o expirado")
+		}
+		if user.Suspendido() {
+			return ErrCuentaSuspendida
+		}
+
+		if err := verificarSegundoFactor(tx, user, codigo); err != nil {
+			if codigoError(err) == 401 {
+				codigoIncorrecto = true
+				accesos.fallo(origen, configAccesos.IntentosIP, time.Now())
+			}
+			return err
+		}
+
+		if err := revocarAcceso(tx, jti, expiraEn); err != nil {
+			return err
+		}
+
+		accesos.olvidar(claveEmail(user.Email))
+		return nil
+	})
+	if errors.Is(err, ErrCuentaSuspendida) {
+		return nil, err, 403
+	}
+	if codigoIncorrecto && desafios.fallo(jti, expiraEn) >= maxFallosDesafio {
+		if err := revocarAcceso(gormDB, jti, expiraEn); err != nil {
+			return nil, err, 500
+		}
+		return nil, errors.New("demasiados códigos incorrectos, vuelva a iniciar sesión"), 401
+	}
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	return user, nil, 200
+}
+
+// verificarSegundoFactor valida un código TOTP o de recuperación (ver validarCodigoSegundoFactor).
+// Los códigos incorrectos cuentan como intentos fallidos de login del email del usuario, y mientras esté
+// bloqueado se rechaza con BloqueoAccesoError sin validar el código.
+func verificarSegundoFactor(tx *gorm.DB, user *models.User, codigo string) error {
+	cuenta := claveEmail(user.Email)
+	ahora := time.Now()
+	if espera := accesos.bloqueo(ahora, cuenta); espera > 0 {
+		return &BloqueoAccesoError{ReintentarEn: espera}
+	}
+
+	err := validarCodigoSegundoFactor(tx, user, codigo)
+	if err != nil && codigoError(err) == 401 {
+		accesos.fallo(cuenta, configAccesos.IntentosCuenta, ahora)
+	}
+	return err
+}
+
+// validarCodigoSegundoFactor valida un código TOTP (que no se haya usado antes) o un código de recuperación sin usar.
+// El usuario debe estar bloqueado en la transacción para evitar que el mismo código se use dos veces.
+func validarCodigoSegundoFactor(tx *gorm.DB, user *models.User, codigo string) error {
+	if paso, ok := totp.Validar(user.TOTPSecret, codigo, time.Now(), 1); ok {
+		if paso <= user.TOTPLastStep {
+			return nuevoError(401, "el código ya fue utilizado")
+		}
+		user.TOTPLastStep = paso
+		return tx.Model(user).Update("totp_last_step", paso).Error
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalizarCodigoRecuperacion(codigo))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nuevoError(401, "código no válido")
+	}
+
+	return nil
+}
+
+// normalizarCodigoRecuperacion quita los espacios y el guion del código y lo pasa a minúsculas, como se guarda su hash
+func normalizarCodigoRecuperacion(codigo string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(codigo), "-", ""))
+}
+
+// fallosDesafio son los códigos incorrectos enviados con un token de desafío y el vencimiento del token
+type fallosDesafio struct {
+	cantidad int
+	expira   time.Time
+}
+
+// registroDesafios cuenta en memoria los códigos incorrectos de cada token de desafío vigente, por jti
+type registroDesafios struct {
+	mu     sync.Mutex
+	fallos map[string]fallosDesafio
+}
+
+var desafios = &registroDesafios{fallos: make(map[string]fallosDesafio)}
+
+// fallo registra un código incorrecto para el token de desafío y retorna cuántos lleva.
+// Los tokens vencidos se descartan cuando el registro alcanza maxEntradasCache entradas.
+func (r *registroDesafios) fallo(jti string, expira time.Time) int {
+	ahora := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.fallos[jti]; !ok && len(r.fallos) >= maxEntradasCache {
+		for otro, fallos := range r.fallos {
+			if !fallos.expira.After(ahora) {
+				delete(r.fallos, otro)
+			}
+		}
+	}
+
+	fallos := r.fallos[jti]
+	fallos.cantidad++
+	fallos.expira = expira
+	r.fallos[jti] = fallos
+	return fallos.cantidad
+}
+
+// generarCodigosRecuperacion reemplaza los códigos de recuperación del usuario y retorna los nuevos en texto plano.
+// Los códigos tienen el formato xxxxx-xxxxx; el guion es opcional al ingresarlos.
+func generarCodigosRecuperacion(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codigos := make([]string, cantidadCodigosRecuperacion)
+	registros := make([]models.RecoveryCode, cantidadCodigosRecuperacion)
+	for i := range codigos {
+		codigo := firmador.GenerarID()[:10]
+		codigos[i] = codigo[:5] + "-" + codigo[5:]
+		registros[i] = models.RecoveryCode{UserID: userID, CodeHash: hashToken(codigo)}
+	}
+
+	if err := tx.Omit(clause.Associations).Create(&registros).Error; err != nil {
+		return nil, err
+	}
+
+	return codigos, nil
+}
+
+// bloquearUsuario obtiene el usuario con su rol y lo bloquea (SELECT ... FOR UPDATE) hasta el fin de la transacción
+func bloquearUsuario(tx *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Preload("Role").
+		First(&user, userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "usuario no encontrado")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
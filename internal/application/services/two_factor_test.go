@@ -0,0 +1,123 @@
+package services
+
+import (
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/totp"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNormalizarCodigoRecuperacion(t *testing.T) {
+	casos := []struct {
+		codigo   string
+		esperado string
+	}{
+		{"abcde-12345", "abcde12345"},
+		{"ABCDE-12345", "abcde12345"},
+		{"  abcde12345 ", "abcde12345"},
+		{"ab-cde-123-45", "abcde12345"},
+	}
+
+	for _, caso := range casos {
+		if obtenido := normalizarCodigoRecuperacion(caso.codigo); obtenido != caso.esperado {
+			t.Errorf("normalizarCodigoRecuperacion(%q) = %q, se esperaba %q", caso.codigo, obtenido, caso.esperado)
+		}
+		if hashToken(normalizarCodigoRecuperacion(caso.codigo)) != hashToken(caso.esperado) {
+			t.Errorf("el hash de %q no coincide con el del código guardado", caso.codigo)
+		}
+	}
+}
+
+func TestValidarCodigoSegundoFactorReutilizado(t *testing.T) {
+	secreto, err := totp.GenerarSecreto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paso := totp.Paso(time.Now())
+	codigo, err := totp.Codigo(secreto, paso)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// El paso ya se usó: se rechaza sin llegar a la base de datos
+	user := &models.User{TOTPSecret: secreto, TOTPLastStep: paso + 1}
+	err = validarCodigoSegundoFactor(nil, user, codigo)
+	if codigoError(err) != 401 {
+		t.Errorf("un código ya utilizado debería rechazarse con 401, se obtuvo %v", err)
+	}
+}
+
+func TestVerificarSegundoFactorBloqueado(t *testing.T) {
+	user := &models.User{Email: "bloqueado@example.com"}
+	cuenta := claveEmail(user.Email)
+	t.Cleanup(func() { accesos.olvidar(cuenta) })
+
+	ahora := time.Now()
+	for range configAccesos.IntentosCuenta + 1 {
+		accesos.fallo(cuenta, configAccesos.IntentosCuenta, ahora)
+	}
+
+	// Con la cuenta bloqueada no se valida el código (ni se usa la transacción)
+	var bloqueo *BloqueoAccesoError
+	if err := verificarSegundoFactor(nil, user, "123456"); !errors.As(err, &bloqueo) {
+		t.Fatalf("se esperaba BloqueoAccesoError, se obtuvo %v", err)
+	}
+	if codigoError(&BloqueoAccesoError{}) != 429 {
+		t.Error("BloqueoAccesoError debería responder 429")
+	}
+}
+
+func TestRegistroDesafios(t *testing.T) {
+	registro := &registroDesafios{fallos: make(map[string]fallosDesafio)}
+	expira := time.Now().Add(duracionDesafio2FA)
+
+	for i := 1; i <= maxFallosDesafio; i++ {
+		if cantidad := registro.fallo("desafio-a", expira); cantidad != i {
+			t.Fatalf("fallo %d: cantidad = %d", i, cantidad)
+		}
+	}
+
+	if cantidad := registro.fallo("desafio-b", expira); cantidad != 1 {
+		t.Errorf("los fallos de otro desafío no deberían sumarse: %d", cantidad)
+	}
+}
+
+func TestRegistroDesafiosDescartaVencidos(t *testing.T) {
+	registro := &registroDesafios{fallos: make(map[string]fallosDesafio)}
+	vencido := time.Now().Add(-time.Minute)
+	for i := range maxEntradasCache {
+		registro.fallos[strconv.Itoa(i)] = fallosDesafio{cantidad: 1, expira: vencido}
+	}
+
+	registro.fallo("nuevo", time.Now().Add(duracionDesafio2FA))
+
+	if len(registro.fallos) != 1 {
+		t.Errorf("se esperaba solo el desafío nuevo, hay %d", len(registro.fallos))
+	}
+}
+
+func TestPermisosAdministrativos(t *testing.T) {
+	casos := []struct {
+		nombre  string
+		codigos []string
+		admin   bool
+	}{
+		{nombre: "sin permisos"},
+		{nombre: "cliente", codigos: []string{models.PermisoCitasReservar}},
+		{nombre: "personal", codigos: []string{models.PermisoCitasReservar, models.PermisoCitasLeer}, admin: true},
+		{nombre: "solo lectura", codigos: []string{models.PermisoServiciosLeer}, admin: true},
+	}
+
+	for _, caso := range casos {
+		codigos := make(map[string]struct{}, len(caso.codigos))
+		for _, codigo := range caso.codigos {
+			codigos[codigo] = struct{}{}
+		}
+		if obtenido := permisosAdministrativos(codigos); obtenido != caso.admin {
+			t.Errorf("%s: permisosAdministrativos = %v, se esperaba %v", caso.nombre, obtenido, caso.admin)
+		}
+	}
+}
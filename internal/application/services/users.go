@@ -1 +1,415 @@
 package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Estados por los que se puede filtrar el listado de usuarios
+const (
+	UsuarioActivo     = "active"
+	UsuarioSuspendido = "suspended"
+	UsuarioEliminado  = "deleted"
+)
+
+// ErrCuentaSuspendida indica que el usuario no puede operar porque su cuenta fue suspendida
+var ErrCuentaSuspendida = errors.New("la cuenta está suspendida")
+
+// PaginaUsuarios es una página del listado de usuarios
+type PaginaUsuarios struct {
+	Usuarios []models.User
+	Total    int64
+	Page     int
+	PerPage  int
+}
+
+// ObtenerUsuarios lista los usuarios paginados aplicando los filtros indicados.
+// Los filtros de texto no distinguen mayúsculas y buscan coincidencias parciales; role filtra por el código del rol.
+// Por defecto se listan los usuarios no eliminados; con Status deleted se listan solo los eliminados.
+func ObtenerUsuarios(filtro *dto.UserFilter) (*PaginaUsuarios, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	page, perPage := filtro.Page, filtro.PerPage
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	query := gormDB.Model(&models.User{})
+
+	switch filtro.Status {
+	case "":
+	case UsuarioActivo:
+		query = query.Where("users.suspended_at IS NULL")
+	case UsuarioSuspendido:
+		query = query.Where("users.suspended_at IS NOT NULL")
+	case UsuarioEliminado:
+		query = query.Unscoped().Where("users.deleted_at IS NOT NULL")
+	default:
+		return nil, errors.New("estado de usuario no válido"), 400
+	}
+
+	if search := strings.TrimSpace(filtro.Search); search != "" {
+		patron := "%" + search + "%"
+		query = query.Where("users.name ILIKE ? OR users.email ILIKE ? OR users.phone ILIKE ?", patron, patron, patron)
+	}
+
+	for columna, valor := range map[string]string{"users.name": filtro.Name, "users.email": filtro.Email, "users.phone": filtro.Phone} {
+		if valor = strings.TrimSpace(valor); valor != "" {
+			query = query.Where(columna+" ILIKE ?", "%"+valor+"%")
+		}
+	}
+
+	if filtro.Role != "" {
+		query = query.Joins("JOIN roles ON roles.id = users.role_id AND roles.code = ?", filtro.Role)
+	}
+
+	// La sesión permite reutilizar la consulta para el conteo y para la página
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err, 500
+	}
+
+	var usuarios []models.User
+	err = query.Preload("Role").
+		Order("users.name").Order("users.id").
+		Limit(perPage).Offset((page - 1) * perPage).
+		Find(&usuarios).Error
+	if err != nil {
+		return nil, err, 500
+	}
+
+	return &PaginaUsuarios{Usuarios: usuarios, Total: total, Page: page, PerPage: perPage}, nil, 200
+}
+
+// ObtenerUsuario retorna un usuario (incluso si fue eliminado) con su rol y su historial de citas,
+// de la más reciente a la más antigua.
+func ObtenerUsuario(id uint) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var usuario models.User
+	err = gormDB.Unscoped().
+		Preload("Role").
+		Preload("Appointments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("start_at DESC")
+		}).
+		Preload("Appointments.AppointmentServices.Service").
+		Preload("Appointments.Employee").
+		First(&usuario, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado"), 404
+		}
+		return nil, err, 500
+	}
+
+	return &usuario, nil, 200
+}
+
+// CambiarRolUsuario asigna otro rol al usuario.
+// Un administrador no puede cambiar su propio rol para evitar quedarse sin acceso.
+func CambiarRolUsuario(adminID, id, roleID uint) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if adminID == id {
+		return nil, errors.New("no puede cambiar su propio rol"), 403
+	}
+
+	usuario, err := buscarUsuario(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	role, err := obtenerRol(gormDB, roleID)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(usuario).Update("role_id", role.ID).Error; err != nil {
+			return err
+		}
+		return registrarAuditoria(tx, usuario.ID, adminID, models.AuditoriaRol, usuario.Role.Code+" -> "+role.Code)
+	})
+	if err != nil {
+		return nil, errors.New("No se pudo cambiar el rol del usuario"), 500
+	}
+	cacheRoles.invalidarUsuario(usuario.ID)
+	usuario.Role = *role
+
+	return usuario, nil, 200
+}
+
+// SuspenderUsuario suspende la cuenta del usuario: no podrá iniciar sesión ni usar sus tokens vigentes.
+// Un administrador no puede suspender su propia cuenta.
+func SuspenderUsuario(adminID, id uint, motivo string) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	if adminID == id {
+		return nil, errors.New("no puede suspender su propia cuenta"), 403
+	}
+
+	usuario, err := buscarUsuario(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if usuario.Suspendido() {
+		return nil, errors.New("la cuenta ya está suspendida"), 409
+	}
+
+	ahora := time.Now()
+	motivo = recortar(strings.TrimSpace(motivo), 255)
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(usuario).Updates(map[string]any{
+			"suspended_at":      ahora,
+			"suspension_reason": motivo,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return registrarAuditoria(tx, usuario.ID, adminID, models.AuditoriaSuspension, "")
+	})
+	if err != nil {
+		return nil, errors.New("No se pudo suspender la cuenta"), 500
+	}
+	cacheRoles.olvidarUsuario(usuario.ID)
+	usuario.SuspendedAt = &ahora
+	usuario.SuspensionReason = motivo
+
+	return usuario, nil, 200
+}
+
+// ReactivarUsuario levanta la suspensión de la cuenta del usuario
+func ReactivarUsuario(adminID, id uint) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	usuario, err := buscarUsuario(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if !usuario.Suspendido() {
+		return nil, errors.New("la cuenta no está suspendida"), 409
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(usuario).Updates(map[string]any{
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}).Error
+		if err != nil {
+			return err
+		}
+		return registrarAuditoria(tx, usuario.ID, adminID, models.AuditoriaReactivacion, "")
+	})
+	if err != nil {
+		return nil, errors.New("No se pudo reactivar la cuenta"), 500
+	}
+	cacheRoles.olvidarUsuario(usuario.ID)
+	usuario.SuspendedAt = nil
+	usuario.SuspensionReason = ""
+
+	return usuario, nil, 200
+}
+
+// VerificarContactoUsuario marca como verificado el email o el teléfono del usuario sin código, por ejemplo
+// cuando el usuario no recibe los mensajes. Los códigos pendientes del canal se invalidan.
+func VerificarContactoUsuario(adminID, id uint, canal string) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	usuario, err := buscarUsuario(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	campo, verificado := "email_verified_at", usuario.EmailVerificado()
+	if canal == models.VerificacionTelefono {
+		campo, verificado = "phone_verified_at", usuario.TelefonoVerificado()
+	}
+	if verificado {
+		return nil, errors.New("el dato ya está verificado"), 409
+	}
+
+	ahora := time.Now()
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(usuario).Update(campo, ahora).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.VerificationCode{}).
+			Where("user_id = ? AND channel = ? AND used_at IS NULL", usuario.ID, canal).
+			Update("used_at", ahora).Error
+		if err != nil {
+			return err
+		}
+
+		return registrarAuditoria(tx, usuario.ID, adminID, models.AuditoriaVerificacion, canal)
+	})
+	if err != nil {
+		return nil, errors.New("No se pudo verificar el dato"), 500
+	}
+	if canal == models.VerificacionTelefono {
+		usuario.PhoneVerifiedAt = &ahora
+	} else {
+		usuario.EmailVerifiedAt = &ahora
+	}
+
+	return usuario, nil, 200
+}
+
+// DesbloquearUsuario levanta el bloqueo temporal del login del usuario por intentos fallidos.
+// Si se indica una IP, también se olvidan los intentos fallidos registrados para ella.
+func DesbloquearUsuario(adminID, id uint, ip string) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	usuario, err := buscarUsuario(gormDB, id)
+	if err != nil {
+		return nil, err, codigoError(err)
+	}
+
+	if err := registrarAuditoria(gormDB, usuario.ID, adminID, models.AuditoriaDesbloqueo, ""); err != nil {
+		return nil, errors.New("No se pudo desbloquear la cuenta"), 500
+	}
+
+	accesos.olvidar(claveEmail(usuario.Email))
+	if ip != "" {
+		accesos.olvidar(claveIP(ip))
+	}
+
+	return usuario, nil, 200
+}
+
+// EliminarUsuario elimina (soft delete) la cuenta del usuario y cancela sus citas futuras.
+// Puede restaurarse con RestaurarUsuario, pero las citas canceladas no se recuperan.
+// Un administrador no puede eliminar su propia cuenta.
+func EliminarUsuario(adminID, id uint) (bool, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return false, err, 500
+	}
+
+	if adminID == id {
+		return false, errors.New("no puede eliminar su propia cuenta"), 403
+	}
+
+	usuario, err := buscarUsuario(gormDB, id)
+	if err != nil {
+		return false, err, codigoError(err)
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := cancelarCitasFuturasUsuario(tx, usuario.ID); err != nil {
+			return err
+		}
+		if err := registrarAuditoria(tx, usuario.ID, adminID, models.AuditoriaEliminacion, ""); err != nil {
+			return err
+		}
+		return tx.Delete(usuario).Error
+	})
+	if err != nil {
+		return false, errors.New("No se pudo eliminar el usuario"), 500
+	}
+	cacheRoles.olvidarUsuario(usuario.ID)
+
+	return true, nil, 200
+}
+
+// RestaurarUsuario recupera una cuenta eliminada con EliminarUsuario
+func RestaurarUsuario(adminID, id uint) (*models.User, error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return nil, err, 500
+	}
+
+	var usuario models.User
+	if err := gormDB.Unscoped().Preload("Role").First(&usuario, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usuario no encontrado"), 404
+		}
+		return nil, err, 500
+	}
+
+	if !usuario.DeletedAt.Valid {
+		return nil, errors.New("el usuario no está eliminado"), 409
+	}
+
+	if usuario.Borrado() {
+		return nil, errors.New("los datos de la cuenta fueron borrados; no puede restaurarse"), 409
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&usuario).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return registrarAuditoria(tx, usuario.ID, adminID, models.AuditoriaRestauracion, "")
+	})
+	if err != nil {
+		return nil, errors.New("No se pudo restaurar el usuario"), 500
+	}
+	cacheRoles.olvidarUsuario(usuario.ID)
+	usuario.DeletedAt = gorm.DeletedAt{}
+
+	return &usuario, nil, 200
+}
+
+// VerificarCuentaActiva retorna un error si el usuario no existe, fue eliminado o está suspendido.
+// El estado se obtiene de la caché de permisos, que se invalida al suspender, reactivar, eliminar o restaurar la cuenta.
+// Se usa al autenticar cada solicitud para que las suspensiones apliquen también a los tokens ya emitidos.
+func VerificarCuentaActiva(userID uint) error {
+	estado, err := cacheRoles.estadoDeUsuario(userID)
+	if err != nil {
+		return err
+	}
+
+	if estado.suspendido {
+		return ErrCuentaSuspendida
+	}
+
+	return nil
+}
+
+// buscarUsuario busca un usuario no eliminado junto con su rol
+func buscarUsuario(gormDB *gorm.DB, id uint) (*models.User, error) {
+	var usuario models.User
+	if err := gormDB.Preload("Role").First(&usuario, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nuevoError(404, "usuario no encontrado")
+		}
+		return nil, err
+	}
+	return &usuario, nil
+}
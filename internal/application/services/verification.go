@@ -0,0 +1,277 @@
+package services
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/notificador"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// duracionCodigoVerificacion es la vigencia de un código de verificación
+	duracionCodigoVerificacion = 15 * time.Minute
+	// intentosCodigoVerificacion es la cantidad de intentos fallidos tras la cual el código se invalida
+	intentosCodigoVerificacion = 5
+	// esperaReenvio es el tiempo mínimo entre dos códigos del mismo canal para un usuario
+	esperaReenvio = time.Minute
+	// maxReenviosPorHora es la cantidad máxima de códigos del mismo canal que se envían a un usuario en una hora
+	maxReenviosPorHora = 5
+)
+
+// errCodigoNoValido se retorna para cualquier código incorrecto, expirado o inexistente,
+// sin distinguir el motivo para no revelar si el email o el teléfono están registrados.
+var errCodigoNoValido = nuevoError(400, "código de verificación no válido o expirado")
+
+// VerificarEmail marca como verificado el email del usuario si el código es correcto
+func VerificarEmail(verifyDto *dto.VerifyDTO) (error, int) {
+	email := strings.TrimSpace(verifyDto.Email)
+	if email == "" || verifyDto.Code == "" {
+		return errors.New("el email y el código son obligatorios"), 400
+	}
+	return verificarCodigo(models.VerificacionEmail, "email", email, verifyDto.Code)
+}
+
+// VerificarTelefono marca como verificado el teléfono del usuario si el código es correcto
+func VerificarTelefono(verifyDto *dto.VerifyDTO) (error, int) {
+	phone := strings.TrimSpace(verifyDto.Phone)
+	if phone == "" || verifyDto.Code == "" {
+		return errors.New("el teléfono y el código son obligatorios"), 400
+	}
+	return verificarCodigo(models.VerificacionTelefono, "phone", phone, verifyDto.Code)
+}
+
+// ReenviarVerificacion envía un código nuevo al email o al teléfono indicado en el DTO si pertenece
+// a un usuario que aún no lo verificó. No retorna error si el dato no está registrado.
+// Los reenvíos se limitan por usuario y canal (ver reenvioPermitido); los que exceden el límite
+// se descartan sin error para no revelar si el dato está registrado.
+func ReenviarVerificacion(canal string, verifyDto *dto.VerifyDTO) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	columna, destino := "email", strings.TrimSpace(verifyDto.Email)
+	if canal == models.VerificacionTelefono {
+		columna, destino = "phone", strings.TrimSpace(verifyDto.Phone)
+	}
+	if destino == "" {
+		return fmt.Errorf("el campo %s es obligatorio", columna), 400
+	}
+
+	var user models.User
+	if err := gormDB.Where(columna+" = ?", destino).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 200
+		}
+		return err, 500
+	}
+
+	verificado := user.EmailVerificado()
+	if canal == models.VerificacionTelefono {
+		verificado = user.TelefonoVerificado()
+	}
+	if verificado || user.Suspendido() {
+		return nil, 200
+	}
+
+	permitido, err := reenvioPermitido(gormDB, user.ID, canal, time.Now())
+	if err != nil {
+		return err, 500
+	}
+	if !permitido {
+		slog.Warn("reenvío de código de verificación descartado por límite", "user_id", user.ID, "canal", canal)
+		return nil, 200
+	}
+
+	if err := enviarCodigoVerificacion(gormDB, &user, canal); err != nil {
+		return errors.New("No se pudo enviar el código de verificación"), 500
+	}
+
+	return nil, 200
+}
+
+// reenvioPermitido indica si se puede enviar otro código del canal al usuario, según los códigos
+// enviados en la última hora. Se cuentan todos los códigos, usados o no, para que verificar
+// o pedir otro código no reinicie el límite.
+func reenvioPermitido(gormDB *gorm.DB, userID uint, canal string, ahora time.Time) (bool, error) {
+	return enviosPermitidos(gormDB.Model(&models.VerificationCode{}).Where("user_id = ? AND channel = ?", userID, canal), ahora)
+}
+
+// enviosPermitidos aplica dentroLimiteReenvio a los registros de la consulta (códigos o tokens enviados)
+// creados en la última hora
+func enviosPermitidos(query *gorm.DB, ahora time.Time) (bool, error) {
+	var enviados []time.Time
+	err := query.Where("created_at > ?", ahora.Add(-time.Hour)).
+		Order("created_at DESC").
+		Pluck("created_at", &enviados).Error
+	if err != nil {
+		return false, err
+	}
+
+	return dentroLimiteReenvio(enviados, ahora), nil
+}
+
+// dentroLimiteReenvio indica si los envíos de la última hora, del más reciente al más antiguo,
+// respetan la espera mínima entre códigos y la cantidad máxima por hora
+func dentroLimiteReenvio(enviados []time.Time, ahora time.Time) bool {
+	if len(enviados) >= maxReenviosPorHora {
+		return false
+	}
+	return len(enviados) == 0 || ahora.Sub(enviados[0]) >= esperaReenvio
+}
+
+// enviarCodigosRegistro envía los códigos de verificación de email y teléfono a un usuario recién registrado.
+// Los errores solo se registran en el log: el usuario puede pedir que se reenvíen.
+func enviarCodigosRegistro(gormDB *gorm.DB, user *models.User) {
+	for _, canal := range []string{models.VerificacionEmail, models.VerificacionTelefono} {
+		if err := enviarCodigoVerificacion(gormDB, user, canal); err != nil {
+			slog.Error("no se pudo enviar el código de verificación", "user_id", user.ID, "canal", canal, "error", err)
+		}
+	}
+}
+
+// enviarCodigoVerificacion envía un código para verificar el email o el teléfono actual del usuario
+func enviarCodigoVerificacion(gormDB *gorm.DB, user *models.User, canal string) error {
+	destino := user.Email
+	if canal == models.VerificacionTelefono {
+		destino = user.Phone
+	}
+	return enviarCodigo(gormDB, user.ID, canal, destino)
+}
+
+// enviarCodigo invalida los códigos pendientes del canal, genera uno nuevo para destino y lo envía con el notificador
+func enviarCodigo(gormDB *gorm.DB, userID uint, canal, destino string) error {
+	codigo, err := generarCodigoNumerico(6)
+	if err != nil {
+		return err
+	}
+
+	mensaje := notificador.Mensaje{
+		Canal:   notificador.CanalEmail,
+		Destino: destino,
+		Asunto:  "Verifique su email",
+		Cuerpo:  fmt.Sprintf("Su código de verificación es %s. Vence en %d minutos.", codigo, int(duracionCodigoVerificacion.Minutes())),
+	}
+	switch canal {
+	case models.VerificacionTelefono:
+		mensaje.Canal = notificador.CanalSMS
+		mensaje.Asunto = "Verifique su teléfono"
+	case models.VerificacionNuevoEmail:
+		mensaje.Asunto = "Confirme su nuevo email"
+	}
+
+	ahora := time.Now()
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.VerificationCode{}).
+			Where("user_id = ? AND channel = ? AND used_at IS NULL", userID, canal).
+			Update("used_at", ahora).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Omit(clause.Associations).Create(&models.VerificationCode{
+			UserID:      userID,
+			Channel:     canal,
+			Destination: mensaje.Destino,
+			CodeHash:    hashToken(codigo),
+			ExpiresAt:   ahora.Add(duracionCodigoVerificacion),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return notificador.Enviar(mensaje)
+}
+
+// verificarCodigo valida el código más reciente del canal para el usuario cuyo email o teléfono es destino.
+// Cada intento fallido se cuenta; al llegar al máximo el código queda invalidado.
+func verificarCodigo(canal, columna, destino, codigo string) (error, int) {
+	gormDB, err := ConnectDB()
+	if err != nil {
+		return err, 500
+	}
+
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where(columna+" = ?", destino).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errCodigoNoValido
+			}
+			return err
+		}
+
+		var registro models.VerificationCode
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("user_id = ? AND channel = ? AND destination = ? AND used_at IS NULL", user.ID, canal, destino).
+			Order("created_at DESC").
+			First(&registro).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errCodigoNoValido
+			}
+			return err
+		}
+
+		if time.Now().After(registro.ExpiresAt) || registro.Attempts >= intentosCodigoVerificacion {
+			return errCodigoNoValido
+		}
+
+		if subtle.ConstantTimeCompare([]byte(registro.CodeHash), []byte(hashToken(strings.TrimSpace(codigo)))) != 1 {
+			// El intento fallido se guarda aunque la verificación falle, por eso se hace fuera de la transacción
+			return &intentoFallido{codigoID: registro.ID}
+		}
+
+		ahora := time.Now()
+		if err := tx.Model(&registro).Update("used_at", ahora).Error; err != nil {
+			return err
+		}
+
+		campo := "email_verified_at"
+		if canal == models.VerificacionTelefono {
+			campo = "phone_verified_at"
+		}
+		return tx.Model(&user).Update(campo, ahora).Error
+	})
+
+	var fallido *intentoFallido
+	if errors.As(err, &fallido) {
+		gormDB.Model(&models.VerificationCode{}).Where("id = ?", fallido.codigoID).
+			UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		return errCodigoNoValido, 400
+	}
+	if err != nil {
+		return err, codigoError(err)
+	}
+
+	return nil, 200
+}
+
+// intentoFallido indica que el código ingresado no coincide con el registro codigoID
+type intentoFallido struct {
+	codigoID uint
+}
+
+func (e *intentoFallido) Error() string {
+	return errCodigoNoValido.Error()
+}
+
+// generarCodigoNumerico genera un código aleatorio de la cantidad de dígitos indicada
+func generarCodigoNumerico(digitos int) (string, error) {
+	maximo := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digitos)), nil)
+	n, err := rand.Int(rand.Reader, maximo)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digitos, n), nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDentroLimiteReenvio(t *testing.T) {
+	ahora := time.Now()
+	hace := func(d time.Duration) time.Time { return ahora.Add(-d) }
+
+	casos := []struct {
+		nombre    string
+		enviados  []time.Time
+		permitido bool
+	}{
+		{nombre: "sin envíos", permitido: true},
+		{nombre: "envío reciente", enviados: []time.Time{hace(30 * time.Second)}},
+		{nombre: "pasada la espera", enviados: []time.Time{hace(esperaReenvio)}, permitido: true},
+		{
+			nombre:    "bajo el máximo por hora",
+			enviados:  []time.Time{hace(2 * time.Minute), hace(10 * time.Minute), hace(20 * time.Minute), hace(30 * time.Minute)},
+			permitido: true,
+		},
+		{
+			nombre:   "máximo por hora",
+			enviados: []time.Time{hace(2 * time.Minute), hace(10 * time.Minute), hace(20 * time.Minute), hace(30 * time.Minute), hace(40 * time.Minute)},
+		},
+	}
+
+	for _, caso := range casos {
+		if obtenido := dentroLimiteReenvio(caso.enviados, ahora); obtenido != caso.permitido {
+			t.Errorf("%s: dentroLimiteReenvio = %v, se esperaba %v", caso.nombre, obtenido, caso.permitido)
+		}
+	}
+}
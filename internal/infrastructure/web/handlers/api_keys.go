@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// llaveAPIData construye la respuesta JSON de una llave de API (sin la llave ni su hash)
+func llaveAPIData(llave *models.APIKey) map[string]any {
+	return map[string]any{
+		"id":           llave.ID,
+		"name":         llave.Name,
+		"prefix":       llave.Prefix,
+		"scopes":       llave.Scopes,
+		"rate_limit":   llave.RateLimit,
+		"expires_at":   llave.ExpiresAt,
+		"last_used_at": llave.LastUsedAt,
+		"last_used_ip": llave.LastUsedIP,
+		"revoked_at":   llave.RevokedAt,
+		"active":       llave.Vigente(),
+		"created_by":   llave.CreatedBy.Email,
+		"created_at":   llave.CreatedAt,
+	}
+}
+
+// parseAPIKeyData parsea los datos de una llave de API desde form-data o JSON.
+// En form-data, scopes puede enviarse separado por comas o repetido y expires_at usa RFC3339 o YYYY-MM-DD.
+func parseAPIKeyData(r *http.Request) (*dto.APIKey, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var apiKeyDto dto.APIKey
+		if err := json.NewDecoder(r.Body).Decode(&apiKeyDto); err != nil {
+			return nil, err
+		}
+		return &apiKeyDto, nil
+	}
+
+	// Default: form-data
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	apiKeyDto := &dto.APIKey{Name: r.FormValue("name")}
+
+	for _, valor := range r.Form["scopes"] {
+		for parte := range strings.SplitSeq(valor, ",") {
+			if parte = strings.TrimSpace(parte); parte != "" {
+				apiKeyDto.Scopes = append(apiKeyDto.Scopes, parte)
+			}
+		}
+	}
+
+	if valor := r.FormValue("expires_at"); valor != "" {
+		expiresAt, _, err := parseFecha(valor)
+		if err != nil {
+			return nil, err
+		}
+		apiKeyDto.ExpiresAt = &expiresAt
+	}
+
+	if valor := r.FormValue("rate_limit"); valor != "" {
+		rateLimit, err := strconv.Atoi(valor)
+		if err != nil {
+			return nil, err
+		}
+		apiKeyDto.RateLimit = rateLimit
+	}
+
+	return apiKeyDto, nil
+}
+
+func ObtenerLlavesAPIHandler(w http.ResponseWriter, r *http.Request) {
+	llaves, err := services.ObtenerLlavesAPI()
+	if err != nil {
+		handler.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	dataLlaves := make([]map[string]any, len(llaves))
+	for i := range llaves {
+		dataLlaves[i] = llaveAPIData(&llaves[i])
+	}
+
+	handler.Success(w, r, "", dataLlaves)
+}
+
+func ObtenerLlaveAPIHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de llave no válido")
+		return
+	}
+
+	llave, err, code := services.ObtenerLlaveAPI(id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", llaveAPIData(llave))
+}
+
+// CrearLlaveAPIHandler crea una llave de API. La llave solo se incluye en esta respuesta.
+func CrearLlaveAPIHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	apiKeyDto, err := parseAPIKeyData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(apiKeyDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	llave, clave, err, code := services.CrearLlaveAPI(adminID, apiKeyDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	data := llaveAPIData(llave)
+	data["key"] = clave
+
+	handler.Success(w, r, "Llave de API creada; guárdela ahora, no volverá a mostrarse", data)
+}
+
+func RevocarLlaveAPIHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de llave no válido")
+		return
+	}
+
+	llave, err, code := services.RevocarLlaveAPI(id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Llave de API revocada correctamente", llaveAPIData(llave))
+}
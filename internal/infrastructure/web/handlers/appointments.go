@@ -0,0 +1,508 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseAppointmentData parsea los datos de una cita desde form-data o JSON.
+// En form-data, service_ids puede enviarse separado por comas o repetido.
+func parseAppointmentData(r *http.Request) (*dto.Appointment, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var appointmentDto dto.Appointment
+		if err := json.NewDecoder(r.Body).Decode(&appointmentDto); err != nil {
+			return nil, err
+		}
+		return &appointmentDto, nil
+	}
+
+	// Default: form-data
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	employeeID, err := uintFormulario(r, "employee_id")
+	if err != nil {
+		return nil, err
+	}
+
+	startAt, err := tiempoFormulario(r, "start_at")
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIDs, err := parseIDs(r.Form["service_ids"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.Appointment{
+		EmployeeID: employeeID,
+		StartAt:    startAt,
+		ServiceIDs: serviceIDs,
+	}, nil
+}
+
+// parseRescheduleData parsea los datos para reprogramar una cita desde form-data o JSON
+func parseRescheduleData(r *http.Request) (*dto.Reschedule, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var rescheduleDto dto.Reschedule
+		if err := json.NewDecoder(r.Body).Decode(&rescheduleDto); err != nil {
+			return nil, err
+		}
+		return &rescheduleDto, nil
+	}
+
+	// Default: form-data
+	startAt, err := tiempoFormulario(r, "start_at")
+	if err != nil {
+		return nil, err
+	}
+
+	employeeID, err := uintFormulario(r, "employee_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.Reschedule{StartAt: startAt, EmployeeID: employeeID}, nil
+}
+
+// parseReassignData parsea el empleado al que se reasigna una cita desde form-data o JSON
+func parseReassignData(r *http.Request) (*dto.Reassign, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var reassignDto dto.Reassign
+		if err := json.NewDecoder(r.Body).Decode(&reassignDto); err != nil {
+			return nil, err
+		}
+		return &reassignDto, nil
+	}
+
+	// Default: form-data
+	employeeID, err := uintFormulario(r, "employee_id")
+	if err != nil {
+		return nil, err
+	}
+	return &dto.Reassign{EmployeeID: employeeID}, nil
+}
+
+// parseAdminAppointmentData parsea los datos de una cita creada por un administrador desde form-data o JSON
+func parseAdminAppointmentData(r *http.Request) (*dto.AdminAppointment, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var appointmentDto dto.AdminAppointment
+		if err := json.NewDecoder(r.Body).Decode(&appointmentDto); err != nil {
+			return nil, err
+		}
+		return &appointmentDto, nil
+	}
+
+	// Default: form-data
+	appointmentDto, err := parseAppointmentData(r)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uintFormulario(r, "user_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AdminAppointment{
+		Appointment:   *appointmentDto,
+		UserID:        userID,
+		CustomerName:  r.FormValue("customer_name"),
+		CustomerPhone: r.FormValue("customer_phone"),
+	}, nil
+}
+
+// parseAppointmentFilter obtiene los filtros de citas desde los parámetros de la URL.
+// Las fechas aceptan RFC3339 o YYYY-MM-DD; en el segundo caso "to" incluye el día completo.
+func parseAppointmentFilter(r *http.Request) (*dto.AppointmentFilter, error) {
+	query := r.URL.Query()
+	filtro := &dto.AppointmentFilter{Status: query.Get("status")}
+
+	if from := query.Get("from"); from != "" {
+		fecha, _, err := parseFecha(from)
+		if err != nil {
+			return nil, errors.New("fecha inicial no válida")
+		}
+		filtro.From = fecha
+	}
+
+	if to := query.Get("to"); to != "" {
+		fecha, soloFecha, err := parseFecha(to)
+		if err != nil {
+			return nil, errors.New("fecha final no válida")
+		}
+		if soloFecha {
+			fecha = fecha.AddDate(0, 0, 1)
+		}
+		filtro.To = fecha
+	}
+
+	for nombre, destino := range map[string]*uint{"employee": &filtro.EmployeeID, "user": &filtro.UserID} {
+		if valor := query.Get(nombre); valor != "" {
+			id, err := strconv.ParseUint(valor, 10, 64)
+			if err != nil {
+				return nil, errors.New("ID no válido en el filtro " + nombre)
+			}
+			*destino = uint(id)
+		}
+	}
+
+	return filtro, nil
+}
+
+// parseFecha interpreta una fecha en formato RFC3339 o YYYY-MM-DD (hora local).
+// Indica además si el valor solo contenía la fecha.
+func parseFecha(valor string) (time.Time, bool, error) {
+	if fecha, err := time.Parse(time.RFC3339, valor); err == nil {
+		return fecha, false, nil
+	}
+
+	fecha, err := time.ParseInLocation("2006-01-02", valor, time.Local)
+	return fecha, true, err
+}
+
+// citaData construye la respuesta JSON de una cita
+func citaData(cita *models.Appointment) map[string]any {
+	servicios := make([]map[string]any, len(cita.AppointmentServices))
+	for i, appointmentService := range cita.AppointmentServices {
+		servicios[i] = map[string]any{
+			"id":             appointmentService.ServiceID,
+			"name":           appointmentService.Service.Name,
+			"code":           appointmentService.Service.Code,
+			"estimated_time": appointmentService.Service.EstimatedTime,
+		}
+	}
+
+	data := map[string]any{
+		"id":          cita.ID,
+		"start_at":    cita.StartAt,
+		"end_at":      cita.EndAt,
+		"status":      cita.Status,
+		"day_id":      cita.DayID,
+		"user_id":     cita.UserID,
+		"employee_id": cita.EmployeeID,
+		"services":    servicios,
+	}
+
+	if cita.UserID == nil {
+		data["customer_name"] = cita.CustomerName
+		data["customer_phone"] = cita.CustomerPhone
+	}
+
+	if cita.Employee.ID != 0 {
+		data["employee_name"] = cita.Employee.Name
+	}
+
+	if cita.User.ID != 0 {
+		data["user_name"] = cita.User.Name
+	}
+
+	return data
+}
+
+// errorCita responde con el error devuelto por los servicios de citas.
+// Si el error es un conflicto de horario, incluye la ventana de la cita con la que se cruza;
+// si la operación afecta citas futuras, incluye la lista de esas citas.
+func errorCita(w http.ResponseWriter, r *http.Request, code int, err error) {
+	var conflicto *services.ConflictoCitaError
+	if errors.As(err, &conflicto) {
+		handler.ErrorData(w, r, http.StatusConflict, conflicto.Error(), map[string]any{
+			"appointment_id": conflicto.AppointmentID,
+			"start_at":       conflicto.StartAt,
+			"end_at":         conflicto.EndAt,
+		})
+		return
+	}
+
+	var afectadas *services.CitasAfectadasError
+	if errors.As(err, &afectadas) {
+		handler.ErrorData(w, r, http.StatusConflict, afectadas.Error(), map[string]any{
+			"appointments": citasAfectadasData(afectadas.Citas),
+		})
+		return
+	}
+
+	handler.Error(w, r, code, err.Error())
+}
+
+// citasAfectadasData construye un resumen de las citas afectadas por una operación administrativa
+func citasAfectadasData(citas []models.Appointment) []map[string]any {
+	data := make([]map[string]any, len(citas))
+	for i, cita := range citas {
+		data[i] = map[string]any{
+			"id":          cita.ID,
+			"start_at":    cita.StartAt,
+			"end_at":      cita.EndAt,
+			"status":      cita.Status,
+			"user_id":     cita.UserID,
+			"employee_id": cita.EmployeeID,
+		}
+	}
+	return data
+}
+
+func CrearCitaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	appointmentDto, err := parseAppointmentData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(appointmentDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	cita, err, code := services.CrearCita(userID, appointmentDto)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "Cita creada correctamente", citaData(cita))
+}
+
+func CancelarCitaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	citaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de cita no válido")
+		return
+	}
+
+	cita, err, code := services.CancelarCita(userID, citaID)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "Cita cancelada correctamente", citaData(cita))
+}
+
+func ReprogramarCitaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	citaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de cita no válido")
+		return
+	}
+
+	rescheduleDto, err := parseRescheduleData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(rescheduleDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	cita, err, code := services.ReprogramarCita(userID, citaID, rescheduleDto)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "Cita reprogramada correctamente", citaData(cita))
+}
+
+func ObtenerDisponibilidadHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	fecha, err := time.ParseInLocation("2006-01-02", query.Get("date"), time.Local)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Fecha no válida, formato esperado YYYY-MM-DD")
+		return
+	}
+
+	serviceIDs, err := parseIDs(query["services"])
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Servicios no válidos")
+		return
+	}
+
+	consulta := dto.AvailabilityQuery{
+		Date:       fecha,
+		ServiceIDs: serviceIDs,
+	}
+
+	if employee := query.Get("employee"); employee != "" {
+		employeeID, err := strconv.ParseUint(employee, 10, 64)
+		if err != nil {
+			handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+			return
+		}
+		consulta.EmployeeID = uint(employeeID)
+	}
+
+	if granularity := query.Get("granularity"); granularity != "" {
+		consulta.Granularity, err = strconv.Atoi(granularity)
+		if err != nil {
+			handler.Error(w, r, http.StatusBadRequest, "Granularidad no válida")
+			return
+		}
+	}
+
+	if errores := validator.Validar(&consulta); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	disponibilidad, err, code := services.ObtenerDisponibilidad(&consulta)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	dataDisponibilidad := make([]map[string]any, len(disponibilidad))
+	for i, empleado := range disponibilidad {
+		dataDisponibilidad[i] = map[string]any{
+			"employee_id": empleado.EmployeeID,
+			"name":        empleado.Name,
+			"slots":       empleado.Slots,
+		}
+	}
+
+	handler.Success(w, r, "", dataDisponibilidad)
+}
+
+func ObtenerCitasHandler(w http.ResponseWriter, r *http.Request) {
+	filtro, err := parseAppointmentFilter(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if errores := validator.Validar(filtro); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	citas, err, code := services.ObtenerCitas(filtro)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	dataCitas := make([]map[string]any, len(citas))
+	for i := range citas {
+		dataCitas[i] = citaData(&citas[i])
+	}
+
+	handler.Success(w, r, "", dataCitas)
+}
+
+func CrearCitaAdminHandler(w http.ResponseWriter, r *http.Request) {
+	appointmentDto, err := parseAdminAppointmentData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(appointmentDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	cita, err, code := services.CrearCitaAdmin(appointmentDto)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "Cita creada correctamente", citaData(cita))
+}
+
+func ReasignarCitaHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	citaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de cita no válido")
+		return
+	}
+
+	reassignDto, err := parseReassignData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(reassignDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	cita, err, code := services.ReasignarCita(adminID, citaID, reassignDto.EmployeeID)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "Cita reasignada correctamente", citaData(cita))
+}
+
+func CompletarCitaHandler(w http.ResponseWriter, r *http.Request) {
+	marcarCita(w, r, models.CitaCompletada)
+}
+
+func MarcarNoAsistioHandler(w http.ResponseWriter, r *http.Request) {
+	marcarCita(w, r, models.CitaNoAsistio)
+}
+
+// marcarCita actualiza el estado final de una cita (completada o no asistió)
+func marcarCita(w http.ResponseWriter, r *http.Request, estado string) {
+	citaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de cita no válido")
+		return
+	}
+
+	cita, err, code := services.MarcarCita(citaID, estado)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", citaData(cita))
+}
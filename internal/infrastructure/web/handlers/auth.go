@@ -7,13 +7,43 @@ import (
 	"backend_reservation/internal/infrastructure/web/middleware"
 	"backend_reservation/pkg/firmador"
 	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// parseRefreshData parsea el refresh token desde form-data o JSON
+func parseRefreshData(r *http.Request) (*dto.RefreshDTO, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var refreshDto dto.RefreshDTO
+		if err := json.NewDecoder(r.Body).Decode(&refreshDto); err != nil {
+			return nil, err
+		}
+		return &refreshDto, nil
+	}
+
+	// Default: form-data
+	return &dto.RefreshDTO{RefreshToken: r.FormValue("refresh_token")}, nil
+}
+
+// tokensData construye la respuesta con el par de tokens de la sesión.
+// Se conserva la clave "token" para el token de acceso por compatibilidad con los clientes existentes.
+func tokensData(tokens *services.Tokens) map[string]interface{} {
+	return map[string]interface{}{
+		"token":                    tokens.AccessToken,
+		"expires_at":               tokens.AccessExpiresAt.Format(time.RFC3339),
+		"refresh_token":            tokens.RefreshToken,
+		"refresh_token_expires_at": tokens.RefreshExpiresAt.Format(time.RFC3339),
+	}
+}
+
 // parseLoginData parsea los datos de login desde form-data o JSON
 func parseLoginData(r *http.Request) (*dto.LoginDTO, error) {
 	contentType := r.Header.Get("Content-Type")
@@ -64,19 +94,52 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := services.Login(loginDto)
+	if errores := validator.Validar(loginDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	user, err := services.Login(loginDto, middleware.ClientIP(r))
+
+	var bloqueo *services.BloqueoAccesoError
+	if errors.As(err, &bloqueo) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(bloqueo.ReintentarEn.Seconds()))))
+		handler.Error(w, r, http.StatusTooManyRequests, "Too many failed login attempts")
+		return
+	}
+
+	if errors.Is(err, services.ErrCuentaSuspendida) {
+		handler.Error(w, r, http.StatusForbidden, "Cuenta suspendida")
+		return
+	}
+
+	if errors.Is(err, services.ErrCredencialesInvalidas) {
+		handler.Error(w, r, http.StatusUnauthorized, "Login failed")
+		return
+	}
 
 	if err != nil {
-		handler.Error(w, r, http.StatusNotFound, "Login failed")
+		handler.Error(w, r, http.StatusInternalServerError, "Login failed")
 		return
 	}
-	data := map[string]string{
-		"user_id": strconv.Itoa(int(user.ID)),
-		"email":   user.Email,
-		"name":    user.Name,
+
+	// Con la autenticación en dos pasos activada, la sesión se emite en Login2FAHandler
+	if user.SegundoFactorActivo() {
+		desafio, expiraEn, err := services.CrearDesafioSegundoFactor(user)
+		if err != nil {
+			handler.Error(w, r, http.StatusInternalServerError, "No se pudo firmar el token")
+			return
+		}
+
+		handler.Success(w, r, "Two-factor authentication required", map[string]any{
+			"two_factor_required": true,
+			"challenge_token":     desafio,
+			"expires_at":          expiraEn.Format(time.RFC3339),
+		})
+		return
 	}
 
-	token, err := firmador.FirmarToken(data, 1440*time.Minute) //token valido por 24 horas
+	tokens, err := services.EmitirTokens(user, dispositivoSolicitud(r))
 
 	if err != nil {
 		handler.Error(w, r, http.StatusInternalServerError, "No se pudo firmar el token")
@@ -87,14 +150,114 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		Email: user.Email,
 	}
 
-	returnData := map[string]interface{}{
-		"token": token,
-		"user":  dataUser,
+	returnData := tokensData(tokens)
+	returnData["user"] = dataUser
+
+	handler.Success(w, r, "Login successful", returnData)
+}
+
+// Login2FAHandler completa el login de un usuario con autenticación en dos pasos:
+// recibe el token de desafío de LoginHandler y un código TOTP o de recuperación.
+func Login2FAHandler(w http.ResponseWriter, r *http.Request) {
+	twoFactorDto, err := parseTwoFactorData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(twoFactorDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	user, err, code := services.CompletarLoginSegundoFactor(twoFactorDto.ChallengeToken, twoFactorDto.Code, middleware.ClientIP(r))
+
+	var bloqueo *services.BloqueoAccesoError
+	if errors.As(err, &bloqueo) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(bloqueo.ReintentarEn.Seconds()))))
+		handler.Error(w, r, http.StatusTooManyRequests, "Too many failed login attempts")
+		return
+	}
+
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	tokens, err := services.EmitirTokens(user, dispositivoSolicitud(r))
+	if err != nil {
+		handler.Error(w, r, http.StatusInternalServerError, "No se pudo firmar el token")
+		return
+	}
+
+	returnData := tokensData(tokens)
+	returnData["user"] = domain.User{
+		Name:  user.Name,
+		Email: user.Email,
 	}
 
 	handler.Success(w, r, "Login successful", returnData)
 }
 
+func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	refreshDto, err := parseRefreshData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(refreshDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	tokens, user, err, code := services.RenovarTokens(refreshDto.RefreshToken, dispositivoSolicitud(r))
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	returnData := tokensData(tokens)
+	returnData["user"] = domain.User{
+		Name:  user.Name,
+		Email: user.Email,
+	}
+
+	handler.Success(w, r, "Token refreshed", returnData)
+}
+
+// LogoutHandler cierra la sesión: revoca el refresh token enviado en el cuerpo
+// y el token de acceso del header Authorization, si es válido.
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	refreshDto, err := parseRefreshData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	// El refresh token es opcional si se envía el token de acceso
+	if errores := validator.ValidarParcial(refreshDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	var jti string
+	var expiraEn time.Time
+	if tokenStr := middleware.ExtractToken(r.Header.Get("Authorization")); tokenStr != "" {
+		if token, err := firmador.VerificarToken(tokenStr); err == nil {
+			jti, _ = token.GetJti()
+			expiraEn, _ = token.GetExpiration()
+		}
+	}
+
+	if err, code := services.CerrarSesion(refreshDto.RefreshToken, jti, expiraEn); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Logout successful", nil)
+}
+
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	registerDto, err := parseRegisterData(r)
@@ -103,6 +266,11 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if errores := validator.Validar(registerDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
 	user, err := services.Register(registerDto)
 
 	if err != nil {
@@ -137,10 +305,12 @@ func GetUserDataHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userData := map[string]any{
-		"id":    user.ID,
-		"name":  user.Name,
-		"email": user.Email,
-		"role":  user.Role.Code,
+		"id":             user.ID,
+		"name":           user.Name,
+		"email":          user.Email,
+		"role":           user.Role.Code,
+		"email_verified": user.EmailVerificado(),
+		"phone_verified": user.TelefonoVerificado(),
 	}
 
 	handler.Success(w, r, "User data retrieved successfully", userData)
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// tamanoMaximoICS limita el tamaño de los archivos iCalendar importados
+const tamanoMaximoICS = 1 << 20
+
+// parseClosureData parsea los datos de un cierre desde form-data o JSON.
+// En form-data, start_at y end_at aceptan RFC3339 o YYYY-MM-DD; una fecha final sin hora incluye ese día completo.
+func parseClosureData(r *http.Request) (*dto.Closure, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var closureDto dto.Closure
+		if err := json.NewDecoder(r.Body).Decode(&closureDto); err != nil {
+			return nil, err
+		}
+		return &closureDto, nil
+	}
+
+	// Default: form-data
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	closureDto := &dto.Closure{
+		Kind:        r.FormValue("kind"),
+		Description: r.FormValue("description"),
+	}
+
+	employeeID, err := parseEmpleadoOpcional(r.FormValue("employee_id"))
+	if err != nil {
+		return nil, err
+	}
+	closureDto.EmployeeID = employeeID
+
+	// Las fechas vacías quedan en cero para que las informe la validación
+	if valor := r.FormValue("start_at"); valor != "" {
+		startAt, _, err := parseFecha(valor)
+		if err != nil {
+			return nil, err
+		}
+		closureDto.StartAt = startAt
+	}
+
+	if valor := r.FormValue("end_at"); valor != "" {
+		endAt, soloFecha, err := parseFecha(valor)
+		if err != nil {
+			return nil, err
+		}
+		if soloFecha {
+			endAt = endAt.AddDate(0, 0, 1)
+		}
+		closureDto.EndAt = endAt
+	}
+
+	return closureDto, nil
+}
+
+// parseClosureUpdateData parsea la actualización parcial de un cierre desde form-data o JSON.
+// Los campos que no se envían no se modifican; employee_id vacío o 0 convierte el cierre en uno de todo el local.
+// Las fechas aceptan los mismos formatos que en parseClosureData.
+func parseClosureUpdateData(r *http.Request) (*dto.ClosureUpdate, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var closureDto dto.ClosureUpdate
+		if err := json.NewDecoder(r.Body).Decode(&closureDto); err != nil {
+			return nil, err
+		}
+		return &closureDto, nil
+	}
+
+	// Default: form-data
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	closureDto := &dto.ClosureUpdate{
+		Kind:        campoOpcional(r, "kind"),
+		Description: campoOpcional(r, "description"),
+	}
+
+	if valor := campoOpcional(r, "employee_id"); valor != nil {
+		employeeID, err := parseEmpleadoOpcional(*valor)
+		if err != nil {
+			return nil, err
+		}
+		if employeeID == nil {
+			employeeID = new(uint)
+		}
+		closureDto.EmployeeID = employeeID
+	}
+
+	if valor := campoOpcional(r, "start_at"); valor != nil {
+		startAt, _, err := parseFecha(*valor)
+		if err != nil {
+			return nil, err
+		}
+		closureDto.StartAt = &startAt
+	}
+
+	if valor := campoOpcional(r, "end_at"); valor != nil {
+		endAt, soloFecha, err := parseFecha(*valor)
+		if err != nil {
+			return nil, err
+		}
+		if soloFecha {
+			endAt = endAt.AddDate(0, 0, 1)
+		}
+		closureDto.EndAt = &endAt
+	}
+
+	return closureDto, nil
+}
+
+// parseClosureFilter parsea los filtros del listado de cierres (from, to y employee)
+func parseClosureFilter(r *http.Request) (*dto.ClosureFilter, error) {
+	query := r.URL.Query()
+	filtro := &dto.ClosureFilter{}
+
+	if from := query.Get("from"); from != "" {
+		fecha, _, err := parseFecha(from)
+		if err != nil {
+			return nil, errors.New("fecha inicial no válida")
+		}
+		filtro.From = fecha
+	}
+
+	if to := query.Get("to"); to != "" {
+		fecha, soloFecha, err := parseFecha(to)
+		if err != nil {
+			return nil, errors.New("fecha final no válida")
+		}
+		if soloFecha {
+			fecha = fecha.AddDate(0, 0, 1)
+		}
+		filtro.To = fecha
+	}
+
+	if employee := query.Get("employee"); employee != "" {
+		id, err := strconv.ParseUint(employee, 10, 64)
+		if err != nil {
+			return nil, errors.New("ID no válido en el filtro employee")
+		}
+		filtro.EmployeeID = uint(id)
+	}
+
+	return filtro, nil
+}
+
+// parseEmpleadoOpcional interpreta un ID de empleado opcional; un valor vacío significa todo el local
+func parseEmpleadoOpcional(valor string) (*uint, error) {
+	if valor == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(valor, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	empleadoID := uint(id)
+	return &empleadoID, nil
+}
+
+// cierreData construye la respuesta JSON de un cierre
+func cierreData(cierre *models.Closure) map[string]any {
+	data := map[string]any{
+		"id":          cierre.ID,
+		"employee_id": cierre.EmployeeID,
+		"kind":        cierre.Kind,
+		"description": cierre.Description,
+		"start_at":    cierre.StartAt,
+		"end_at":      cierre.EndAt,
+		"source":      cierre.Source,
+	}
+
+	if cierre.Employee != nil {
+		data["employee_name"] = cierre.Employee.Name
+	}
+
+	return data
+}
+
+// resultadoCierreData incluye el cierre y las citas afectadas que deben reprogramarse
+func resultadoCierreData(resultado *services.ResultadoCierre) map[string]any {
+	return map[string]any{
+		"closure":               cierreData(&resultado.Cierre),
+		"affected_appointments": citasAfectadasData(resultado.CitasAfectadas),
+	}
+}
+
+func ObtenerCierresHandler(w http.ResponseWriter, r *http.Request) {
+	filtro, err := parseClosureFilter(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cierres, err, code := services.ObtenerCierres(filtro)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	dataCierres := make([]map[string]any, len(cierres))
+	for i := range cierres {
+		dataCierres[i] = cierreData(&cierres[i])
+	}
+
+	handler.Success(w, r, "", dataCierres)
+}
+
+func ObtenerCierreHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de cierre no válido")
+		return
+	}
+
+	cierre, err, code := services.ObtenerCierre(id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", cierreData(cierre))
+}
+
+func CrearCierreHandler(w http.ResponseWriter, r *http.Request) {
+	closureDto, err := parseClosureData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(closureDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	resultado, err, code := services.CrearCierre(closureDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Cierre creado correctamente", resultadoCierreData(resultado))
+}
+
+func ActualizarCierreHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de cierre no válido")
+		return
+	}
+
+	closureDto, err := parseClosureUpdateData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(closureDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	resultado, err, code := services.ActualizarCierre(id, closureDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Cierre actualizado correctamente", resultadoCierreData(resultado))
+}
+
+func EliminarCierreHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de cierre no válido")
+		return
+	}
+
+	deleted, err, code := services.EliminarCierre(id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", map[string]any{
+		"eliminado": deleted,
+	})
+}
+
+// ImportarCierresHandler recibe un archivo .ics en el campo "file" (multipart/form-data).
+// Opcionalmente employee_id limita los cierres a un empleado y kind define su tipo (holiday por defecto).
+func ImportarCierresHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, tamanoMaximoICS+1024)
+	if err := r.ParseMultipartForm(tamanoMaximoICS); err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "El archivo es demasiado grande o la solicitud no es válida")
+		return
+	}
+
+	archivo, _, err := r.FormFile("file")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Debe adjuntar un archivo .ics en el campo file")
+		return
+	}
+	defer archivo.Close()
+
+	employeeID, err := parseEmpleadoOpcional(r.FormValue("employee_id"))
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	resultado, err, code := services.ImportarCierres(archivo, employeeID, r.FormValue("kind"))
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	dataCierres := make([]map[string]any, len(resultado.Cierres))
+	for i := range resultado.Cierres {
+		dataCierres[i] = cierreData(&resultado.Cierres[i])
+	}
+
+	rechazados := make([]map[string]any, len(resultado.Rechazados))
+	for i, rechazado := range resultado.Rechazados {
+		rechazados[i] = map[string]any{
+			"uid":     rechazado.UID,
+			"summary": rechazado.Resumen,
+			"reason":  rechazado.Motivo,
+		}
+	}
+
+	handler.Success(w, r, "Cierres importados correctamente", map[string]any{
+		"created":               resultado.Creados,
+		"updated":               resultado.Actualizados,
+		"closures":              dataCierres,
+		"affected_appointments": citasAfectadasData(resultado.CitasAfectadas),
+		"rejected":              rechazados,
+	})
+}
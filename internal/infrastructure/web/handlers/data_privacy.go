@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"archive/zip"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/logger"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Formatos en los que se puede descargar la exportación de datos (parámetro format)
+const (
+	formatoJSON = "json"
+	formatoZIP  = "zip"
+)
+
+// auditoriaData construye la respuesta JSON de una acción registrada sobre la cuenta
+func auditoriaData(registro *models.AuditLog) map[string]any {
+	return map[string]any{
+		"id":         registro.ID,
+		"action":     registro.Action,
+		"actor_id":   registro.ActorID,
+		"detail":     registro.Detail,
+		"created_at": registro.CreatedAt,
+	}
+}
+
+// historialData construye la respuesta JSON del historial de reprogramaciones de una cita
+func historialData(historial []models.AppointmentHistory) []map[string]any {
+	data := make([]map[string]any, len(historial))
+	for i, cambio := range historial {
+		data[i] = map[string]any{
+			"previous_start_at":    cambio.PreviousStartAt,
+			"previous_end_at":      cambio.PreviousEndAt,
+			"previous_employee_id": cambio.PreviousEmployeeID,
+			"start_at":             cambio.StartAt,
+			"end_at":               cambio.EndAt,
+			"employee_id":          cambio.EmployeeID,
+			"changed_at":           cambio.CreatedAt,
+		}
+	}
+	return data
+}
+
+// exportacionData arma los archivos de la exportación de datos, indexados por nombre
+func exportacionData(r *http.Request, exportacion *services.ExportacionDatos) map[string]any {
+	citas := make([]map[string]any, len(exportacion.Citas))
+	for i := range exportacion.Citas {
+		citas[i] = citaData(&exportacion.Citas[i])
+		citas[i]["history"] = historialData(exportacion.Citas[i].History)
+	}
+
+	auditoria := make([]map[string]any, len(exportacion.Auditoria))
+	for i := range exportacion.Auditoria {
+		auditoria[i] = auditoriaData(&exportacion.Auditoria[i])
+	}
+
+	return map[string]any{
+		"profile":      usuarioData(&exportacion.Usuario),
+		"appointments": citas,
+		"sessions":     sesionesData(r, exportacion.Sesiones),
+		"audit_log":    auditoria,
+		"generated_at": exportacion.GeneradoEn,
+	}
+}
+
+// responderExportacion envía la exportación como JSON o, con format=zip, como un archivo ZIP
+// con un archivo JSON por sección
+func responderExportacion(w http.ResponseWriter, r *http.Request, exportacion *services.ExportacionDatos) {
+	data := exportacionData(r, exportacion)
+
+	if r.URL.Query().Get("format") != formatoZIP {
+		handler.Success(w, r, "", data)
+		return
+	}
+
+	nombre := fmt.Sprintf("user-%d-%s.zip", exportacion.Usuario.ID, exportacion.GeneradoEn.Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nombre))
+
+	// Los errores de escritura solo se registran: la respuesta ya comenzó a enviarse
+	archivo := zip.NewWriter(w)
+	for _, seccion := range []string{"profile", "appointments", "sessions", "audit_log"} {
+		escritor, err := archivo.Create(seccion + ".json")
+		if err != nil {
+			logger.LoggerFromCtx(r.Context()).Error("no se pudo escribir la exportación", "error", err)
+			return
+		}
+
+		codificador := json.NewEncoder(escritor)
+		codificador.SetIndent("", "  ")
+		if err := codificador.Encode(data[seccion]); err != nil {
+			logger.LoggerFromCtx(r.Context()).Error("no se pudo escribir la exportación", "error", err)
+			return
+		}
+	}
+
+	if err := archivo.Close(); err != nil {
+		logger.LoggerFromCtx(r.Context()).Error("no se pudo escribir la exportación", "error", err)
+	}
+}
+
+// formatoExportacionValido indica si el parámetro format es uno de los formatos admitidos (vacío equivale a JSON)
+func formatoExportacionValido(r *http.Request) bool {
+	formato := r.URL.Query().Get("format")
+	return formato == "" || formato == formatoJSON || formato == formatoZIP
+}
+
+// ExportarDatosHandler descarga los datos personales del usuario autenticado (format=json o format=zip)
+func ExportarDatosHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if !formatoExportacionValido(r) {
+		handler.Error(w, r, http.StatusBadRequest, "Formato no válido, use json o zip")
+		return
+	}
+
+	exportacion, err, code := services.ExportarDatosUsuario(userID, userID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	responderExportacion(w, r, exportacion)
+}
+
+// BorrarMisDatosHandler anonimiza la cuenta del usuario autenticado; requiere su contraseña
+func BorrarMisDatosHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	borrarDto, err := parseDeleteAccountData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(borrarDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.BorrarMisDatos(userID, borrarDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Datos de la cuenta borrados correctamente", nil)
+}
+
+// ExportarDatosUsuarioHandler descarga los datos personales de un usuario para la administración
+func ExportarDatosUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	if !formatoExportacionValido(r) {
+		handler.Error(w, r, http.StatusBadRequest, "Formato no válido, use json o zip")
+		return
+	}
+
+	exportacion, err, code := services.ExportarDatosUsuario(adminID, id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	responderExportacion(w, r, exportacion)
+}
+
+// BorrarDatosUsuarioHandler anonimiza la cuenta de un usuario
+func BorrarDatosUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	usuario, err, code := services.BorrarDatosUsuario(adminID, id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Datos de la cuenta borrados correctamente", usuarioData(usuario))
+}
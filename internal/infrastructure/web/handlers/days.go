@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// diaData construye la respuesta JSON de un día de atención
+func diaData(dia *models.Day) map[string]any {
+	return map[string]any{
+		"id":          dia.ID,
+		"code":        dia.Code,
+		"description": dia.Description,
+		"start_at":    dia.StartAt.In(time.Local).Format("15:04"),
+		"end_at":      dia.EndAt.In(time.Local).Format("15:04"),
+		"status":      dia.Status,
+	}
+}
+
+// parseDayData obtiene los datos del día desde form-data. start_at y end_at usan el formato HH:MM.
+func parseDayData(r *http.Request) *dto.Day {
+	return &dto.Day{
+		Code:        r.FormValue("code"),
+		Description: r.FormValue("description"),
+		StartAt:     r.FormValue("start_at"),
+		EndAt:       r.FormValue("end_at"),
+	}
+}
+
+func ObtenerDiasHandler(w http.ResponseWriter, r *http.Request) {
+	dias, err := services.ObtenerDias()
+	if err != nil {
+		handler.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	dataDias := make([]map[string]any, len(dias))
+	for i := range dias {
+		dataDias[i] = diaData(&dias[i])
+	}
+
+	handler.Success(w, r, "", dataDias)
+}
+
+func CrearDiaHandler(w http.ResponseWriter, r *http.Request) {
+	dayDto := parseDayData(r)
+	if errores := validator.Validar(dayDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	dia, err, code := services.CrearDia(dayDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", diaData(dia))
+}
+
+func ObtenerDiaHandler(w http.ResponseWriter, r *http.Request) {
+	diaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de día no válido")
+		return
+	}
+
+	dia, err, code := services.ObtenerDia(diaID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", diaData(dia))
+}
+
+func ActualizarDiaHandler(w http.ResponseWriter, r *http.Request) {
+	diaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de día no válido")
+		return
+	}
+
+	dayDto := parseDayData(r)
+	if errores := validator.ValidarParcial(dayDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	dia, err, code := services.ActualizarDia(diaID, dayDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", diaData(dia))
+}
+
+func ActivarDesactivarDiaHandler(w http.ResponseWriter, r *http.Request) {
+	diaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de día no válido")
+		return
+	}
+
+	forzar, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	dia, err, code := services.ActivarDesactivarDia(diaID, forzar)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "", diaData(dia))
+}
+
+func EliminarDiaHandler(w http.ResponseWriter, r *http.Request) {
+	diaID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de día no válido")
+		return
+	}
+
+	forzar, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	deleted, err, code := services.EliminarDia(diaID, forzar)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "", map[string]any{
+		"eliminado": deleted,
+	})
+}
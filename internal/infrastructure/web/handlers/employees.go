@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// empleadoData construye la respuesta JSON de un empleado
+func empleadoData(empleado *models.Employee) map[string]any {
+	return map[string]any{
+		"id":      empleado.ID,
+		"name":    empleado.Name,
+		"role_id": empleado.RoleID,
+		"role":    empleado.Role.Code,
+		"status":  empleado.Status,
+	}
+}
+
+// parseEmployeeData obtiene los datos del empleado desde form-data.
+// role_id es opcional para permitir actualizaciones parciales.
+func parseEmployeeData(r *http.Request) (*dto.Employee, error) {
+	employeeDto := &dto.Employee{Name: r.FormValue("name")}
+
+	if role := r.FormValue("role_id"); role != "" {
+		roleID, err := strconv.ParseUint(role, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		employeeDto.RoleID = uint(roleID)
+	}
+
+	return employeeDto, nil
+}
+
+func ObtenerEmpleadosHandler(w http.ResponseWriter, r *http.Request) {
+	empleados, err := services.ObtenerEmpleados()
+	if err != nil {
+		handler.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	dataEmpleados := make([]map[string]any, len(empleados))
+	for i := range empleados {
+		dataEmpleados[i] = empleadoData(&empleados[i])
+	}
+
+	handler.Success(w, r, "", dataEmpleados)
+}
+
+func CrearEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	employeeDto, err := parseEmployeeData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Rol no válido")
+		return
+	}
+
+	if errores := validator.Validar(employeeDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	empleado, err, code := services.CrearEmpleado(employeeDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", empleadoData(empleado))
+}
+
+func ObtenerEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	empleado, err, code := services.ObtenerEmpleado(empleadoID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", empleadoData(empleado))
+}
+
+func ActualizarEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	employeeDto, err := parseEmployeeData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Rol no válido")
+		return
+	}
+
+	if errores := validator.ValidarParcial(employeeDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	empleado, err, code := services.ActualizarEmpleado(empleadoID, employeeDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", empleadoData(empleado))
+}
+
+func ActivarDesactivarEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	forzar, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	empleado, err, code := services.ActivarDesactivarEmpleado(empleadoID, forzar)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "", empleadoData(empleado))
+}
+
+func EliminarEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	forzar, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	deleted, err, code := services.EliminarEmpleado(empleadoID, forzar)
+	if err != nil {
+		errorCita(w, r, code, err)
+		return
+	}
+
+	handler.Success(w, r, "", map[string]any{
+		"eliminado": deleted,
+	})
+}
+
+// habilidadData construye la respuesta JSON de un servicio asignado a un empleado
+func habilidadData(habilidad *models.EmployeeService) map[string]any {
+	return map[string]any{
+		"employee_id":            habilidad.EmployeeID,
+		"service_id":             habilidad.ServiceID,
+		"name":                   habilidad.Service.Name,
+		"code":                   habilidad.Service.Code,
+		"estimated_time":         habilidad.Duracion(habilidad.Service),
+		"estimated_time_default": habilidad.Service.EstimatedTime,
+		"override":               habilidad.EstimatedTime != nil,
+	}
+}
+
+func ObtenerServiciosEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	habilidades, err, code := services.ObtenerServiciosEmpleado(empleadoID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	dataHabilidades := make([]map[string]any, len(habilidades))
+	for i := range habilidades {
+		dataHabilidades[i] = habilidadData(&habilidades[i])
+	}
+
+	handler.Success(w, r, "", dataHabilidades)
+}
+
+func AsignarServicioEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	servicioID, err := idDeRuta(r, "service_id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de servicio no válido")
+		return
+	}
+
+	habilidadDto := dto.EmployeeService{}
+	if estimatedTime := r.FormValue("estimated_time"); estimatedTime != "" {
+		parseEstimatedTime, err := strconv.ParseUint(estimatedTime, 10, 64)
+		if err != nil {
+			handler.Error(w, r, http.StatusBadRequest, "Tiempo estimado no válido")
+			return
+		}
+		minutos := uint(parseEstimatedTime)
+		habilidadDto.EstimatedTime = &minutos
+	}
+
+	if errores := validator.Validar(&habilidadDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	habilidad, err, code := services.AsignarServicioEmpleado(empleadoID, servicioID, &habilidadDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", habilidadData(habilidad))
+}
+
+func QuitarServicioEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	servicioID, err := idDeRuta(r, "service_id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de servicio no válido")
+		return
+	}
+
+	deleted, err, code := services.QuitarServicioEmpleado(empleadoID, servicioID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", map[string]any{
+		"eliminado": deleted,
+	})
+}
+
+// horarioData construye la respuesta JSON del horario semanal de un empleado
+func horarioData(bloques []models.EmployeeSchedule) []map[string]any {
+	data := make([]map[string]any, len(bloques))
+	for i, bloque := range bloques {
+		data[i] = map[string]any{
+			"weekday":    bloque.Weekday,
+			"kind":       bloque.Kind,
+			"start_time": bloque.StartTime,
+			"end_time":   bloque.EndTime,
+		}
+	}
+	return data
+}
+
+// excepcionesData construye la respuesta JSON de las excepciones de horario de un empleado
+func excepcionesData(excepciones []models.EmployeeScheduleOverride) []map[string]any {
+	data := make([]map[string]any, len(excepciones))
+	for i, excepcion := range excepciones {
+		data[i] = map[string]any{
+			"date":       excepcion.Date.Format("2006-01-02"),
+			"kind":       excepcion.Kind,
+			"start_time": excepcion.StartTime,
+			"end_time":   excepcion.EndTime,
+		}
+	}
+	return data
+}
+
+func ObtenerHorarioEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	bloques, err, code := services.ObtenerHorarioEmpleado(empleadoID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", horarioData(bloques))
+}
+
+func ActualizarHorarioEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	var horarioDto dto.WeeklySchedule
+	if err := json.NewDecoder(r.Body).Decode(&horarioDto); err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(&horarioDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	bloques, err, code := services.ActualizarHorarioEmpleado(empleadoID, &horarioDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Horario actualizado correctamente", horarioData(bloques))
+}
+
+func ObtenerExcepcionesEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	query := r.URL.Query()
+	excepciones, err, code := services.ObtenerExcepcionesEmpleado(empleadoID, query.Get("from"), query.Get("to"))
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", excepcionesData(excepciones))
+}
+
+func GuardarExcepcionEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	var excepcionDto dto.ScheduleOverride
+	if err := json.NewDecoder(r.Body).Decode(&excepcionDto); err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(&excepcionDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	excepciones, err, code := services.GuardarExcepcionEmpleado(empleadoID, &excepcionDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Excepción de horario guardada correctamente", excepcionesData(excepciones))
+}
+
+func EliminarExcepcionEmpleadoHandler(w http.ResponseWriter, r *http.Request) {
+	empleadoID, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de empleado no válido")
+		return
+	}
+
+	deleted, err, code := services.EliminarExcepcionEmpleado(empleadoID, r.PathValue("date"))
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", map[string]any{
+		"eliminado": deleted,
+	})
+}
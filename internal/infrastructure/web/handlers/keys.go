@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"backend_reservation/pkg/firmador"
+	"backend_reservation/pkg/handler"
+	"net/http"
+)
+
+// LlavesPublicasHandler publica las claves públicas con las que terceros pueden verificar los tokens v4.public.
+// Si el firmador está en modo local no hay claves que publicar.
+func LlavesPublicasHandler(w http.ResponseWriter, r *http.Request) {
+	if firmador.Modo() != firmador.ModoPublico {
+		handler.Error(w, r, http.StatusNotFound, "Los tokens no usan el modo público")
+		return
+	}
+
+	llaves := firmador.LlavesPublicas()
+	dataLlaves := make([]map[string]any, len(llaves))
+	for i, llave := range llaves {
+		dataLlaves[i] = map[string]any{
+			"kid":        llave.ID,
+			"version":    "v4",
+			"purpose":    "public",
+			"public_key": llave.Hex,
+			"active":     llave.Activa,
+		}
+		if llave.RetiradaEn != nil {
+			dataLlaves[i]["retired_at"] = llave.RetiradaEn
+		}
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	handler.Success(w, r, "", map[string]any{
+		"keys": dataLlaves,
+	})
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"backend_reservation/internal/infrastructure/web/middleware"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usuarioAutenticado obtiene el ID del usuario autenticado desde el contexto de la solicitud
+func usuarioAutenticado(r *http.Request) (uint, error) {
+	userId, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		return 0, errors.New("usuario no autenticado")
+	}
+
+	parseUserId, err := strconv.Atoi(userId)
+	if err != nil {
+		return 0, errors.New("ID de usuario no válido")
+	}
+
+	return uint(parseUserId), nil
+}
+
+// actorAutenticado obtiene el ID de quien realiza una acción administrativa, para la auditoría y las
+// verificaciones sobre la propia cuenta. Con una llave de API es el administrador que creó la llave.
+func actorAutenticado(r *http.Request) (uint, error) {
+	if llave, ok := middleware.GetAPIKeyFromContext(r.Context()); ok {
+		if llave.CreatedByID == 0 {
+			return 0, errors.New("la llave de API no tiene un responsable")
+		}
+		return llave.CreatedByID, nil
+	}
+
+	return usuarioAutenticado(r)
+}
+
+// idDeRuta obtiene y valida un ID numérico de los parámetros de la ruta
+func idDeRuta(r *http.Request, nombre string) (uint, error) {
+	valor := r.PathValue(nombre)
+	if valor == "" {
+		return 0, errors.New("ID no proporcionado")
+	}
+
+	id, err := strconv.ParseUint(valor, 10, 64)
+	if err != nil || id == 0 {
+		return 0, errors.New("ID no válido")
+	}
+
+	return uint(id), nil
+}
+
+// uintFormulario interpreta un número entero sin signo del formulario. Un campo vacío es cero,
+// para que su ausencia la informe la validación del DTO (regla required) y no un error de formato.
+func uintFormulario(r *http.Request, nombre string) (uint, error) {
+	valor := r.FormValue(nombre)
+	if valor == "" {
+		return 0, nil
+	}
+
+	numero, err := strconv.ParseUint(valor, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s no válido: %w", nombre, err)
+	}
+	return uint(numero), nil
+}
+
+// tiempoFormulario interpreta una fecha y hora RFC3339 del formulario. Un campo vacío es el tiempo cero (ver uintFormulario).
+func tiempoFormulario(r *http.Request, nombre string) (time.Time, error) {
+	valor := r.FormValue(nombre)
+	if valor == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, valor)
+}
+
+// parseIDs convierte una lista de IDs separados por comas (o valores repetidos) en un slice de uint
+func parseIDs(valores []string) ([]uint, error) {
+	var ids []uint
+	for _, valor := range valores {
+		for parte := range strings.SplitSeq(valor, ",") {
+			parte = strings.TrimSpace(parte)
+			if parte == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(parte, 10, 64)
+			if err != nil || id == 0 {
+				return nil, errors.New("ID no válido: " + parte)
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// parseForgotPasswordData parsea el email para solicitar el restablecimiento desde form-data o JSON
+func parseForgotPasswordData(r *http.Request) (*dto.ForgotPasswordDTO, error) {
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var forgotDto dto.ForgotPasswordDTO
+		if err := json.NewDecoder(r.Body).Decode(&forgotDto); err != nil {
+			return nil, err
+		}
+		return &forgotDto, nil
+	}
+
+	// Default: form-data
+	return &dto.ForgotPasswordDTO{Email: r.FormValue("email")}, nil
+}
+
+// parseResetPasswordData parsea el token y la nueva contraseña desde form-data o JSON
+func parseResetPasswordData(r *http.Request) (*dto.ResetPasswordDTO, error) {
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var resetDto dto.ResetPasswordDTO
+		if err := json.NewDecoder(r.Body).Decode(&resetDto); err != nil {
+			return nil, err
+		}
+		return &resetDto, nil
+	}
+
+	// Default: form-data
+	return &dto.ResetPasswordDTO{
+		Token:    r.FormValue("token"),
+		Password: r.FormValue("password"),
+	}, nil
+}
+
+// ForgotPasswordHandler responde siempre con el mismo mensaje para no revelar si el email está registrado
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	forgotDto, err := parseForgotPasswordData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(forgotDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.SolicitarRestablecimiento(forgotDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Si el email está registrado, recibirá las instrucciones para restablecer la contraseña", nil)
+}
+
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	resetDto, err := parseResetPasswordData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(resetDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.RestablecerContrasena(resetDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Contraseña restablecida correctamente", nil)
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/internal/domain"
+	"backend_reservation/internal/infrastructure/web/middleware"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// usuarioDominio construye la respuesta del perfil del usuario con la forma de domain.User (sin la contraseña)
+func usuarioDominio(user *models.User) domain.User {
+	return domain.User{
+		ID:        user.ID,
+		Name:      user.Name,
+		Phone:     user.Phone,
+		Email:     user.Email,
+		RoleID:    user.RoleID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// campoOpcional retorna el valor del campo del formulario o nil si no se envió
+func campoOpcional(r *http.Request, nombre string) *string {
+	valor := r.FormValue(nombre)
+	if _, ok := r.Form[nombre]; !ok {
+		return nil
+	}
+	return &valor
+}
+
+// parseUpdateProfileData parsea los datos del perfil desde form-data o JSON.
+// Los campos que no se envían no se modifican.
+func parseUpdateProfileData(r *http.Request) (*dto.UpdateProfileDTO, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var profileDto dto.UpdateProfileDTO
+		if err := json.NewDecoder(r.Body).Decode(&profileDto); err != nil {
+			return nil, err
+		}
+		return &profileDto, nil
+	}
+
+	// Default: form-data
+	return &dto.UpdateProfileDTO{
+		Name:  campoOpcional(r, "name"),
+		Phone: campoOpcional(r, "phone"),
+	}, nil
+}
+
+// parseChangeEmailData parsea el email nuevo y la contraseña desde form-data o JSON
+func parseChangeEmailData(r *http.Request) (*dto.ChangeEmailDTO, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var emailDto dto.ChangeEmailDTO
+		if err := json.NewDecoder(r.Body).Decode(&emailDto); err != nil {
+			return nil, err
+		}
+		return &emailDto, nil
+	}
+
+	// Default: form-data
+	return &dto.ChangeEmailDTO{
+		Email:    r.FormValue("email"),
+		Password: r.FormValue("password"),
+	}, nil
+}
+
+// parseChangePasswordData parsea la contraseña actual y la nueva desde form-data o JSON
+func parseChangePasswordData(r *http.Request) (*dto.ChangePasswordDTO, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var passwordDto dto.ChangePasswordDTO
+		if err := json.NewDecoder(r.Body).Decode(&passwordDto); err != nil {
+			return nil, err
+		}
+		return &passwordDto, nil
+	}
+
+	// Default: form-data
+	return &dto.ChangePasswordDTO{
+		CurrentPassword: r.FormValue("current_password"),
+		NewPassword:     r.FormValue("new_password"),
+	}, nil
+}
+
+// parseDeleteAccountData parsea la contraseña que confirma la eliminación de la cuenta desde form-data o JSON
+func parseDeleteAccountData(r *http.Request) (*dto.DeleteAccountDTO, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var deleteDto dto.DeleteAccountDTO
+		if err := json.NewDecoder(r.Body).Decode(&deleteDto); err != nil {
+			return nil, err
+		}
+		return &deleteDto, nil
+	}
+
+	// Default: form-data
+	return &dto.DeleteAccountDTO{Password: r.FormValue("password")}, nil
+}
+
+// ActualizarPerfilHandler actualiza el nombre y/o el teléfono del usuario autenticado
+func ActualizarPerfilHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	profileDto, err := parseUpdateProfileData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(profileDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	user, err, code := services.ActualizarPerfil(userID, profileDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Perfil actualizado correctamente", usuarioDominio(user))
+}
+
+// SolicitarCambioEmailHandler envía un código de confirmación al email nuevo del usuario autenticado
+func SolicitarCambioEmailHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	emailDto, err := parseChangeEmailData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(emailDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.SolicitarCambioEmail(userID, emailDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Se envió un código de confirmación al email nuevo", nil)
+}
+
+// ConfirmarCambioEmailHandler aplica el cambio de email con el código enviado al email nuevo
+func ConfirmarCambioEmailHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	verifyDto, err := parseVerifyData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(verifyDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	user, err, code := services.ConfirmarCambioEmail(userID, verifyDto.Code)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Email actualizado correctamente", usuarioDominio(user))
+}
+
+// CambiarContrasenaHandler cambia la contraseña del usuario autenticado y cierra sus otras sesiones
+func CambiarContrasenaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	passwordDto, err := parseChangePasswordData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(passwordDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	sesionActual, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	if err, code := services.CambiarContrasena(userID, sesionActual, passwordDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Contraseña actualizada correctamente", nil)
+}
+
+// EliminarCuentaHandler elimina la cuenta del usuario autenticado
+func EliminarCuentaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	deleteDto, err := parseDeleteAccountData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(deleteDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.EliminarCuenta(userID, deleteDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Cuenta eliminada correctamente", nil)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// permisoData construye la respuesta JSON de un permiso
+func permisoData(permiso *models.Permission) map[string]any {
+	return map[string]any{
+		"id":          permiso.ID,
+		"code":        permiso.Code,
+		"description": permiso.Description,
+	}
+}
+
+// rolData construye la respuesta JSON de un rol con sus permisos
+func rolData(rol *services.RolPermisos) map[string]any {
+	permisos := make([]string, len(rol.Permisos))
+	for i, permiso := range rol.Permisos {
+		permisos[i] = permiso.Code
+	}
+
+	return map[string]any{
+		"id":          rol.Rol.ID,
+		"code":        rol.Rol.Code,
+		"description": rol.Rol.Description,
+		"permissions": permisos,
+	}
+}
+
+// parseRoleData parsea los datos de un rol desde form-data o JSON.
+// En form-data, permissions puede enviarse separado por comas o repetido.
+func parseRoleData(r *http.Request) (*dto.Role, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var roleDto dto.Role
+		if err := json.NewDecoder(r.Body).Decode(&roleDto); err != nil {
+			return nil, err
+		}
+		return &roleDto, nil
+	}
+
+	// Default: form-data
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	var permisos []string
+	for _, valor := range r.Form["permissions"] {
+		for parte := range strings.SplitSeq(valor, ",") {
+			if parte = strings.TrimSpace(parte); parte != "" {
+				permisos = append(permisos, parte)
+			}
+		}
+	}
+
+	return &dto.Role{
+		Code:        r.FormValue("code"),
+		Description: r.FormValue("description"),
+		Permissions: permisos,
+	}, nil
+}
+
+func ObtenerPermisosHandler(w http.ResponseWriter, r *http.Request) {
+	permisos, err := services.ObtenerPermisos()
+	if err != nil {
+		handler.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	dataPermisos := make([]map[string]any, len(permisos))
+	for i := range permisos {
+		dataPermisos[i] = permisoData(&permisos[i])
+	}
+
+	handler.Success(w, r, "", dataPermisos)
+}
+
+func ObtenerRolesHandler(w http.ResponseWriter, r *http.Request) {
+	roles, err := services.ObtenerRoles()
+	if err != nil {
+		handler.Error(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	dataRoles := make([]map[string]any, len(roles))
+	for i := range roles {
+		dataRoles[i] = rolData(&roles[i])
+	}
+
+	handler.Success(w, r, "", dataRoles)
+}
+
+func ObtenerRolHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de rol no válido")
+		return
+	}
+
+	rol, err, code := services.ObtenerRol(id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", rolData(rol))
+}
+
+func CrearRolHandler(w http.ResponseWriter, r *http.Request) {
+	roleDto, err := parseRoleData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(roleDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	rol, err, code := services.CrearRol(roleDto)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Rol creado correctamente", rolData(rol))
+}
+
+func OtorgarPermisoHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de rol no válido")
+		return
+	}
+
+	rol, err, code := services.OtorgarPermiso(id, r.PathValue("permission"))
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Permiso otorgado correctamente", rolData(rol))
+}
+
+func RevocarPermisoHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de rol no válido")
+		return
+	}
+
+	rol, err, code := services.RevocarPermiso(adminID, id, r.PathValue("permission"))
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Permiso revocado correctamente", rolData(rol))
+}
@@ -4,6 +4,7 @@ import (
 	"backend_reservation/internal/application/dto"
 	"backend_reservation/internal/application/services"
 	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
 	"net/http"
 	"strconv"
 )
@@ -32,11 +33,14 @@ func ObtenerServiciosHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func CrearServicioHandler(w http.ResponseWriter, r *http.Request) {
-	estimatedTime, err := strconv.Atoi(r.FormValue("estimated_time"))
-
-	if err != nil {
-		handler.Error(w, r, http.StatusBadRequest, "Tiempo estimado no válido")
-		return
+	// Si no se envía el tiempo estimado, la validación informa que es obligatorio
+	estimatedTime := 0
+	if valor := r.FormValue("estimated_time"); valor != "" {
+		var err error
+		if estimatedTime, err = strconv.Atoi(valor); err != nil || estimatedTime < 0 {
+			handler.Error(w, r, http.StatusBadRequest, "Tiempo estimado no válido")
+			return
+		}
 	}
 
 	registerService := dto.Service{
@@ -45,6 +49,11 @@ func CrearServicioHandler(w http.ResponseWriter, r *http.Request) {
 		EstimatedTime: uint(estimatedTime),
 	}
 
+	if errores := validator.Validar(&registerService); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
 	register, err := services.CrearServicio(&registerService)
 
 	if err != nil {
@@ -147,6 +156,11 @@ func ActualizarServicioHandler(w http.ResponseWriter, r *http.Request) {
 		EstimatedTime: uint(parseEstimatedTime),
 	}
 
+	if errores := validator.ValidarParcial(&serviceDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
 	servicio, err := services.ActualizarServicio(uint(parseServiceId), &serviceDto)
 
 	if err != nil {
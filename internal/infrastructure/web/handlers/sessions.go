@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/services"
+	"backend_reservation/internal/infrastructure/web/middleware"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"net/http"
+)
+
+// dispositivoSolicitud obtiene el user agent y la IP del cliente para registrar la sesión
+func dispositivoSolicitud(r *http.Request) services.Dispositivo {
+	return services.Dispositivo{
+		UserAgent: r.UserAgent(),
+		IP:        middleware.ClientIP(r),
+	}
+}
+
+// sesionData construye la respuesta JSON de una sesión. actual indica si es la sesión de la solicitud.
+func sesionData(sesion *models.Session, actual bool) map[string]any {
+	return map[string]any{
+		"id":           sesion.ID,
+		"user_agent":   sesion.UserAgent,
+		"ip":           sesion.IP,
+		"created_at":   sesion.CreatedAt,
+		"last_seen_at": sesion.LastSeenAt,
+		"expires_at":   sesion.ExpiresAt,
+		"current":      actual,
+	}
+}
+
+// sesionesData construye la respuesta JSON de un listado de sesiones
+func sesionesData(r *http.Request, sesiones []models.Session) []map[string]any {
+	sesionActual, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	dataSesiones := make([]map[string]any, len(sesiones))
+	for i := range sesiones {
+		dataSesiones[i] = sesionData(&sesiones[i], sesiones[i].ID == sesionActual)
+	}
+	return dataSesiones
+}
+
+// ObtenerSesionesHandler lista las sesiones activas del usuario autenticado
+func ObtenerSesionesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	sesiones, err, code := services.ObtenerSesiones(userID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", sesionesData(r, sesiones))
+}
+
+// CerrarSesionRemotaHandler cierra una de las sesiones del usuario autenticado (por ejemplo, la de otro dispositivo)
+func CerrarSesionRemotaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de sesión no válido")
+		return
+	}
+
+	if err, code := services.CerrarSesionRemota(userID, id); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Sesión cerrada correctamente", nil)
+}
+
+// ObtenerSesionesUsuarioHandler lista las sesiones activas de un usuario para la administración
+func ObtenerSesionesUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	sesiones, err, code := services.ObtenerSesiones(id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", sesionesData(r, sesiones))
+}
+
+// CerrarSesionesUsuarioHandler cierra todas las sesiones de un usuario
+func CerrarSesionesUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	if err, code := services.CerrarSesionesUsuario(id); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Sesiones cerradas correctamente", nil)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// parseTwoFactorData parsea el código (y el token de desafío, en el login) desde form-data o JSON
+func parseTwoFactorData(r *http.Request) (*dto.TwoFactorDTO, error) {
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var twoFactorDto dto.TwoFactorDTO
+		if err := json.NewDecoder(r.Body).Decode(&twoFactorDto); err != nil {
+			return nil, err
+		}
+		return &twoFactorDto, nil
+	}
+
+	// Default: form-data
+	return &dto.TwoFactorDTO{
+		ChallengeToken: r.FormValue("challenge_token"),
+		Code:           r.FormValue("code"),
+	}, nil
+}
+
+func IniciarSegundoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	secreto, uri, err, code := services.IniciarSegundoFactor(userID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Escanee el código en su app de autenticación y confirme con un código", map[string]any{
+		"secret":      secreto,
+		"otpauth_uri": uri,
+	})
+}
+
+func ActivarSegundoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	twoFactorDto, err := parseTwoFactorData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(twoFactorDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	codigos, err, code := services.ActivarSegundoFactor(userID, twoFactorDto.Code)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Autenticación en dos pasos activada; guarde los códigos de recuperación", map[string]any{
+		"recovery_codes": codigos,
+	})
+}
+
+func DesactivarSegundoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	twoFactorDto, err := parseTwoFactorData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(twoFactorDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.DesactivarSegundoFactor(userID, twoFactorDto.Code); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Autenticación en dos pasos desactivada", nil)
+}
+
+func RegenerarCodigosRecuperacionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := usuarioAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	twoFactorDto, err := parseTwoFactorData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(twoFactorDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	codigos, err, code := services.RegenerarCodigosRecuperacion(userID, twoFactorDto.Code)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Códigos de recuperación regenerados", map[string]any{
+		"recovery_codes": codigos,
+	})
+}
@@ -1,11 +1,357 @@
 package handlers
 
 import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
 	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// usuarioData construye la respuesta JSON de un usuario para la administración (sin la contraseña)
+func usuarioData(usuario *models.User) map[string]any {
+	data := map[string]any{
+		"id":                usuario.ID,
+		"name":              usuario.Name,
+		"email":             usuario.Email,
+		"phone":             usuario.Phone,
+		"role_id":           usuario.RoleID,
+		"role":              usuario.Role.Code,
+		"email_verified":    usuario.EmailVerificado(),
+		"phone_verified":    usuario.TelefonoVerificado(),
+		"two_factor":        usuario.SegundoFactorActivo(),
+		"suspended":         usuario.Suspendido(),
+		"suspended_at":      usuario.SuspendedAt,
+		"suspension_reason": usuario.SuspensionReason,
+		"created_at":        usuario.CreatedAt,
+		"deleted":           usuario.DeletedAt.Valid,
+		"erased":            usuario.Borrado(),
+	}
+
+	if usuario.DeletedAt.Valid {
+		data["deleted_at"] = usuario.DeletedAt.Time
+	}
+
+	return data
+}
+
+// parseUserFilter parsea los filtros y la paginación del listado de usuarios
+// (q, name, email, phone, role, status, page y per_page)
+func parseUserFilter(r *http.Request) *dto.UserFilter {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+
+	return &dto.UserFilter{
+		Search:  query.Get("q"),
+		Name:    query.Get("name"),
+		Email:   query.Get("email"),
+		Phone:   query.Get("phone"),
+		Role:    query.Get("role"),
+		Status:  query.Get("status"),
+		Page:    page,
+		PerPage: perPage,
+	}
+}
+
+// parseChangeRoleData parsea el rol nuevo de un usuario desde form-data o JSON
+func parseChangeRoleData(r *http.Request) (*dto.ChangeRole, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var rolDto dto.ChangeRole
+		if err := json.NewDecoder(r.Body).Decode(&rolDto); err != nil {
+			return nil, err
+		}
+		return &rolDto, nil
+	}
+
+	// Default: form-data
+	roleID, err := uintFormulario(r, "role_id")
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ChangeRole{RoleID: roleID}, nil
+}
+
+// parseSuspensionData parsea el motivo de la suspensión desde form-data o JSON
+func parseSuspensionData(r *http.Request) (*dto.Suspension, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var suspensionDto dto.Suspension
+		if err := json.NewDecoder(r.Body).Decode(&suspensionDto); err != nil {
+			return nil, err
+		}
+		return &suspensionDto, nil
+	}
+
+	// Default: form-data
+	return &dto.Suspension{Reason: r.FormValue("reason")}, nil
+}
+
+// parseContactVerificationData parsea el canal a verificar desde form-data o JSON
+func parseContactVerificationData(r *http.Request) (*dto.ContactVerification, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.Contains(contentType, "application/json") {
+		var verificationDto dto.ContactVerification
+		if err := json.NewDecoder(r.Body).Decode(&verificationDto); err != nil {
+			return nil, err
+		}
+		return &verificationDto, nil
+	}
+
+	// Default: form-data
+	return &dto.ContactVerification{Channel: r.FormValue("channel")}, nil
+}
+
 func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
+	pagina, err, code := services.ObtenerUsuarios(parseUserFilter(r))
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	dataUsuarios := make([]map[string]any, len(pagina.Usuarios))
+	for i := range pagina.Usuarios {
+		dataUsuarios[i] = usuarioData(&pagina.Usuarios[i])
+	}
+
+	handler.Success(w, r, "Users", map[string]any{
+		"users":    dataUsuarios,
+		"total":    pagina.Total,
+		"page":     pagina.Page,
+		"per_page": pagina.PerPage,
+	})
+}
+
+func ObtenerUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	usuario, err, code := services.ObtenerUsuario(id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	citas := make([]map[string]any, len(usuario.Appointments))
+	for i := range usuario.Appointments {
+		citas[i] = citaData(&usuario.Appointments[i])
+	}
+
+	data := usuarioData(usuario)
+	data["appointments"] = citas
+
+	handler.Success(w, r, "", data)
+}
+
+func CambiarRolUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	rolDto, err := parseChangeRoleData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(rolDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	usuario, err, code := services.CambiarRolUsuario(adminID, id, rolDto.RoleID)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Rol actualizado correctamente", usuarioData(usuario))
+}
+
+func SuspenderUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	suspensionDto, err := parseSuspensionData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(suspensionDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	usuario, err, code := services.SuspenderUsuario(adminID, id, suspensionDto.Reason)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Cuenta suspendida correctamente", usuarioData(usuario))
+}
+
+func ReactivarUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	usuario, err, code := services.ReactivarUsuario(adminID, id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Cuenta reactivada correctamente", usuarioData(usuario))
+}
+
+func VerificarContactoUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	verificationDto, err := parseContactVerificationData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(verificationDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	usuario, err, code := services.VerificarContactoUsuario(adminID, id, verificationDto.Channel)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Dato verificado correctamente", usuarioData(usuario))
+}
+
+// DesbloquearUsuarioHandler levanta el bloqueo del login del usuario por intentos fallidos.
+// El parámetro opcional "ip" desbloquea además esa dirección IP.
+func DesbloquearUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	ip := r.FormValue("ip")
+	if ip != "" {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			handler.Error(w, r, http.StatusBadRequest, "IP no válida")
+			return
+		}
+		ip = parsed.String()
+	}
+
+	usuario, err, code := services.DesbloquearUsuario(adminID, id, ip)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Cuenta desbloqueada correctamente", usuarioData(usuario))
+}
+
+func EliminarUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	deleted, err, code := services.EliminarUsuario(adminID, id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "", map[string]any{
+		"eliminado": deleted,
+	})
+}
+
+func RestaurarUsuarioHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := actorAutenticado(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	id, err := idDeRuta(r, "id")
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "ID de usuario no válido")
+		return
+	}
+
+	usuario, err, code := services.RestaurarUsuario(adminID, id)
+	if err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
 
-	handler.Success(w, r, "Users", nil)
+	handler.Success(w, r, "Usuario restaurado correctamente", usuarioData(usuario))
 }
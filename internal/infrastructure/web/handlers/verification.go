@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"backend_reservation/internal/application/dto"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"backend_reservation/pkg/validator"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// parseVerifyData parsea el email o teléfono y el código de verificación desde form-data o JSON
+func parseVerifyData(r *http.Request) (*dto.VerifyDTO, error) {
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var verifyDto dto.VerifyDTO
+		if err := json.NewDecoder(r.Body).Decode(&verifyDto); err != nil {
+			return nil, err
+		}
+		return &verifyDto, nil
+	}
+
+	// Default: form-data
+	return &dto.VerifyDTO{
+		Email: r.FormValue("email"),
+		Phone: r.FormValue("phone"),
+		Code:  r.FormValue("code"),
+	}, nil
+}
+
+func VerificarEmailHandler(w http.ResponseWriter, r *http.Request) {
+	verifyDto, err := parseVerifyData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(verifyDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.VerificarEmail(verifyDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Email verificado correctamente", nil)
+}
+
+func VerificarTelefonoHandler(w http.ResponseWriter, r *http.Request) {
+	verifyDto, err := parseVerifyData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	if errores := validator.Validar(verifyDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.VerificarTelefono(verifyDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Teléfono verificado correctamente", nil)
+}
+
+// ReenviarVerificacionEmailHandler y ReenviarVerificacionTelefonoHandler responden siempre con el mismo mensaje
+// para no revelar si el dato está registrado.
+func ReenviarVerificacionEmailHandler(w http.ResponseWriter, r *http.Request) {
+	reenviarVerificacion(w, r, models.VerificacionEmail)
+}
+
+func ReenviarVerificacionTelefonoHandler(w http.ResponseWriter, r *http.Request) {
+	reenviarVerificacion(w, r, models.VerificacionTelefono)
+}
+
+func reenviarVerificacion(w http.ResponseWriter, r *http.Request, canal string) {
+	verifyDto, err := parseVerifyData(r)
+	if err != nil {
+		handler.Error(w, r, http.StatusBadRequest, "Invalid request data")
+		return
+	}
+
+	// Para reenviar no se envía el código
+	if errores := validator.ValidarParcial(verifyDto); errores != nil {
+		handler.ErrorValidacion(w, r, errores)
+		return
+	}
+
+	if err, code := services.ReenviarVerificacion(canal, verifyDto); err != nil {
+		handler.Error(w, r, code, err.Error())
+		return
+	}
+
+	handler.Success(w, r, "Si el dato está registrado y pendiente de verificación, recibirá un código nuevo", nil)
+}
@@ -1,26 +1,59 @@
 package middleware
 
 import (
+	"backend_reservation/internal/application/services"
 	"backend_reservation/pkg/handler"
+	"errors"
 	"net/http"
+	"strconv"
 )
 
+// AdminMiddleware protege las rutas de administración. Cada ruta exige además su propio permiso
+// con RequirePermission; aquí se exige la autenticación en dos pasos si REQUIRE_ADMIN_2FA está activo
+// y el rol del usuario tiene algún permiso de administración.
+// Las solicitudes autenticadas con una llave de API no tienen usuario ni segundo factor.
 func AdminMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetAPIKeyFromContext(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		userID, ok := GetUserIDFromContext(r.Context())
 		if !ok {
 			handler.Error(w, r, http.StatusUnauthorized, "Unauthorized, no tienes permisos")
 			return
 		}
-		permission, err := HasPermission(userID, "admin")
-		if err != nil {
-			handler.Error(w, r, http.StatusUnauthorized, "Unauthorized, no se pudo obtener el rol")
-			return
-		}
-		if !permission {
-			handler.Error(w, r, http.StatusForbidden, "Forbidden, no tienes permisos de administrador")
+		if err := verificarSegundoFactor(userID); err != nil {
+			handler.Error(w, r, http.StatusForbidden, err.Error())
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// verificarSegundoFactor retorna un error si el usuario debe tener activada la autenticación en dos pasos y no la tiene
+func verificarSegundoFactor(userID string) error {
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return errors.New("Unauthorized, no se pudo obtener el usuario")
+	}
+
+	exigido, err := services.SegundoFactorExigido(uint(id))
+	if err != nil {
+		return errors.New("Unauthorized, no se pudo obtener el usuario")
+	}
+	if !exigido {
+		return nil
+	}
+
+	activo, err := services.TieneSegundoFactor(uint(id))
+	if err != nil {
+		return errors.New("Unauthorized, no se pudo obtener el usuario")
+	}
+	if !activo {
+		return errors.New("Forbidden, debe activar la autenticación en dos pasos para usar las rutas de administrador")
+	}
+
+	return nil
+}
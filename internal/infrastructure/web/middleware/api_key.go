@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/database/models"
+	"backend_reservation/pkg/handler"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader es el header con el que las integraciones envían su llave de API
+const APIKeyHeader = "X-API-Key"
+
+// RutasLlaveAPI es el prefijo de las rutas protegidas con APIKeyMiddleware
+const RutasLlaveAPI = "/api/admin/"
+
+// APIKeyKey es la context key con la llave de API que autenticó la solicitud
+const APIKeyKey contextKey = "api_key"
+
+// maxFallosLlaveIP es el máximo de llaves no válidas por minuto que se aceptan desde una IP
+const maxFallosLlaveIP = 10
+
+// ventanaLlave cuenta las solicitudes de una llave (o los fallos de una IP) en el minuto en curso.
+// limite es el máximo de la ventana, para poder rechazar antes de buscar la llave en la base de datos.
+type ventanaLlave struct {
+	inicio      time.Time
+	solicitudes int
+	limite      int
+}
+
+// limitadorLlaves aplica el límite de solicitudes por minuto de cada llave de API, identificada por el
+// hash de la clave, y el de llaves no válidas por IP ("ip:...")
+type limitadorLlaves struct {
+	mu       sync.Mutex
+	ventanas map[string]*ventanaLlave
+}
+
+var limiteLlaves = &limitadorLlaves{ventanas: make(map[string]*ventanaLlave)}
+
+// agotada indica si la clave ya alcanzó el límite en el minuto en curso y el momento en que se reinicia el contador
+func (l *limitadorLlaves) agotada(clave string) (bool, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ventana, ok := l.ventanas[clave]
+	if !ok || time.Since(ventana.inicio) >= time.Minute {
+		return false, time.Time{}
+	}
+	return ventana.solicitudes >= ventana.limite, ventana.inicio.Add(time.Minute)
+}
+
+// permitir registra una solicitud de la clave y retorna si está dentro del límite indicado,
+// las solicitudes restantes y el momento en que se reinicia el contador
+func (l *limitadorLlaves) permitir(clave string, limite int) (bool, int, time.Time) {
+	ahora := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ventana, ok := l.ventanas[clave]
+	if !ok || ahora.Sub(ventana.inicio) >= time.Minute {
+		// Descartar las ventanas vencidas de otras claves para no acumular memoria
+		for otraClave, otra := range l.ventanas {
+			if ahora.Sub(otra.inicio) >= time.Minute {
+				delete(l.ventanas, otraClave)
+			}
+		}
+		ventana = &ventanaLlave{inicio: ahora}
+		l.ventanas[clave] = ventana
+	}
+	ventana.limite = limite
+
+	reinicio := ventana.inicio.Add(time.Minute)
+	if ventana.solicitudes >= limite {
+		return false, 0, reinicio
+	}
+
+	ventana.solicitudes++
+	return true, limite - ventana.solicitudes, reinicio
+}
+
+// usaLlaveAPI indica si la solicitud trae una llave de API para una ruta protegida con APIKeyMiddleware.
+// Esas solicitudes tienen su propio límite por llave y por IP para las llaves no válidas, por lo que
+// el límite general por IP no se les aplica (ver RateLimiter.Throttle).
+func usaLlaveAPI(r *http.Request) bool {
+	return r.Header.Get(APIKeyHeader) != "" && strings.HasPrefix(r.URL.Path, RutasLlaveAPI)
+}
+
+// huellaLlave identifica la llave en el limitador sin guardar la clave en memoria
+func huellaLlave(clave string) string {
+	suma := sha256.Sum256([]byte(clave))
+	return hex.EncodeToString(suma[:])
+}
+
+// rechazarPorLimite responde 429 indicando cuándo se puede volver a intentar
+func rechazarPorLimite(w http.ResponseWriter, r *http.Request, reinicio time.Time) {
+	w.Header().Set("Retry-After", fmt.Sprintf("%.0f", time.Until(reinicio).Seconds()))
+	handler.Error(w, r, http.StatusTooManyRequests, "Rate limit exceeded")
+}
+
+// APIKeyMiddleware autentica las solicitudes que traen el header X-API-Key con una llave de API vigente
+// y aplica su límite de solicitudes por minuto. Las solicitudes sin el header se autentican con PasetoMiddleware.
+// Los límites se verifican antes de buscar la llave: una llave que ya agotó su minuto y una IP que envió
+// demasiadas llaves no válidas se rechazan sin consultar la base de datos.
+// Las llaves no tienen un usuario asociado: los permisos se verifican con sus scopes (ver RequirePermission)
+// y las acciones que quedan en la auditoría se atribuyen al administrador que creó la llave.
+func APIKeyMiddleware(next http.Handler) http.Handler {
+	paseto := PasetoMiddleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clave := r.Header.Get(APIKeyHeader)
+		if clave == "" {
+			paseto.ServeHTTP(w, r)
+			return
+		}
+
+		ip := ClientIP(r)
+		huella := huellaLlave(clave)
+		for _, limitada := range []string{huella, "ip:" + ip} {
+			if agotada, reinicio := limiteLlaves.agotada(limitada); agotada {
+				rechazarPorLimite(w, r, reinicio)
+				return
+			}
+		}
+
+		llave, err := services.AutenticarLlaveAPI(clave, ip)
+		if err != nil {
+			if errors.Is(err, services.ErrLlaveAPIInvalida) {
+				limiteLlaves.permitir("ip:"+ip, maxFallosLlaveIP)
+				handler.Error(w, r, http.StatusUnauthorized, "Invalid API key")
+				return
+			}
+			handler.Error(w, r, http.StatusInternalServerError, "No se pudo verificar la llave de API")
+			return
+		}
+
+		permitido, restantes, reinicio := limiteLlaves.permitir(huella, llave.RateLimit)
+		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", llave.RateLimit))
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", restantes))
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", reinicio.Unix()))
+		if !permitido {
+			rechazarPorLimite(w, r, reinicio)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), APIKeyKey, llave)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetAPIKeyFromContext extrae la llave de API que autenticó la solicitud
+func GetAPIKeyFromContext(ctx context.Context) (*models.APIKey, bool) {
+	llave, ok := ctx.Value(APIKeyKey).(*models.APIKey)
+	return llave, ok && llave != nil
+}
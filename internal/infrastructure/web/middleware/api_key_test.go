@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"backend_reservation/pkg/database/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLimitadorLlaves(t *testing.T) {
+	limitador := &limitadorLlaves{ventanas: make(map[string]*ventanaLlave)}
+
+	for i := 1; i <= 3; i++ {
+		permitido, restantes, _ := limitador.permitir("llave", 3)
+		if !permitido || restantes != 3-i {
+			t.Fatalf("solicitud %d: permitido = %v, restantes = %d", i, permitido, restantes)
+		}
+	}
+
+	if agotada, _ := limitador.agotada("llave"); !agotada {
+		t.Error("la llave debería estar agotada tras 3 solicitudes")
+	}
+	if permitido, _, _ := limitador.permitir("llave", 3); permitido {
+		t.Error("se permitió una solicitud por encima del límite")
+	}
+
+	if agotada, _ := limitador.agotada("otra"); agotada {
+		t.Error("una llave sin solicitudes no debería estar agotada")
+	}
+}
+
+func TestAPIKeyMiddlewareAgotadaSinConsultar(t *testing.T) {
+	clave := "rk_agotada"
+	t.Cleanup(func() {
+		limiteLlaves.mu.Lock()
+		delete(limiteLlaves.ventanas, huellaLlave(clave))
+		limiteLlaves.mu.Unlock()
+	})
+	limiteLlaves.permitir(huellaLlave(clave), 1)
+
+	siguiente := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no debería llegar al handler")
+	})
+
+	// La ventana agotada se rechaza antes de buscar la llave en la base de datos
+	r := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+	r.Header.Set(APIKeyHeader, clave)
+	w := httptest.NewRecorder()
+	APIKeyMiddleware(siguiente).ServeHTTP(w, r)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("código = %d, se esperaba 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("falta el header Retry-After")
+	}
+}
+
+func TestRequirePermissionScopeLlave(t *testing.T) {
+	siguiente := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no debería llegar al handler")
+	})
+
+	llave := &models.APIKey{Scopes: []string{models.PermisoCitasLeer}, CreatedByID: 1}
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/appointments", nil)
+	r = r.WithContext(context.WithValue(r.Context(), APIKeyKey, llave))
+	w := httptest.NewRecorder()
+
+	// Sin el scope se rechaza sin consultar los permisos del creador
+	RequirePermission(models.PermisoCitasEscribir)(siguiente).ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("código = %d, se esperaba 403", w.Code)
+	}
+}
@@ -32,6 +32,7 @@ func DefaultCORSConfig() CORSConfig {
 			"Accept", "Origin",
 			"Cache-Control",
 			"X-File-Name",
+			APIKeyHeader,
 		},
 		AllowCredentials: true,
 		MaxAge:           84600, // 24 horas en segundos
@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"backend_reservation/internal/application/services"
 	"backend_reservation/pkg/firmador"
+	"backend_reservation/pkg/logger"
 	"backend_reservation/pkg/utils"
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -12,9 +16,12 @@ import (
 type contextKey string
 
 const (
-	UserIDKey contextKey = "user_id"
-	EmailKey  contextKey = "email"
-	NameKey   contextKey = "name"
+	UserIDKey  contextKey = "user_id"
+	EmailKey   contextKey = "email"
+	NameKey    contextKey = "name"
+	TokenIDKey contextKey = "jti"
+	RoleIDKey  contextKey = "role_id"
+	SessionKey contextKey = "sid"
 )
 
 func PasetoMiddleware(next http.Handler) http.Handler {
@@ -26,7 +33,7 @@ func PasetoMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Extraer el token del header Authorization (formato: "Bearer <token>")
-		tokenStr := extractToken(authHeader)
+		tokenStr := ExtractToken(authHeader)
 		if tokenStr == "" {
 			utils.Error(w, r, http.StatusUnauthorized, "Invalid token format")
 			return
@@ -46,21 +53,78 @@ func PasetoMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Extraer email y name como opcionales (sin fallar si no están presentes)
+		// Los tokens con propósito específico (por ejemplo el desafío del segundo factor) no son tokens de acceso
+		if purpose, _ := token.GetString("purpose"); purpose != "" {
+			utils.Error(w, r, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+
+		// El jti es obligatorio para poder revocar el token (logout o reutilización del refresh token)
+		jti, err := token.GetJti()
+		if err != nil || jti == "" {
+			utils.Error(w, r, http.StatusUnauthorized, "Invalid token data")
+			return
+		}
+
+		revocado, err := services.TokenRevocado(jti)
+		if err != nil {
+			utils.Error(w, r, http.StatusInternalServerError, "No se pudo verificar el token")
+			return
+		}
+		if revocado {
+			utils.Error(w, r, http.StatusUnauthorized, "Token revoked")
+			return
+		}
+
+		// Verificar que la cuenta siga activa (no eliminada ni suspendida)
+		parsedUserID, err := strconv.ParseUint(userID, 10, 64)
+		if err != nil {
+			utils.Error(w, r, http.StatusUnauthorized, "Invalid token data")
+			return
+		}
+		if err := services.VerificarCuentaActiva(uint(parsedUserID)); err != nil {
+			if errors.Is(err, services.ErrCuentaSuspendida) {
+				utils.Error(w, r, http.StatusForbidden, "Cuenta suspendida")
+				return
+			}
+			utils.Error(w, r, http.StatusUnauthorized, "Invalid token")
+			return
+		}
+
+		// Extraer email y name como opcionales (los tokens v4.public no los incluyen)
 		email, _ := token.GetString("email")
 		name, _ := token.GetString("name")
 
+		// El rol del token solo se usa si no cambió después de emitirlo; si no, los permisos se buscan por usuario
+		roleID, _ := token.GetString("role_id")
+		if emitido, err := token.GetIssuedAt(); err != nil || services.RolCambiadoDesde(uint(parsedUserID), emitido) {
+			roleID = ""
+		}
+
 		// Crear un contexto con los datos del usuario usando las keys personalizadas
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
 		ctx = context.WithValue(ctx, EmailKey, email)
 		ctx = context.WithValue(ctx, NameKey, name)
+		ctx = context.WithValue(ctx, TokenIDKey, jti)
+		ctx = context.WithValue(ctx, RoleIDKey, roleID)
+
+		// Registrar la actividad de la sesión (los tokens emitidos antes del registro de sesiones no tienen sid)
+		if sid, _ := token.GetString("sid"); sid != "" {
+			if sesionID, err := strconv.ParseUint(sid, 10, 64); err == nil {
+				if err := services.RegistrarActividadSesion(uint(sesionID)); err != nil {
+					logger.LoggerFromCtx(r.Context()).Error("no se pudo registrar la actividad de la sesión", "sid", sesionID, "error", err)
+				}
+				ctx = context.WithValue(ctx, SessionKey, uint(sesionID))
+			}
+		}
 		// Continuar con el siguiente handler con el contexto actualizado
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// extractToken extrae el token del header Authorization
-func extractToken(authHeader string) string {
+// ExtractToken extrae el token del header Authorization. Lo usan también los handlers que leen
+// el token sin pasar por PasetoMiddleware, como el logout.
+func ExtractToken(authHeader string) string {
 	// Verificar si tiene el prefijo "Bearer "
 	if strings.HasPrefix(authHeader, "Bearer ") {
 		return strings.TrimPrefix(authHeader, "Bearer ")
@@ -94,11 +158,30 @@ func GetNameFromContext(ctx context.Context) (string, bool) {
 	return name, ok && name != ""
 }
 
+// GetTokenIDFromContext extrae el jti del token de acceso del contexto
+func GetTokenIDFromContext(ctx context.Context) (string, bool) {
+	jti, ok := ctx.Value(TokenIDKey).(string)
+	return jti, ok && jti != ""
+}
+
+// GetSessionIDFromContext extrae el ID de la sesión del token de acceso del contexto
+func GetSessionIDFromContext(ctx context.Context) (uint, bool) {
+	sesionID, ok := ctx.Value(SessionKey).(uint)
+	return sesionID, ok && sesionID != 0
+}
+
+// GetRoleIDFromContext extrae el ID del rol del token del contexto
+func GetRoleIDFromContext(ctx context.Context) (string, bool) {
+	roleID, ok := ctx.Value(RoleIDKey).(string)
+	return roleID, ok && roleID != ""
+}
+
 // GetUserDataFromContext extrae todos los datos del usuario del contexto
 func GetUserDataFromContext(ctx context.Context) (userID, email, name, roleID string, ok bool) {
 	userID, okID := GetUserIDFromContext(ctx)
 	email, _ = GetEmailFromContext(ctx)
 	name, _ = GetNameFromContext(ctx)
+	roleID, _ = GetRoleIDFromContext(ctx)
 	// Solo retorna ok=true si user_id está presente (email y name son opcionales)
 	return userID, email, name, roleID, okID
 }
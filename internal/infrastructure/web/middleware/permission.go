@@ -1,35 +1,78 @@
 package middleware
 
 import (
-	"backend_reservation/pkg/database/connection"
-	"backend_reservation/pkg/database/models"
+	"backend_reservation/internal/application/services"
+	"backend_reservation/pkg/handler"
+	"net/http"
 	"strconv"
 )
 
+// HasPermission indica si el rol del usuario tiene otorgado el permiso con el código indicado.
+// El rol y sus permisos se obtienen de la caché de la capa de servicios.
 func HasPermission(userID string, code string) (bool, error) {
-	_, gormDB, err := connection.GetDB()
+	// Convertir userID a int
+	userIDInt, err := strconv.ParseUint(userID, 10, 64)
 	if err != nil {
 		return false, err
 	}
 
-	// Convertir userID a int
-	userIDInt, err := strconv.Atoi(userID)
-	if err != nil {
-		return false, err
+	return services.TienePermiso(uint(userIDInt), code)
+}
+
+// RequirePermission retorna un middleware que solo deja pasar a los usuarios cuyo rol
+// tiene otorgado el permiso indicado, por ejemplo RequirePermission("appointments:write").
+// Debe usarse después de PasetoMiddleware o APIKeyMiddleware, que agregan el usuario o la llave al contexto.
+func RequirePermission(code string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Las llaves de API solo tienen los permisos de sus scopes que su creador conserva
+			if llave, ok := GetAPIKeyFromContext(r.Context()); ok {
+				if !llave.TieneScope(code) {
+					handler.Error(w, r, http.StatusForbidden, "Forbidden, la llave de API no tiene el permiso "+code)
+					return
+				}
+				if permitido, err := services.TienePermiso(llave.CreatedByID, code); err != nil || !permitido {
+					handler.Error(w, r, http.StatusForbidden, "Forbidden, el creador de la llave de API no tiene el permiso "+code)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok {
+				handler.Error(w, r, http.StatusUnauthorized, "Unauthorized, no tienes permisos")
+				return
+			}
+
+			permission, err := permisoSolicitud(r, userID, code)
+			if err != nil {
+				handler.Error(w, r, http.StatusUnauthorized, "Unauthorized, no se pudo obtener el rol")
+				return
+			}
+
+			if !permission {
+				handler.Error(w, r, http.StatusForbidden, "Forbidden, no tienes el permiso "+code)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
 	}
+}
 
-	// Buscar el rol de administrador
-	var role models.Role
-	if err := gormDB.Where("code = ?", code).First(&role).Error; err != nil {
-		return false, err
+// permisoSolicitud verifica el permiso con el rol del token si está disponible,
+// evitando buscar el rol del usuario; si no, lo verifica con HasPermission.
+func permisoSolicitud(r *http.Request, userID string, code string) (bool, error) {
+	roleID, ok := GetRoleIDFromContext(r.Context())
+	if !ok {
+		return HasPermission(userID, code)
 	}
 
-	// Buscar el usuario
-	var user models.User
-	if err := gormDB.Where("id = ?", userIDInt).First(&user).Error; err != nil {
-		return false, err
+	roleIDInt, err := strconv.ParseUint(roleID, 10, 64)
+	if err != nil {
+		return HasPermission(userID, code)
 	}
 
-	// Verificar si el usuario tiene el rol correspondiente
-	return role.ID == user.RoleID, nil
+	return services.RolTienePermiso(uint(roleIDInt), code)
 }
@@ -114,6 +114,8 @@ func NewRateLimiter(limit int, resetTime time.Duration, maxVisitors ...int) *Rat
 // responde con un error HTTP 429 (Too Many Requests) y no permite que la solicitud avance.
 // Si no se ha alcanzado el límite, incrementa el contador de solicitudes para esa IP y
 // permite que la solicitud continúe al siguiente handler.
+// Las solicitudes con llave de API a las rutas de admin no se cuentan: APIKeyMiddleware aplica el límite
+// de cada llave y escribe sus propios headers X-RateLimit-*.
 //
 // Parámetros:
 //   - next: http.Handler que representa el siguiente handler en la cadena de middlewares.
@@ -122,8 +124,13 @@ func NewRateLimiter(limit int, resetTime time.Duration, maxVisitors ...int) *Rat
 //   - http.Handler: un handler que aplica la lógica de limitación de tasa antes de invocar al siguiente handler.
 func (rl *RateLimiter) Throttle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if usaLlaveAPI(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// Obtener la IP del cliente usando la función auxiliar, que maneja headers de proxy y conexión directa
-		ip := getClientIP(r)
+		ip := ClientIP(r)
 
 		// Bloqueamos el acceso concurrente al mapa de visitantes para evitar condiciones de carrera
 		rl.mu.Lock()
@@ -158,39 +165,103 @@ func (rl *RateLimiter) Throttle(next http.Handler) http.Handler {
 	})
 }
 
-// getClientIP extrae la IP del cliente de forma más confiable
-func getClientIP(r *http.Request) string {
-	// Primero intentamos obtener la IP del cliente desde el header "X-Forwarded-For" (usado por proxies y balanceadores)
+// proxiesConfiables son las redes de los proxies y balanceadores cuyos headers X-Forwarded-For y X-Real-IP se aceptan
+var proxiesConfiables []*net.IPNet
+
+// InitProxiesConfiables configura los proxies confiables a partir de IPs o rangos CIDR
+// (por ejemplo "10.0.0.0/8" o "127.0.0.1"). Sin proxies configurados, ClientIP usa siempre la IP de la conexión.
+func InitProxiesConfiables(direcciones []string) error {
+	var redes []*net.IPNet
+	for _, direccion := range direcciones {
+		direccion = strings.TrimSpace(direccion)
+		if direccion == "" {
+			continue
+		}
+
+		if !strings.Contains(direccion, "/") {
+			ip := net.ParseIP(direccion)
+			if ip == nil {
+				return fmt.Errorf("proxy no válido: %q", direccion)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			redes = append(redes, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, red, err := net.ParseCIDR(direccion)
+		if err != nil {
+			return fmt.Errorf("proxy no válido: %q", direccion)
+		}
+		redes = append(redes, red)
+	}
+
+	proxiesConfiables = redes
+	return nil
+}
+
+// esProxyConfiable indica si la IP pertenece a alguno de los proxies confiables
+func esProxyConfiable(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, red := range proxiesConfiables {
+		if red.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP extrae la IP del cliente de forma más confiable.
+// Se usa también fuera del rate limiter, por ejemplo para registrar los intentos fallidos de login por IP.
+//
+// Los headers de proxy solo se consideran si la conexión viene de un proxy confiable (ver InitProxiesConfiables);
+// si no, cualquier cliente podría elegir su IP enviando el header. En X-Forwarded-For cada proxy agrega al final
+// la IP de quien le envió la solicitud, por eso se recorre de derecha a izquierda y se toma la primera IP
+// que no es de un proxy confiable: las anteriores las pudo escribir el cliente.
+func ClientIP(r *http.Request) string {
+	// IP de la conexión directa (RemoteAddr), sin el puerto
+	remota, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remota = r.RemoteAddr
+	}
+
+	if !esProxyConfiable(remota) {
+		return remota
+	}
+
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// El header puede contener varias IPs separadas por comas, tomamos la primera (la del cliente original)
-		if ips := parseXForwardedFor(xff); len(ips) > 0 {
+		ips := parseXForwardedFor(xff)
+		for i := len(ips) - 1; i >= 0; i-- {
+			if !esProxyConfiable(ips[i]) {
+				return ips[i]
+			}
+		}
+		// Si todas las IPs son de proxies confiables, la primera es la más cercana al cliente
+		if len(ips) > 0 {
 			return ips[0]
 		}
 	}
 
 	// Si no existe "X-Forwarded-For", intentamos con "X-Real-IP" (otro header común de proxies)
 	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		// Validamos que sea una IP válida
 		if ip := net.ParseIP(xri); ip != nil {
 			return ip.String()
 		}
 	}
 
-	// Si no hay headers de proxy, usamos la IP de la conexión directa (RemoteAddr)
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		// Si ocurre un error al separar el host y el puerto, devolvemos el valor original
-		return r.RemoteAddr
-	}
-	// Devolvemos solo la IP (sin el puerto)
-	return ip
+	return remota
 }
 
 // parseXForwardedFor maneja correctamente el header X-Forwarded-For que puede tener múltiples IPs
 // parseXForwardedFor toma el valor del header "X-Forwarded-For" (que puede contener una lista de IPs separadas por comas)
 // y devuelve un slice con todas las IPs válidas encontradas.
 // Este header es comúnmente utilizado por proxies y balanceadores de carga para indicar la cadena de IPs
-// por las que ha pasado la petición, donde la primera IP suele ser la del cliente original.
+// por las que ha pasado la petición, donde la primera IP es la que declara el cliente (y puede ser falsa).
 //
 // Parámetros:
 //   - xff: string que representa el valor del header "X-Forwarded-For", por ejemplo: "203.0.113.1, 70.41.3.18, 150.172.238.178"
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		proxies  []string
+		remota   string
+		xff      string
+		realIP   string
+		esperada string
+	}{
+		{nombre: "sin proxies ignora los headers", remota: "203.0.113.7:5000", xff: "198.51.100.1", realIP: "198.51.100.2", esperada: "203.0.113.7"},
+		{nombre: "conexión que no es de un proxy", proxies: []string{"10.0.0.0/8"}, remota: "203.0.113.7:5000", xff: "198.51.100.1", esperada: "203.0.113.7"},
+		{nombre: "proxy confiable", proxies: []string{"10.0.0.0/8"}, remota: "10.0.0.2:5000", xff: "198.51.100.1", esperada: "198.51.100.1"},
+		{nombre: "IP falsa agregada por el cliente", proxies: []string{"10.0.0.0/8"}, remota: "10.0.0.2:5000", xff: "1.2.3.4, 198.51.100.1", esperada: "198.51.100.1"},
+		{nombre: "varios proxies confiables", proxies: []string{"10.0.0.0/8", "192.0.2.10"}, remota: "10.0.0.2:5000", xff: "1.2.3.4, 198.51.100.1, 192.0.2.10", esperada: "198.51.100.1"},
+		{nombre: "solo proxies en la cadena", proxies: []string{"10.0.0.0/8"}, remota: "10.0.0.2:5000", xff: "10.0.0.5, 10.0.0.3", esperada: "10.0.0.5"},
+		{nombre: "X-Real-IP de un proxy confiable", proxies: []string{"10.0.0.2"}, remota: "10.0.0.2:5000", realIP: "198.51.100.9", esperada: "198.51.100.9"},
+		{nombre: "proxy sin headers", proxies: []string{"10.0.0.2"}, remota: "10.0.0.2:5000", esperada: "10.0.0.2"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			if err := InitProxiesConfiables(caso.proxies); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { proxiesConfiables = nil })
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = caso.remota
+			if caso.xff != "" {
+				r.Header.Set("X-Forwarded-For", caso.xff)
+			}
+			if caso.realIP != "" {
+				r.Header.Set("X-Real-IP", caso.realIP)
+			}
+
+			if ip := ClientIP(r); ip != caso.esperada {
+				t.Errorf("ClientIP = %q, se esperaba %q", ip, caso.esperada)
+			}
+		})
+	}
+}
+
+func TestInitProxiesConfiablesNoValido(t *testing.T) {
+	t.Cleanup(func() { proxiesConfiables = nil })
+
+	for _, direccion := range []string{"10.0.0.0/33", "proxy.local"} {
+		if err := InitProxiesConfiables([]string{direccion}); err == nil {
+			t.Errorf("InitProxiesConfiables(%q) debería fallar", direccion)
+		}
+	}
+}
+
+func TestThrottleExceptuaLlavesAPI(t *testing.T) {
+	rl := NewRateLimiter(1, time.Minute)
+	defer rl.Stop()
+
+	siguiente := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	solicitud := func(ruta, llave string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, ruta, nil)
+		r.RemoteAddr = "203.0.113.7:5000"
+		if llave != "" {
+			r.Header.Set(APIKeyHeader, llave)
+		}
+		w := httptest.NewRecorder()
+		rl.Throttle(siguiente).ServeHTTP(w, r)
+		return w
+	}
+
+	// Las llaves de API tienen su propio límite: no consumen ni escriben el límite por IP
+	for range 3 {
+		w := solicitud("/api/admin/appointments", "rk_prueba")
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("una solicitud con llave de API fue limitada por IP: %d", w.Code)
+		}
+	}
+
+	if w := solicitud("/api/login", "rk_prueba"); w.Code != http.StatusOK {
+		t.Fatalf("primera solicitud fuera de admin: %d", w.Code)
+	}
+	// Fuera de las rutas de admin el header no evita el límite por IP
+	if w := solicitud("/api/login", "rk_prueba"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("código = %d, se esperaba 429", w.Code)
+	}
+}
@@ -2,21 +2,88 @@ package routes
 
 import (
 	"backend_reservation/internal/infrastructure/web/handlers"
+	"backend_reservation/pkg/database/models"
 	"net/http"
 )
 
 func AdminRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /users", handlers.GetUsersHandler)
+	//Rutas para usuarios
+	mux.Handle("GET /users", conPermiso(models.PermisoUsuariosLeer, handlers.GetUsersHandler))
+	mux.Handle("GET /users/{id}", conPermiso(models.PermisoUsuariosLeer, handlers.ObtenerUsuarioHandler))
+	mux.Handle("DELETE /users/{id}", conPermiso(models.PermisoUsuariosEscribir, handlers.EliminarUsuarioHandler))
+	mux.Handle("PUT /users/{id}/role", conPermiso(models.PermisoRolesEscribir, handlers.CambiarRolUsuarioHandler))
+	mux.Handle("PUT /users/{id}/suspend", conPermiso(models.PermisoUsuariosEscribir, handlers.SuspenderUsuarioHandler))
+	mux.Handle("PUT /users/{id}/reactivate", conPermiso(models.PermisoUsuariosEscribir, handlers.ReactivarUsuarioHandler))
+	mux.Handle("PUT /users/{id}/verify", conPermiso(models.PermisoUsuariosEscribir, handlers.VerificarContactoUsuarioHandler))
+	mux.Handle("PUT /users/{id}/unlock", conPermiso(models.PermisoUsuariosEscribir, handlers.DesbloquearUsuarioHandler))
+	mux.Handle("PUT /users/{id}/restore", conPermiso(models.PermisoUsuariosEscribir, handlers.RestaurarUsuarioHandler))
+	mux.Handle("GET /users/{id}/sessions", conPermiso(models.PermisoUsuariosLeer, handlers.ObtenerSesionesUsuarioHandler))
+	mux.Handle("DELETE /users/{id}/sessions", conPermiso(models.PermisoUsuariosEscribir, handlers.CerrarSesionesUsuarioHandler))
+	mux.Handle("GET /users/{id}/export", conPermiso(models.PermisoUsuariosLeer, handlers.ExportarDatosUsuarioHandler))
+	mux.Handle("POST /users/{id}/erase", conPermiso(models.PermisoUsuariosEscribir, handlers.BorrarDatosUsuarioHandler))
 
 	//Rutas para servicios
-	mux.HandleFunc("GET /service", handlers.ObtenerServiciosHandler)
-	mux.HandleFunc("POST /service", handlers.CrearServicioHandler)
-	mux.HandleFunc("GET /service/{id}", handlers.ObtenerServicioHandler)
-	mux.HandleFunc("PATCH /service/{id}", handlers.ActualizarServicioHandler)
-	mux.HandleFunc("DELETE /service/{id}", handlers.EliminarServicioHandler)
-	mux.HandleFunc("PUT /service/{id}/activate", handlers.ActivarDesactivarServicioHandler)
+	mux.Handle("GET /service", conPermiso(models.PermisoServiciosLeer, handlers.ObtenerServiciosHandler))
+	mux.Handle("POST /service", conPermiso(models.PermisoServiciosEscribir, handlers.CrearServicioHandler))
+	mux.Handle("GET /service/{id}", conPermiso(models.PermisoServiciosLeer, handlers.ObtenerServicioHandler))
+	mux.Handle("PATCH /service/{id}", conPermiso(models.PermisoServiciosEscribir, handlers.ActualizarServicioHandler))
+	mux.Handle("DELETE /service/{id}", conPermiso(models.PermisoServiciosEscribir, handlers.EliminarServicioHandler))
+	mux.Handle("PUT /service/{id}/activate", conPermiso(models.PermisoServiciosEscribir, handlers.ActivarDesactivarServicioHandler))
+
+	//Rutas para días de atención
+	mux.Handle("GET /day", conPermiso(models.PermisoDiasLeer, handlers.ObtenerDiasHandler))
+	mux.Handle("POST /day", conPermiso(models.PermisoDiasEscribir, handlers.CrearDiaHandler))
+	mux.Handle("GET /day/{id}", conPermiso(models.PermisoDiasLeer, handlers.ObtenerDiaHandler))
+	mux.Handle("PATCH /day/{id}", conPermiso(models.PermisoDiasEscribir, handlers.ActualizarDiaHandler))
+	mux.Handle("DELETE /day/{id}", conPermiso(models.PermisoDiasEscribir, handlers.EliminarDiaHandler))
+	mux.Handle("PUT /day/{id}/activate", conPermiso(models.PermisoDiasEscribir, handlers.ActivarDesactivarDiaHandler))
+
+	//Rutas para empleados
+	mux.Handle("GET /employee", conPermiso(models.PermisoEmpleadosLeer, handlers.ObtenerEmpleadosHandler))
+	mux.Handle("POST /employee", conPermiso(models.PermisoEmpleadosEscribir, handlers.CrearEmpleadoHandler))
+	mux.Handle("GET /employee/{id}", conPermiso(models.PermisoEmpleadosLeer, handlers.ObtenerEmpleadoHandler))
+	mux.Handle("PATCH /employee/{id}", conPermiso(models.PermisoEmpleadosEscribir, handlers.ActualizarEmpleadoHandler))
+	mux.Handle("DELETE /employee/{id}", conPermiso(models.PermisoEmpleadosEscribir, handlers.EliminarEmpleadoHandler))
+	mux.Handle("PUT /employee/{id}/activate", conPermiso(models.PermisoEmpleadosEscribir, handlers.ActivarDesactivarEmpleadoHandler))
+	mux.Handle("GET /employee/{id}/services", conPermiso(models.PermisoEmpleadosLeer, handlers.ObtenerServiciosEmpleadoHandler))
+	mux.Handle("PUT /employee/{id}/services/{service_id}", conPermiso(models.PermisoEmpleadosEscribir, handlers.AsignarServicioEmpleadoHandler))
+	mux.Handle("DELETE /employee/{id}/services/{service_id}", conPermiso(models.PermisoEmpleadosEscribir, handlers.QuitarServicioEmpleadoHandler))
+	mux.Handle("GET /employee/{id}/schedule", conPermiso(models.PermisoEmpleadosLeer, handlers.ObtenerHorarioEmpleadoHandler))
+	mux.Handle("PUT /employee/{id}/schedule", conPermiso(models.PermisoEmpleadosEscribir, handlers.ActualizarHorarioEmpleadoHandler))
+	mux.Handle("GET /employee/{id}/overrides", conPermiso(models.PermisoEmpleadosLeer, handlers.ObtenerExcepcionesEmpleadoHandler))
+	mux.Handle("POST /employee/{id}/overrides", conPermiso(models.PermisoEmpleadosEscribir, handlers.GuardarExcepcionEmpleadoHandler))
+	mux.Handle("DELETE /employee/{id}/overrides/{date}", conPermiso(models.PermisoEmpleadosEscribir, handlers.EliminarExcepcionEmpleadoHandler))
+
+	//Rutas para cierres (feriados, vacaciones y permisos)
+	mux.Handle("GET /closures", conPermiso(models.PermisoCierresLeer, handlers.ObtenerCierresHandler))
+	mux.Handle("POST /closures", conPermiso(models.PermisoCierresEscribir, handlers.CrearCierreHandler))
+	mux.Handle("POST /closures/import", conPermiso(models.PermisoCierresEscribir, handlers.ImportarCierresHandler))
+	mux.Handle("GET /closures/{id}", conPermiso(models.PermisoCierresLeer, handlers.ObtenerCierreHandler))
+	mux.Handle("PATCH /closures/{id}", conPermiso(models.PermisoCierresEscribir, handlers.ActualizarCierreHandler))
+	mux.Handle("DELETE /closures/{id}", conPermiso(models.PermisoCierresEscribir, handlers.EliminarCierreHandler))
+
+	//Rutas para citas
+	mux.Handle("GET /appointments", conPermiso(models.PermisoCitasLeer, handlers.ObtenerCitasHandler))
+	mux.Handle("POST /appointments", conPermiso(models.PermisoCitasEscribir, handlers.CrearCitaAdminHandler))
+	mux.Handle("PUT /appointments/{id}/employee", conPermiso(models.PermisoCitasEscribir, handlers.ReasignarCitaHandler))
+	mux.Handle("PUT /appointments/{id}/complete", conPermiso(models.PermisoCitasEscribir, handlers.CompletarCitaHandler))
+	mux.Handle("PUT /appointments/{id}/no-show", conPermiso(models.PermisoCitasEscribir, handlers.MarcarNoAsistioHandler))
+
+	//Rutas para roles y permisos
+	mux.Handle("GET /roles", conPermiso(models.PermisoRolesLeer, handlers.ObtenerRolesHandler))
+	mux.Handle("POST /roles", conPermiso(models.PermisoRolesEscribir, handlers.CrearRolHandler))
+	mux.Handle("GET /roles/{id}", conPermiso(models.PermisoRolesLeer, handlers.ObtenerRolHandler))
+	mux.Handle("PUT /roles/{id}/permissions/{permission}", conPermiso(models.PermisoRolesEscribir, handlers.OtorgarPermisoHandler))
+	mux.Handle("DELETE /roles/{id}/permissions/{permission}", conPermiso(models.PermisoRolesEscribir, handlers.RevocarPermisoHandler))
+	mux.Handle("GET /permissions", conPermiso(models.PermisoRolesLeer, handlers.ObtenerPermisosHandler))
+
+	//Rutas para llaves de API
+	mux.Handle("GET /api-keys", conPermiso(models.PermisoLlavesAPILeer, handlers.ObtenerLlavesAPIHandler))
+	mux.Handle("POST /api-keys", conPermiso(models.PermisoLlavesAPIEscribir, handlers.CrearLlaveAPIHandler))
+	mux.Handle("GET /api-keys/{id}", conPermiso(models.PermisoLlavesAPILeer, handlers.ObtenerLlaveAPIHandler))
+	mux.Handle("DELETE /api-keys/{id}", conPermiso(models.PermisoLlavesAPIEscribir, handlers.RevocarLlaveAPIHandler))
 
 	return mux
 }
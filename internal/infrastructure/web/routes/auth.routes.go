@@ -10,6 +10,18 @@ func AuthRoutes() *http.ServeMux {
 
 	// Usar la sintaxis correcta para Go 1.22+ sin prefijo
 	mux.HandleFunc("POST /api/login", handlers.LoginHandler)
+	mux.HandleFunc("POST /api/login/2fa", handlers.Login2FAHandler)
 	mux.HandleFunc("POST /api/register", handlers.RegisterHandler)
+	mux.HandleFunc("POST /api/refresh", handlers.RefreshHandler)
+	mux.HandleFunc("POST /api/logout", handlers.LogoutHandler)
+	mux.HandleFunc("POST /api/password/forgot", handlers.ForgotPasswordHandler)
+	mux.HandleFunc("POST /api/password/reset", handlers.ResetPasswordHandler)
+	mux.HandleFunc("POST /api/verify/email", handlers.VerificarEmailHandler)
+	mux.HandleFunc("POST /api/verify/phone", handlers.VerificarTelefonoHandler)
+	mux.HandleFunc("POST /api/verify/email/resend", handlers.ReenviarVerificacionEmailHandler)
+	mux.HandleFunc("POST /api/verify/phone/resend", handlers.ReenviarVerificacionTelefonoHandler)
+
+	// Claves públicas para verificar los tokens v4.public
+	mux.HandleFunc("GET /.well-known/paseto-keys", handlers.LlavesPublicasHandler)
 	return mux
 }
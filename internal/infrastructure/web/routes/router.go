@@ -17,8 +17,14 @@ func MainRouter() *http.ServeMux {
 
 	mux.Handle("/api/user/", http.StripPrefix("/api/user", middleware.PasetoMiddleware(userRoutes)))
 
-	// Usar StripPrefix para remover "/api/admin" antes de pasar al handler de admin
-	mux.Handle("/api/admin/", http.StripPrefix("/api/admin", middleware.PasetoMiddleware(middleware.AdminMiddleware(adminRoutes))))
+	// Usar StripPrefix para remover "/api/admin" antes de pasar al handler de admin.
+	// Las rutas de admin aceptan también llaves de API (header X-API-Key) para las integraciones.
+	mux.Handle(middleware.RutasLlaveAPI, http.StripPrefix("/api/admin", middleware.APIKeyMiddleware(middleware.AdminMiddleware(adminRoutes))))
 
 	return mux
 }
+
+// conPermiso envuelve el handler con el middleware que exige el permiso indicado
+func conPermiso(code string, h http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(code)(h)
+}
@@ -2,11 +2,39 @@ package routes
 
 import (
 	"backend_reservation/internal/infrastructure/web/handlers"
+	"backend_reservation/pkg/database/models"
 	"net/http"
 )
 
 func UserRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /", handlers.GetUserDataHandler)
+
+	//Rutas para el perfil
+	mux.HandleFunc("PATCH /profile", handlers.ActualizarPerfilHandler)
+	mux.HandleFunc("DELETE /profile", handlers.EliminarCuentaHandler)
+	mux.HandleFunc("POST /profile/email", handlers.SolicitarCambioEmailHandler)
+	mux.HandleFunc("POST /profile/email/confirm", handlers.ConfirmarCambioEmailHandler)
+	mux.HandleFunc("PUT /profile/password", handlers.CambiarContrasenaHandler)
+
+	//Rutas para la exportación y el borrado de los datos personales
+	mux.HandleFunc("GET /export", handlers.ExportarDatosHandler)
+	mux.HandleFunc("POST /erase", handlers.BorrarMisDatosHandler)
+
+	//Rutas para citas
+	mux.Handle("POST /appointments", conPermiso(models.PermisoCitasReservar, handlers.CrearCitaHandler))
+	mux.Handle("DELETE /appointments/{id}", conPermiso(models.PermisoCitasReservar, handlers.CancelarCitaHandler))
+	mux.Handle("PATCH /appointments/{id}/reschedule", conPermiso(models.PermisoCitasReservar, handlers.ReprogramarCitaHandler))
+	mux.Handle("GET /availability", conPermiso(models.PermisoCitasReservar, handlers.ObtenerDisponibilidadHandler))
+
+	//Rutas para las sesiones activas
+	mux.HandleFunc("GET /sessions", handlers.ObtenerSesionesHandler)
+	mux.HandleFunc("DELETE /sessions/{id}", handlers.CerrarSesionRemotaHandler)
+
+	//Rutas para la autenticación en dos pasos
+	mux.HandleFunc("POST /2fa/setup", handlers.IniciarSegundoFactorHandler)
+	mux.HandleFunc("POST /2fa/enable", handlers.ActivarSegundoFactorHandler)
+	mux.HandleFunc("POST /2fa/disable", handlers.DesactivarSegundoFactorHandler)
+	mux.HandleFunc("POST /2fa/recovery-codes", handlers.RegenerarCodigosRecuperacionHandler)
 	return mux
 }
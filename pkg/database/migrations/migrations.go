@@ -4,8 +4,10 @@ import (
 	"backend_reservation/pkg/database/models"
 	"fmt"
 	"log"
+	"slices"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func RunMigrations(db *gorm.DB) error {
@@ -19,13 +21,171 @@ func RunMigrations(db *gorm.DB) error {
 		&models.Day{},
 		&models.Appointment{},
 		&models.AppointmentService{},
+		&models.AppointmentHistory{},
+		&models.EmployeeService{},
+		&models.EmployeeSchedule{},
+		&models.EmployeeScheduleOverride{},
+		&models.Closure{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.Session{},
+		&models.PasswordReset{},
+		&models.VerificationCode{},
+		&models.RecoveryCode{},
+		&models.Permission{},
+		&models.RolePermission{},
+		&models.APIKey{},
+		&models.AuditLog{},
 	}
 
+	// Se comprueba antes de migrar: la tabla nueva se completa una única vez, al crearse
+	habilidadesNuevas := !db.Migrator().HasTable(&models.EmployeeService{})
+	verificacionNueva := db.Migrator().HasTable(&models.User{}) && !db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+
 	err := db.AutoMigrate(modelsToMigrate...)
 	if err != nil {
 		return fmt.Errorf("error al ejecutar las migraciones: %v", err)
 	}
 
+	if habilidadesNuevas {
+		if err := habilitarServiciosEmpleados(db); err != nil {
+			return fmt.Errorf("error al habilitar los servicios de los empleados: %v", err)
+		}
+	}
+
+	if verificacionNueva {
+		if err := verificarUsuariosExistentes(db); err != nil {
+			return fmt.Errorf("error al verificar los usuarios existentes: %v", err)
+		}
+	}
+
+	if err := crearRestriccionesCitas(db); err != nil {
+		return fmt.Errorf("error al crear las restricciones de citas: %v", err)
+	}
+
+	if err := sembrarRolesPermisos(db); err != nil {
+		return fmt.Errorf("error al crear los roles y permisos: %v", err)
+	}
+
 	log.Println("Migraciones ejecutadas correctamente")
 	return nil
 }
+
+// habilitarServiciosEmpleados habilita a cada empleado existente para todos los servicios existentes,
+// con su tiempo estimado general. Se ejecuta al crear la tabla employee_services para que, en una base
+// anterior a las habilidades por empleado, todos los empleados sigan ofreciendo todos los servicios.
+func habilitarServiciosEmpleados(db *gorm.DB) error {
+	return db.Exec(`INSERT INTO employee_services (employee_id, service_id, created_at, updated_at)
+		SELECT employees.id, services.id, now(), now()
+		FROM employees CROSS JOIN services
+		WHERE employees.deleted_at IS NULL AND services.deleted_at IS NULL
+		ON CONFLICT DO NOTHING`).Error
+}
+
+// verificarUsuariosExistentes marca como verificados el email y el teléfono de los usuarios registrados
+// antes de que existiera la verificación de contactos, para que puedan seguir reservando sin pedir un código.
+// Se toma como fecha de verificación la de registro. Se ejecuta una única vez, al agregar las columnas.
+func verificarUsuariosExistentes(db *gorm.DB) error {
+	return db.Exec(`UPDATE users SET
+		email_verified_at = COALESCE(email_verified_at, created_at),
+		phone_verified_at = COALESCE(phone_verified_at, created_at)`).Error
+}
+
+// crearRestriccionesCitas crea la restricción de exclusión que impide que un empleado tenga
+// dos citas que se crucen en el tiempo. Las citas eliminadas (soft delete) y las canceladas no se consideran.
+// La restricción se recrea en cada ejecución para mantener su definición actualizada.
+func crearRestriccionesCitas(db *gorm.DB) error {
+	sentencias := []string{
+		// btree_gist permite combinar la igualdad de employee_id con el solapamiento de rangos en un índice GiST
+		`CREATE EXTENSION IF NOT EXISTS btree_gist`,
+		fmt.Sprintf(`ALTER TABLE appointments DROP CONSTRAINT IF EXISTS %s`, models.RestriccionSolapamientoCitas),
+		fmt.Sprintf(`ALTER TABLE appointments ADD CONSTRAINT %s
+			EXCLUDE USING gist (employee_id WITH =, tstzrange(start_at, end_at, '[)') WITH &&)
+			WHERE (deleted_at IS NULL AND status <> '%s')`, models.RestriccionSolapamientoCitas, models.CitaCancelada),
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, sentencia := range sentencias {
+			if err := tx.Exec(sentencia).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sembrarRolesPermisos crea los permisos y los roles predeterminados que falten y les otorga sus permisos iniciales.
+// Los permisos iniciales se otorgan en cada ejecución (sin duplicar los existentes), de modo que los roles que ya
+// existían antes de introducir los permisos, como "user" y "admin", también los reciban. El rol admin recibe
+// todos los permisos existentes.
+func sembrarRolesPermisos(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permisos := append([]models.Permission(nil), models.PermisosPredeterminados...)
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description", "updated_at"}),
+		}).Create(&permisos).Error
+		if err != nil {
+			return err
+		}
+
+		var todos []models.Permission
+		if err := tx.Find(&todos).Error; err != nil {
+			return err
+		}
+		permisoIDs := make(map[string]uint, len(todos))
+		for _, permiso := range todos {
+			permisoIDs[permiso.Code] = permiso.ID
+		}
+
+		rolIDs := make(map[string]uint, len(models.RolesPredeterminados))
+		for _, predeterminado := range models.RolesPredeterminados {
+			role := models.Role{Code: predeterminado.Code, Description: predeterminado.Description}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error; err != nil {
+				return err
+			}
+			// Si el rol ya existía, Create no asigna el ID
+			if err := tx.Where("code = ?", predeterminado.Code).First(&role).Error; err != nil {
+				return err
+			}
+			rolIDs[role.Code] = role.ID
+		}
+
+		otorgados := otorgamientosPredeterminados(models.RolesPredeterminados, rolIDs, permisoIDs)
+		if len(otorgados) == 0 {
+			return nil
+		}
+		return tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&otorgados).Error
+	})
+}
+
+// otorgamientosPredeterminados arma los permisos iniciales de cada rol predeterminado a partir de los IDs
+// de los roles y permisos guardados. El rol admin recibe todos los permisos; los códigos sin ID se ignoran.
+func otorgamientosPredeterminados(roles []models.RolPredeterminado, rolIDs, permisoIDs map[string]uint) []models.RolePermission {
+	var otorgados []models.RolePermission
+	for _, predeterminado := range roles {
+		roleID, ok := rolIDs[predeterminado.Code]
+		if !ok {
+			continue
+		}
+
+		var ids []uint
+		if predeterminado.Code == models.RolAdmin {
+			for _, id := range permisoIDs {
+				ids = append(ids, id)
+			}
+			slices.Sort(ids)
+		} else {
+			for _, codigo := range predeterminado.Permisos {
+				if id, ok := permisoIDs[codigo]; ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+
+		for _, id := range ids {
+			otorgados = append(otorgados, models.RolePermission{RoleID: roleID, PermissionID: id})
+		}
+	}
+	return otorgados
+}
@@ -0,0 +1,122 @@
+package migrations
+
+import (
+	"backend_reservation/pkg/database/models"
+	"os"
+	"slices"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestOtorgamientosPredeterminados(t *testing.T) {
+	permisoIDs := map[string]uint{
+		models.PermisoCitasReservar: 1,
+		models.PermisoCitasLeer:     2,
+		models.PermisoUsuariosLeer:  3,
+	}
+	roles := []models.RolPredeterminado{
+		{Code: models.RolAdmin},
+		{Code: models.RolUsuario, Permisos: []string{models.PermisoCitasReservar}},
+		{Code: models.RolPersonal, Permisos: []string{models.PermisoCitasLeer, "inexistente"}},
+	}
+
+	casos := []struct {
+		nombre   string
+		rolIDs   map[string]uint
+		esperado []models.RolePermission
+	}{
+		{
+			nombre: "todos los roles",
+			rolIDs: map[string]uint{models.RolAdmin: 10, models.RolUsuario: 20, models.RolPersonal: 30},
+			esperado: []models.RolePermission{
+				{RoleID: 10, PermissionID: 1}, {RoleID: 10, PermissionID: 2}, {RoleID: 10, PermissionID: 3},
+				{RoleID: 20, PermissionID: 1},
+				{RoleID: 30, PermissionID: 2},
+			},
+		},
+		{
+			nombre:   "rol sin ID",
+			rolIDs:   map[string]uint{models.RolUsuario: 20},
+			esperado: []models.RolePermission{{RoleID: 20, PermissionID: 1}},
+		},
+		{
+			nombre: "sin roles",
+			rolIDs: map[string]uint{},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			obtenido := otorgamientosPredeterminados(roles, caso.rolIDs, permisoIDs)
+			if !slices.Equal(obtenido, caso.esperado) {
+				t.Errorf("otorgamientos = %v, se esperaba %v", obtenido, caso.esperado)
+			}
+		})
+	}
+}
+
+// TestSembrarRolesPermisosIdempotente ejecuta la siembra sobre una base que ya tiene los roles "user" y
+// "admin" sin permisos (como una instalación anterior al control de permisos) y luego una segunda vez.
+// Requiere TEST_DATABASE_URL con una base PostgreSQL; los cambios se deshacen al terminar.
+func TestSembrarRolesPermisosIdempotente(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no está definida")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("no se pudo conectar: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Role{}, &models.Permission{}, &models.RolePermission{}); err != nil {
+		t.Fatalf("no se pudieron migrar las tablas: %v", err)
+	}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	if err := tx.Exec("DELETE FROM role_permissions").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, codigo := range []string{models.RolAdmin, models.RolUsuario} {
+		if err := tx.Exec("INSERT INTO roles (code, description, created_at, updated_at) VALUES (?, ?, now(), now()) ON CONFLICT DO NOTHING", codigo, codigo).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	contar := func() int64 {
+		var total int64
+		if err := tx.Model(&models.RolePermission{}).Count(&total).Error; err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+
+	if err := sembrarRolesPermisos(tx); err != nil {
+		t.Fatalf("primera siembra: %v", err)
+	}
+	primera := contar()
+
+	if err := sembrarRolesPermisos(tx); err != nil {
+		t.Fatalf("segunda siembra: %v", err)
+	}
+	if segunda := contar(); segunda != primera {
+		t.Errorf("la segunda siembra cambió los permisos otorgados: %d, antes %d", segunda, primera)
+	}
+
+	var reservar int64
+	err = tx.Table("role_permissions").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("roles.code = ? AND permissions.code = ?", models.RolUsuario, models.PermisoCitasReservar).
+		Count(&reservar).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reservar != 1 {
+		t.Errorf("el rol %q existente no recibió %s", models.RolUsuario, models.PermisoCitasReservar)
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// APIKey es una llave de acceso para integraciones (kioscos, reportes) que no usan el login de un usuario.
+// Solo se guarda el hash SHA-256 de la llave; Prefix son sus primeros caracteres, para reconocerla en los listados.
+// Scopes son los códigos de los permisos que otorga y RateLimit el máximo de solicitudes por minuto.
+type APIKey struct {
+	gorm.Model
+	Name        string         `gorm:"size:100;not null"`
+	Prefix      string         `gorm:"size:16;not null"`
+	KeyHash     string         `gorm:"size:64;not null;uniqueIndex"`
+	Scopes      pq.StringArray `gorm:"type:text[];not null"`
+	RateLimit   int            `gorm:"not null"`
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	LastUsedIP  string `gorm:"size:64"`
+	RevokedAt   *time.Time
+	CreatedByID uint
+	CreatedBy   User `gorm:"foreignKey:CreatedByID"`
+}
+
+// Vigente indica si la llave no fue revocada ni expiró
+func (k APIKey) Vigente() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
+
+// TieneScope indica si la llave otorga el permiso indicado
+func (k APIKey) TieneScope(codigo string) bool {
+	return slices.Contains(k.Scopes, codigo)
+}
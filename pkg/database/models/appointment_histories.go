@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AppointmentHistory guarda el horario anterior de una cita cada vez que se modifica
+type AppointmentHistory struct {
+	gorm.Model
+	AppointmentID      uint
+	Appointment        Appointment `gorm:"foreignKey:AppointmentID"`
+	PreviousStartAt    time.Time   `gorm:"not null"`
+	PreviousEndAt      time.Time   `gorm:"not null"`
+	PreviousEmployeeID uint
+	StartAt            time.Time `gorm:"not null"`
+	EndAt              time.Time `gorm:"not null"`
+	EmployeeID         uint
+	ChangedByID        uint
+	ChangedBy          User `gorm:"foreignKey:ChangedByID"`
+}
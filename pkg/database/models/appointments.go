@@ -6,15 +6,52 @@ import (
 	"gorm.io/gorm"
 )
 
+// RestriccionSolapamientoCitas es el nombre de la restricción de exclusión que impide
+// que un empleado tenga dos citas que se crucen en el tiempo.
+const RestriccionSolapamientoCitas = "appointments_employee_no_overlap"
+
+// Estados posibles de una cita
+const (
+	CitaReservada    = "booked"
+	CitaCancelada    = "cancelled"
+	CitaReprogramada = "rescheduled"
+	CitaCompletada   = "completed"
+	CitaNoAsistio    = "no_show"
+)
+
 type Appointment struct {
 	gorm.Model
 	StartAt             time.Time `gorm:"not null"`
 	EndAt               time.Time `gorm:"not null"`
+	Status              string    `gorm:"size:20;not null;default:booked;index"`
+	CancelledAt         *time.Time
 	DayID               uint
 	Day                 Day `gorm:"foreignKey:DayID"`
-	UserID              uint
-	User                User `gorm:"foreignKey:UserID"`
+	UserID              *uint
+	User                User   `gorm:"foreignKey:UserID"`
+	CustomerName        string `gorm:"size:255"`
+	CustomerPhone       string `gorm:"size:50"`
 	EmployeeID          uint
 	Employee            Employee             `gorm:"foreignKey:EmployeeID"`
 	AppointmentServices []AppointmentService `gorm:"foreignKey:AppointmentID"`
+	History             []AppointmentHistory `gorm:"foreignKey:AppointmentID"`
+}
+
+// EstadoCitaValido indica si el estado corresponde a uno de los estados de cita conocidos
+func EstadoCitaValido(estado string) bool {
+	switch estado {
+	case CitaReservada, CitaCancelada, CitaReprogramada, CitaCompletada, CitaNoAsistio:
+		return true
+	}
+	return false
+}
+
+// Modificable indica si la cita todavía puede cancelarse o reprogramarse
+func (a Appointment) Modificable() bool {
+	return a.Status == CitaReservada || a.Status == CitaReprogramada
+}
+
+// CitaVigente filtra las citas que ocupan el horario del empleado (todas excepto las canceladas)
+func CitaVigente(db *gorm.DB) *gorm.DB {
+	return db.Where("status <> ?", CitaCancelada)
 }
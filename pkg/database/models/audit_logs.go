@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Acciones registradas en la auditoría de las cuentas
+const (
+	AuditoriaExportacion      = "data_export"
+	AuditoriaBorrado          = "data_erasure"
+	AuditoriaContrasena       = "password_change"
+	AuditoriaRestablecimiento = "password_reset"
+	AuditoriaEmail            = "email_change"
+	AuditoriaEliminacion      = "account_deletion"
+	AuditoriaRol              = "role_change"
+	AuditoriaSuspension       = "suspension"
+	AuditoriaReactivacion     = "reactivation"
+	AuditoriaRestauracion     = "account_restore"
+	AuditoriaDesbloqueo       = "login_unlock"
+	AuditoriaVerificacion     = "contact_verification"
+)
+
+// AuditLog registra una acción sobre la cuenta de un usuario. ActorID es quien la realizó
+// (el propio usuario o un administrador); es nil si la realizó el sistema.
+// Detail no debe contener datos personales, ya que la auditoría se conserva tras el borrado de la cuenta.
+type AuditLog struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	ActorID   *uint  `gorm:"index"`
+	Action    string `gorm:"size:50;not null"`
+	Detail    string `gorm:"size:255"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de cierre
+const (
+	CierreFeriado    = "holiday"  // Feriado o día festivo
+	CierreVacaciones = "vacation" // Vacaciones de un empleado
+	CierrePermiso    = "time_off" // Permiso o ausencia puntual de un empleado
+	CierreLocal      = "closure"  // Cierre del local por otro motivo
+)
+
+// Orígenes de un cierre
+const (
+	OrigenManual = "manual"
+	OrigenICS    = "ics"
+)
+
+// Closure es un rango [StartAt, EndAt) en el que no se atiende.
+// Si EmployeeID es nil el cierre aplica a todo el local; si no, solo al empleado indicado.
+// Los cierres importados desde un archivo iCalendar guardan el UID del evento para no duplicarlos.
+type Closure struct {
+	gorm.Model
+	EmployeeID  *uint     `gorm:"index"`
+	Employee    *Employee `gorm:"foreignKey:EmployeeID"`
+	Kind        string    `gorm:"size:20;not null;default:closure"`
+	Description string    `gorm:"size:255"`
+	StartAt     time.Time `gorm:"not null;index"`
+	EndAt       time.Time `gorm:"not null;index"`
+	Source      string    `gorm:"size:10;not null;default:manual"`
+	UID         string    `gorm:"size:255;index"`
+}
+
+// TipoCierreValido indica si kind es uno de los tipos de cierre conocidos
+func TipoCierreValido(kind string) bool {
+	switch kind {
+	case CierreFeriado, CierreVacaciones, CierrePermiso, CierreLocal:
+		return true
+	}
+	return false
+}
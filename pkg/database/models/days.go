@@ -15,3 +15,46 @@ type Day struct {
 	Status       bool          `gorm:"default:true"`
 	Appointments []Appointment `gorm:"foreignKey:DayID"`
 }
+
+// codigosDias asocia cada día de la semana con el código registrado en la tabla days
+var codigosDias = map[time.Weekday]string{
+	time.Sunday:    "domingo",
+	time.Monday:    "lunes",
+	time.Tuesday:   "martes",
+	time.Wednesday: "miercoles",
+	time.Thursday:  "jueves",
+	time.Friday:    "viernes",
+	time.Saturday:  "sabado",
+}
+
+// CodigoDia devuelve el código usado en la tabla days para el día de la semana de la fecha.
+// El día de la semana se toma en la zona horaria del local (time.Local), no en la de la fecha,
+// para que una fecha en UTC cercana a la medianoche no caiga en el día anterior o siguiente.
+func CodigoDia(fecha time.Time) string {
+	return codigosDias[fecha.In(time.Local).Weekday()]
+}
+
+// CodigoDiaValido indica si code corresponde a alguno de los días de la semana
+func CodigoDiaValido(code string) bool {
+	for _, codigo := range codigosDias {
+		if codigo == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Ventana aplica el horario del día (solo hora y minutos de StartAt/EndAt) a la fecha indicada.
+// La fecha y el horario se interpretan en la zona horaria del local (time.Local), sin importar
+// la zona en que lleguen (por ejemplo UTC desde la base de datos).
+func (d Day) Ventana(fecha time.Time) (time.Time, time.Time) {
+	anio, mes, dia := fecha.In(time.Local).Date()
+	apertura, cierre := d.StartAt.In(time.Local), d.EndAt.In(time.Local)
+	inicio := time.Date(anio, mes, dia, apertura.Hour(), apertura.Minute(), 0, 0, time.Local)
+	fin := time.Date(anio, mes, dia, cierre.Hour(), cierre.Minute(), 0, 0, time.Local)
+	return inicio, fin
+}
+
+func DiaActivo(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", true)
+}
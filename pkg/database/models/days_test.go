@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// usarZonaLocal reemplaza time.Local durante la prueba
+func usarZonaLocal(t *testing.T, zona *time.Location) {
+	anterior := time.Local
+	time.Local = zona
+	t.Cleanup(func() { time.Local = anterior })
+}
+
+func TestCodigoDiaZonaLocal(t *testing.T) {
+	usarZonaLocal(t, time.FixedZone("UTC-3", -3*60*60))
+
+	// 02:00 UTC del martes es todavía lunes a las 23:00 en UTC-3
+	fecha := time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC)
+	if codigo := CodigoDia(fecha); codigo != "lunes" {
+		t.Errorf("CodigoDia = %s, se esperaba lunes", codigo)
+	}
+}
+
+func TestVentanaZonaLocal(t *testing.T) {
+	local := time.FixedZone("UTC-3", -3*60*60)
+	usarZonaLocal(t, local)
+
+	// El horario se guarda en hora local y puede leerse en UTC desde la base de datos
+	dia := Day{
+		StartAt: time.Date(2000, 1, 1, 9, 0, 0, 0, local).UTC(),
+		EndAt:   time.Date(2000, 1, 1, 18, 30, 0, 0, local).UTC(),
+	}
+
+	inicio, fin := dia.Ventana(time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC))
+
+	if esperado := time.Date(2026, 3, 2, 9, 0, 0, 0, local); !inicio.Equal(esperado) {
+		t.Errorf("inicio = %v, se esperaba %v", inicio, esperado)
+	}
+	if esperado := time.Date(2026, 3, 2, 18, 30, 0, 0, local); !fin.Equal(esperado) {
+		t.Errorf("fin = %v, se esperaba %v", fin, esperado)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de bloque de un horario de empleado
+const (
+	BloqueTurno    = "shift" // Intervalo en el que el empleado atiende
+	BloqueDescanso = "break" // Intervalo que se descuenta de los turnos (por ejemplo el almuerzo)
+	BloqueLibre    = "off"   // Solo en excepciones: el empleado no trabaja esa fecha
+)
+
+// EmployeeSchedule es un bloque del horario semanal recurrente de un empleado.
+// Un empleado puede tener varios turnos y descansos en el mismo día de la semana.
+// StartTime y EndTime usan el formato HH:MM.
+type EmployeeSchedule struct {
+	gorm.Model
+	EmployeeID uint         `gorm:"not null;index"`
+	Employee   Employee     `gorm:"foreignKey:EmployeeID"`
+	Weekday    time.Weekday `gorm:"not null"`
+	Kind       string       `gorm:"size:10;not null;default:shift"`
+	StartTime  string       `gorm:"size:5;not null"`
+	EndTime    string       `gorm:"size:5;not null"`
+}
+
+// EmployeeScheduleOverride reemplaza el horario semanal de un empleado en una fecha concreta.
+// Si existe al menos una excepción para la fecha, solo se consideran las excepciones de ese día.
+type EmployeeScheduleOverride struct {
+	gorm.Model
+	EmployeeID uint      `gorm:"not null;index:idx_employee_override_date"`
+	Employee   Employee  `gorm:"foreignKey:EmployeeID"`
+	Date       time.Time `gorm:"type:date;not null;index:idx_employee_override_date"`
+	Kind       string    `gorm:"size:10;not null;default:shift"`
+	StartTime  string    `gorm:"size:5"`
+	EndTime    string    `gorm:"size:5"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// EmployeeService indica que un empleado está habilitado para realizar un servicio.
+// EstimatedTime permite sobrescribir, para ese empleado, el tiempo estimado (en minutos) del servicio.
+type EmployeeService struct {
+	gorm.Model
+	EmployeeID    uint     `gorm:"not null;uniqueIndex:idx_employee_service"`
+	Employee      Employee `gorm:"foreignKey:EmployeeID"`
+	ServiceID     uint     `gorm:"not null;uniqueIndex:idx_employee_service"`
+	Service       Service  `gorm:"foreignKey:ServiceID"`
+	EstimatedTime *uint
+}
+
+// Duracion devuelve el tiempo estimado (en minutos) del servicio para este empleado
+func (es EmployeeService) Duracion(servicio Service) uint {
+	if es.EstimatedTime != nil {
+		return *es.EstimatedTime
+	}
+	return servicio.EstimatedTime
+}
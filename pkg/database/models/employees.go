@@ -8,9 +8,10 @@ type Employee struct {
 	gorm.Model
 	Name         string `gorm:"unique;not null"`
 	RoleID       uint
-	Role         Role          `gorm:"foreignKey:RoleID"`
-	Status       bool          `gorm:"default:true"`
-	Appointments []Appointment `gorm:"foreignKey:EmployeeID"`
+	Role         Role              `gorm:"foreignKey:RoleID"`
+	Status       bool              `gorm:"default:true"`
+	Appointments []Appointment     `gorm:"foreignKey:EmployeeID"`
+	Services     []EmployeeService `gorm:"foreignKey:EmployeeID"`
 }
 
 func EmpleadoActivo(db *gorm.DB) *gorm.DB {
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordReset es una solicitud de restablecimiento de contraseña.
+// Solo se guarda el hash SHA-256 del token enviado al usuario; el token es de un solo uso.
+type PasswordReset struct {
+	gorm.Model
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"foreignKey:UserID"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+}
+
+// Vigente indica si el token no fue usado ni expiró
+func (p PasswordReset) Vigente() bool {
+	return p.UsedAt == nil && time.Now().Before(p.ExpiresAt)
+}
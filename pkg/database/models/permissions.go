@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Códigos de los permisos, con el formato "recurso:acción"
+const (
+	PermisoUsuariosLeer      = "users:read"
+	PermisoUsuariosEscribir  = "users:write"
+	PermisoRolesLeer         = "roles:read"
+	PermisoRolesEscribir     = "roles:write"
+	PermisoServiciosLeer     = "services:read"
+	PermisoServiciosEscribir = "services:write"
+	PermisoDiasLeer          = "days:read"
+	PermisoDiasEscribir      = "days:write"
+	PermisoEmpleadosLeer     = "employees:read"
+	PermisoEmpleadosEscribir = "employees:write"
+	PermisoCierresLeer       = "closures:read"
+	PermisoCierresEscribir   = "closures:write"
+	PermisoCitasLeer         = "appointments:read"
+	PermisoCitasEscribir     = "appointments:write"
+	PermisoCitasReservar     = "appointments:book"
+	PermisoLlavesAPILeer     = "api_keys:read"
+	PermisoLlavesAPIEscribir = "api_keys:write"
+)
+
+// Permission es un derecho puntual sobre un recurso que se otorga a los roles
+type Permission struct {
+	gorm.Model
+	Code        string `gorm:"unique;not null"`
+	Description string `gorm:"size:255;not null"`
+}
+
+// RolePermission otorga un permiso a un rol
+type RolePermission struct {
+	RoleID       uint       `gorm:"primaryKey"`
+	Role         Role       `gorm:"foreignKey:RoleID"`
+	PermissionID uint       `gorm:"primaryKey"`
+	Permission   Permission `gorm:"foreignKey:PermissionID"`
+	CreatedAt    time.Time
+}
+
+// PermisosPredeterminados es el catálogo de permisos que se crea en las migraciones
+var PermisosPredeterminados = []Permission{
+	{Code: PermisoUsuariosLeer, Description: "Consultar usuarios"},
+	{Code: PermisoUsuariosEscribir, Description: "Suspender, eliminar, restaurar y desbloquear usuarios"},
+	{Code: PermisoRolesLeer, Description: "Consultar roles y permisos"},
+	{Code: PermisoRolesEscribir, Description: "Crear roles, otorgar permisos y cambiar el rol de los usuarios"},
+	{Code: PermisoServiciosLeer, Description: "Consultar servicios"},
+	{Code: PermisoServiciosEscribir, Description: "Administrar servicios"},
+	{Code: PermisoDiasLeer, Description: "Consultar días de atención"},
+	{Code: PermisoDiasEscribir, Description: "Administrar días de atención"},
+	{Code: PermisoEmpleadosLeer, Description: "Consultar empleados y sus horarios"},
+	{Code: PermisoEmpleadosEscribir, Description: "Administrar empleados, sus servicios y horarios"},
+	{Code: PermisoCierresLeer, Description: "Consultar cierres"},
+	{Code: PermisoCierresEscribir, Description: "Administrar cierres"},
+	{Code: PermisoCitasLeer, Description: "Consultar todas las citas"},
+	{Code: PermisoCitasEscribir, Description: "Administrar las citas de cualquier usuario"},
+	{Code: PermisoCitasReservar, Description: "Reservar y gestionar citas propias"},
+	{Code: PermisoLlavesAPILeer, Description: "Consultar las llaves de API"},
+	{Code: PermisoLlavesAPIEscribir, Description: "Crear y revocar llaves de API"},
+}
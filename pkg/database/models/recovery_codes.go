@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode es un código de recuperación de un solo uso que reemplaza al código TOTP
+// cuando el usuario no tiene acceso a su app de autenticación. Solo se guarda su hash.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index"`
+	User     User   `gorm:"foreignKey:UserID"`
+	CodeHash string `gorm:"size:64;not null"`
+	UsedAt   *time.Time
+}
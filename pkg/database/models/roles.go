@@ -4,8 +4,39 @@ import (
 	"gorm.io/gorm"
 )
 
+// Códigos de los roles predeterminados que se crean en las migraciones
+const (
+	RolAdmin    = "admin"
+	RolUsuario  = "user"
+	RolPersonal = "staff"
+)
+
 type Role struct {
 	gorm.Model
 	Code        string `gorm:"unique;not null"`
 	Description string `gorm:"size:255;not null"`
 }
+
+// RolPredeterminado describe un rol que se crea en las migraciones junto con sus permisos iniciales
+type RolPredeterminado struct {
+	Code        string
+	Description string
+	Permisos    []string
+}
+
+// RolesPredeterminados son los roles que se crean si no existen.
+// El rol admin no lista permisos porque siempre recibe todos los permisos existentes.
+var RolesPredeterminados = []RolPredeterminado{
+	{Code: RolAdmin, Description: "Administrador"},
+	{Code: RolUsuario, Description: "Cliente", Permisos: []string{PermisoCitasReservar}},
+	{Code: RolPersonal, Description: "Personal del local", Permisos: []string{
+		PermisoServiciosLeer,
+		PermisoDiasLeer,
+		PermisoEmpleadosLeer,
+		PermisoCierresLeer,
+		PermisoCierresEscribir,
+		PermisoCitasLeer,
+		PermisoCitasEscribir,
+		PermisoCitasReservar,
+	}},
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session es una sesión iniciada por un usuario en un dispositivo. Corresponde a una familia de
+// tokens de renovación: se crea al iniciar sesión, se actualiza en cada renovación y se cierra
+// cuando se revoca la familia.
+type Session struct {
+	gorm.Model
+	UserID     uint       `gorm:"not null;index"`
+	User       User       `gorm:"foreignKey:UserID"`
+	FamilyID   string     `gorm:"size:64;not null;uniqueIndex"`
+	UserAgent  string     `gorm:"size:512"`
+	IP         string     `gorm:"size:64"`
+	LastSeenAt time.Time  `gorm:"not null"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time `gorm:"index"`
+}
+
+// Activa indica si la sesión no fue cerrada y su token de renovación no expiró
+func (s Session) Activa() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// SesionActiva filtra las sesiones que no fueron cerradas ni expiraron
+func SesionActiva(db *gorm.DB) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+}
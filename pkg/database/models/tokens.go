@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken es un token de renovación emitido al iniciar sesión.
+// Solo se guarda el hash SHA-256 del token. Cada renovación revoca el token usado y emite otro de la misma familia;
+// si se presenta un token ya rotado se considera robado y se revoca toda la familia.
+// AccessJti es el jti del token de acceso emitido junto con este token de renovación.
+type RefreshToken struct {
+	gorm.Model
+	UserID       uint       `gorm:"not null;index"`
+	User         User       `gorm:"foreignKey:UserID"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex"`
+	FamilyID     string     `gorm:"size:64;not null;index"`
+	AccessJti    string     `gorm:"size:64;not null"`
+	ExpiresAt    time.Time  `gorm:"not null"`
+	RevokedAt    *time.Time `gorm:"index"`
+	ReplacedByID *uint
+}
+
+// Vigente indica si el token no fue revocado ni expiró
+func (t RefreshToken) Vigente() bool {
+	return t.RevokedAt == nil && time.Now().Before(t.ExpiresAt)
+}
+
+// RevokedToken registra el jti de un token de acceso revocado antes de su expiración.
+// El registro puede eliminarse una vez pasado ExpiresAt, ya que el token deja de ser válido por sí solo.
+type RevokedToken struct {
+	ID        uint      `gorm:"primarykey"`
+	Jti       string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
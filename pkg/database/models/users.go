@@ -1,16 +1,51 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type User struct {
 	gorm.Model
-	Name         string `gorm:"size:255;not null"`
-	Password     string `gorm:"size:255;not null"`
-	Phone        string `gorm:"unique;not null"`
-	Email        string `gorm:"unique;not null"`
-	RoleID       uint
-	Role         Role          `gorm:"foreignKey:RoleID"`
-	Appointments []Appointment `gorm:"foreignKey:UserID"`
+	Name             string `gorm:"size:255;not null"`
+	Password         string `gorm:"size:255;not null"`
+	Phone            string `gorm:"unique;not null"`
+	Email            string `gorm:"unique;not null"`
+	RoleID           uint
+	Role             Role          `gorm:"foreignKey:RoleID"`
+	Appointments     []Appointment `gorm:"foreignKey:UserID"`
+	SuspendedAt      *time.Time    `gorm:"index"`
+	SuspensionReason string        `gorm:"size:255"`
+	EmailVerifiedAt  *time.Time
+	PhoneVerifiedAt  *time.Time
+	TOTPSecret       string `gorm:"size:64"`
+	TOTPEnabledAt    *time.Time
+	TOTPLastStep     int64 `gorm:"not null;default:0"`
+	ErasedAt         *time.Time
+}
+
+// Suspendido indica si la cuenta fue suspendida por un administrador
+func (u User) Suspendido() bool {
+	return u.SuspendedAt != nil
+}
+
+// Borrado indica si los datos personales de la cuenta fueron anonimizados (derecho al olvido)
+func (u User) Borrado() bool {
+	return u.ErasedAt != nil
+}
+
+// EmailVerificado indica si el usuario confirmó su email con un código de verificación
+func (u User) EmailVerificado() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// TelefonoVerificado indica si el usuario confirmó su teléfono con un código de verificación
+func (u User) TelefonoVerificado() bool {
+	return u.PhoneVerifiedAt != nil
+}
+
+// SegundoFactorActivo indica si el usuario tiene activada la autenticación en dos pasos (TOTP)
+func (u User) SegundoFactorActivo() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != ""
 }
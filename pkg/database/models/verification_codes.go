@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Medios de contacto que se pueden verificar. VerificacionNuevoEmail confirma el email
+// al que el usuario quiere cambiar; el cambio se aplica recién al verificar el código.
+const (
+	VerificacionEmail      = "email"
+	VerificacionTelefono   = "phone"
+	VerificacionNuevoEmail = "new_email"
+)
+
+// VerificationCode es un código de un solo uso enviado para verificar el email o el teléfono de un usuario.
+// Destination guarda el email o teléfono al que se envió, de modo que el código no sirva si el dato cambia.
+// Solo se guarda el hash del código; Attempts cuenta los intentos fallidos.
+type VerificationCode struct {
+	gorm.Model
+	UserID      uint      `gorm:"not null;index"`
+	User        User      `gorm:"foreignKey:UserID"`
+	Channel     string    `gorm:"size:10;not null"`
+	Destination string    `gorm:"size:255;not null"`
+	CodeHash    string    `gorm:"size:64;not null"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	Attempts    int       `gorm:"not null;default:0"`
+	UsedAt      *time.Time
+}
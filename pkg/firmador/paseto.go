@@ -1,13 +1,29 @@
 package firmador
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"aidanwoods.dev/go-paseto"
 )
 
-// Firmar un token con los datos proporcionados y una duracion
+// footer es el pie (sin cifrar) de los tokens; indica el kid de la llave con la que se firmó
+type footer struct {
+	Kid string `json:"kid"`
+}
+
+// Firmar un token con los datos proporcionados y una duracion, usando la llave activa del llavero.
+// Según el modo configurado el token es v4.local (cifrado) o v4.public (firmado, los datos no van cifrados).
+// Si data incluye "jti" se usa como identificador del token (necesario para poder revocarlo).
 func FirmarToken(data map[string]string, duration time.Duration) (string, error) {
+	activa, modo, err := keyring.llaveFirma()
+	if err != nil {
+		return "", err
+	}
+
 	token := paseto.NewToken()
 
 	for key, value := range data {
@@ -20,15 +36,59 @@ func FirmarToken(data map[string]string, duration time.Duration) (string, error)
 	token.SetNotBefore(now)
 	token.SetExpiration(now.Add(duration))
 
-	return token.V4Encrypt(SecretKey, nil), nil
+	pie, err := json.Marshal(footer{Kid: activa.id})
+	if err != nil {
+		return "", err
+	}
+	token.SetFooter(pie)
+
+	if modo == ModoPublico {
+		return token.V4Sign(*activa.secreta, nil), nil
+	}
+
+	return token.V4Encrypt(activa.clave, nil), nil
 }
 
-// Verificar un token y retornar los datos del token
+// Verificar un token y retornar los datos del token.
+// La llave se elige según el kid del pie del token, por lo que se aceptan tokens de llaves anteriores
+// mientras sigan configuradas o dentro del periodo de gracia.
 func VerificarToken(tokenStr string) (*paseto.Token, error) {
 	parser := paseto.NewParser()
 	parser.AddRule(paseto.NotExpired())        // Verifica que el token no haya expirado
 	parser.AddRule(paseto.ValidAt(time.Now())) // Verifica que el token sea valido en el momento actual
 
-	return parser.ParseV4Local(SecretKey, tokenStr, nil) // Parseamos el token y lo retornamos
+	protocolo := paseto.V4Local
+	if Modo() == ModoPublico {
+		protocolo = paseto.V4Public
+	}
 
+	// El pie no está cifrado, pero está autenticado: si se altera, el parseo falla
+	var pie footer
+	if crudo, err := parser.UnsafeParseFooter(protocolo, tokenStr); err == nil && len(crudo) > 0 {
+		if err := json.Unmarshal(crudo, &pie); err != nil {
+			return nil, err
+		}
+	}
+
+	llave, modo, err := keyring.llaveVerificacion(pie.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if modo == ModoPublico {
+		return parser.ParseV4Public(llave.publica, tokenStr, nil)
+	}
+
+	return parser.ParseV4Local(llave.clave, tokenStr, nil) // Parseamos el token y lo retornamos
+
+}
+
+// Generar un identificador aleatorio de 32 caracteres hexadecimales (para el claim jti y similares).
+// Si no se pueden obtener bytes aleatorios provoca un panic: un identificador predecible no es seguro.
+func GenerarID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(fmt.Sprintf("no se pudo generar un identificador aleatorio: %v", err))
+	}
+	return hex.EncodeToString(bytes)
 }
@@ -1,28 +1,339 @@
 package firmador
 
 import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"aidanwoods.dev/go-paseto"
 )
 
-var SecretKey paseto.V4SymmetricKey
+// Modos de firma de los tokens
+const (
+	ModoLocal   = "local"  // v4.local: tokens cifrados con una clave simétrica compartida
+	ModoPublico = "public" // v4.public: tokens firmados con Ed25519, verificables con la clave pública
+)
+
+// llave es una clave del llavero identificada por su kid.
+// En modo local se usa clave; en modo público se usan secreta (solo si la llave puede firmar) y publica.
+// retiradaEn es cero mientras la llave esté configurada; cuando deja de estarlo,
+// se sigue aceptando para verificar durante el periodo de gracia.
+type llave struct {
+	id         string
+	clave      paseto.V4SymmetricKey
+	secreta    *paseto.V4AsymmetricSecretKey
+	publica    paseto.V4AsymmetricPublicKey
+	retiradaEn time.Time
+}
+
+// llavero contiene la llave activa (con la que se firman los tokens) y las llaves aceptadas para verificar
+type llavero struct {
+	mu     sync.RWMutex
+	modo   string
+	activa string
+	llaves map[string]*llave
+	gracia time.Duration
+}
+
+// archivoLlaves es el formato del archivo indicado en PASETO_KEYS_FILE:
+//
+//	{"active": "2025-06", "keys": {"2025-06": "<hex>", "2025-01": "<hex>"}, "public_keys": {"2024-06": "<hex>"}}
+//
+// Todas las llaves del archivo se aceptan para verificar; solo la activa se usa para firmar.
+// En modo local keys contiene claves simétricas; en modo público contiene claves privadas Ed25519
+// (64 bytes o semilla de 32 bytes) y public_keys permite agregar claves públicas solo para verificar.
+type archivoLlaves struct {
+	Active     string            `json:"active"`
+	Keys       map[string]string `json:"keys"`
+	PublicKeys map[string]string `json:"public_keys"`
+}
+
+// LlavePublica es una clave pública publicada para que terceros verifiquen los tokens v4.public
+type LlavePublica struct {
+	ID         string
+	Hex        string
+	Activa     bool
+	RetiradaEn *time.Time
+}
 
-// Inicializar la clave secreta para firmar y verificar tokens
+var keyring = &llavero{modo: ModoLocal, llaves: map[string]*llave{}}
 
+// Inicializar el llavero para firmar y verificar tokens.
+// PASETO_MODE elige el modo de firma: local (por defecto) o public.
+// Las llaves se leen del archivo PASETO_KEYS_FILE o, si no está definido, de las variables de entorno:
+// SECRET_KEY en modo local y PASETO_PRIVATE_KEY o PASETO_PRIVATE_KEY_FILE (hex o PEM PKCS#8) en modo público,
+// con el kid indicado en SECRET_KEY_ID.
+// PASETO_KEY_GRACE_HOURS define durante cuántas horas se aceptan las llaves retiradas (24 por defecto).
 func InitPaseto() {
-	keyHex := os.Getenv("SECRET_KEY")
+	if err := RecargarLlaves(); err != nil {
+		panic(fmt.Sprintf("Error al inicializar la clave paseto: %v", err))
+	}
+}
 
-	if keyHex == "" {
-		panic("SECRET_KEY is not set")
+// RecargarLlaves vuelve a leer la configuración de llaves sin reiniciar el servidor.
+// Las llaves que dejan de estar configuradas (por ejemplo la activa anterior) se aceptan durante el periodo de gracia.
+// Si la nueva configuración no es válida se conserva el llavero actual y se retorna el error.
+func RecargarLlaves() error {
+	modo := strings.ToLower(os.Getenv("PASETO_MODE"))
+	if modo == "" {
+		modo = ModoLocal
+	}
+	if modo != ModoLocal && modo != ModoPublico {
+		return fmt.Errorf("PASETO_MODE no válido: %q", modo)
 	}
 
-	var err error
-	SecretKey, err = paseto.V4SymmetricKeyFromHex(keyHex)
+	activa, llaves, err := leerLlaves(modo)
+	if err != nil {
+		return err
+	}
+
+	gracia := 24 * time.Hour
+	if horas, err := strconv.Atoi(os.Getenv("PASETO_KEY_GRACE_HOURS")); err == nil && horas >= 0 {
+		gracia = time.Duration(horas) * time.Hour
+	}
+
+	keyring.mu.Lock()
+	defer keyring.mu.Unlock()
+
+	// Las llaves de otro modo no sirven para verificar los tokens del modo nuevo
+	ahora := time.Now()
+	if keyring.modo == modo {
+		for id, anterior := range keyring.llaves {
+			if _, sigue := llaves[id]; sigue {
+				continue
+			}
+			if anterior.retiradaEn.IsZero() {
+				anterior.retiradaEn = ahora
+			}
+			if ahora.Sub(anterior.retiradaEn) < gracia {
+				llaves[id] = anterior
+			}
+		}
+	}
+
+	keyring.modo = modo
+	keyring.activa = activa
+	keyring.llaves = llaves
+	keyring.gracia = gracia
+
+	return nil
+}
 
+// LlaveActiva retorna el kid de la llave con la que se firman los tokens
+func LlaveActiva() string {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+	return keyring.activa
+}
+
+// Modo retorna el modo de firma configurado (local o public)
+func Modo() string {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+	return keyring.modo
+}
+
+// LlavesPublicas retorna las claves públicas aceptadas para verificar, ordenadas por kid.
+// En modo local no hay claves públicas y el resultado es vacío.
+func LlavesPublicas() []LlavePublica {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+
+	publicas := []LlavePublica{}
+	if keyring.modo != ModoPublico {
+		return publicas
+	}
+
+	for id, llave := range keyring.llaves {
+		if !llave.retiradaEn.IsZero() && time.Since(llave.retiradaEn) >= keyring.gracia {
+			continue
+		}
+
+		publica := LlavePublica{ID: id, Hex: llave.publica.ExportHex(), Activa: id == keyring.activa}
+		if !llave.retiradaEn.IsZero() {
+			retiradaEn := llave.retiradaEn
+			publica.RetiradaEn = &retiradaEn
+		}
+		publicas = append(publicas, publica)
+	}
+
+	sort.Slice(publicas, func(i, j int) bool { return publicas[i].ID < publicas[j].ID })
+	return publicas
+}
+
+// leerLlaves obtiene las llaves configuradas para el modo y el kid de la llave activa
+func leerLlaves(modo string) (string, map[string]*llave, error) {
+	llaves := map[string]*llave{}
+
+	ruta := os.Getenv("PASETO_KEYS_FILE")
+	if ruta == "" {
+		id := os.Getenv("SECRET_KEY_ID")
+		if id == "" {
+			id = "default"
+		}
+
+		keyHex, err := llaveDeEntorno(modo)
+		if err != nil {
+			return "", nil, err
+		}
+
+		nueva, err := nuevaLlave(modo, id, keyHex)
+		if err != nil {
+			return "", nil, err
+		}
+
+		llaves[id] = nueva
+		return id, llaves, nil
+	}
+
+	contenido, err := os.ReadFile(ruta)
 	if err != nil {
-		panic(fmt.Sprintf("Error al inicializar la clave paseto: %v", err))
+		return "", nil, err
+	}
+
+	var archivo archivoLlaves
+	if err := json.Unmarshal(contenido, &archivo); err != nil {
+		return "", nil, fmt.Errorf("formato no válido en %s: %v", ruta, err)
+	}
+
+	for id, keyHex := range archivo.Keys {
+		nueva, err := nuevaLlave(modo, id, keyHex)
+		if err != nil {
+			return "", nil, err
+		}
+		llaves[id] = nueva
+	}
+
+	if modo == ModoPublico {
+		for id, keyHex := range archivo.PublicKeys {
+			if _, repetida := llaves[id]; repetida {
+				return "", nil, fmt.Errorf("la llave %q está repetida en keys y public_keys", id)
+			}
+			publica, err := paseto.NewV4AsymmetricPublicKeyFromHex(keyHex)
+			if err != nil {
+				return "", nil, fmt.Errorf("llave pública %q no válida: %v", id, err)
+			}
+			llaves[id] = &llave{id: id, publica: publica}
+		}
+	}
+
+	if activa, ok := llaves[archivo.Active]; !ok || (modo == ModoPublico && activa.secreta == nil) {
+		return "", nil, fmt.Errorf("la llave activa %q no está en las llaves de firma de %s", archivo.Active, ruta)
+	}
+
+	return archivo.Active, llaves, nil
+}
+
+// llaveDeEntorno lee la llave de firma desde las variables de entorno del modo indicado
+func llaveDeEntorno(modo string) (string, error) {
+	if modo == ModoLocal {
+		keyHex := os.Getenv("SECRET_KEY")
+		if keyHex == "" {
+			return "", errors.New("SECRET_KEY is not set")
+		}
+		return keyHex, nil
+	}
+
+	if keyHex := os.Getenv("PASETO_PRIVATE_KEY"); keyHex != "" {
+		return keyHex, nil
+	}
+
+	ruta := os.Getenv("PASETO_PRIVATE_KEY_FILE")
+	if ruta == "" {
+		return "", errors.New("PASETO_PRIVATE_KEY or PASETO_PRIVATE_KEY_FILE is not set")
+	}
+
+	contenido, err := os.ReadFile(ruta)
+	if err != nil {
+		return "", err
+	}
+
+	bloque, _ := pem.Decode(contenido)
+	if bloque == nil {
+		return strings.TrimSpace(string(contenido)), nil
+	}
+
+	privada, err := x509.ParsePKCS8PrivateKey(bloque.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("llave privada no válida en %s: %v", ruta, err)
+	}
+
+	ed, ok := privada.(ed25519.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("la llave privada de %s no es Ed25519", ruta)
+	}
+
+	secreta, err := paseto.NewV4AsymmetricSecretKeyFromEd25519(ed)
+	if err != nil {
+		return "", err
+	}
+	return secreta.ExportHex(), nil
+}
+
+// nuevaLlave construye una llave del modo indicado a partir de su representación hexadecimal.
+// En modo público se acepta la clave privada Ed25519 completa (64 bytes) o su semilla (32 bytes).
+func nuevaLlave(modo, id, keyHex string) (*llave, error) {
+	if modo == ModoLocal {
+		clave, err := paseto.V4SymmetricKeyFromHex(keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("llave %q no válida: %v", id, err)
+		}
+		return &llave{id: id, clave: clave}, nil
+	}
+
+	var secreta paseto.V4AsymmetricSecretKey
+	var err error
+	if len(keyHex) == ed25519.SeedSize*2 {
+		secreta, err = paseto.NewV4AsymmetricSecretKeyFromSeed(keyHex)
+	} else {
+		secreta, err = paseto.NewV4AsymmetricSecretKeyFromHex(keyHex)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("llave %q no válida: %v", id, err)
+	}
+
+	return &llave{id: id, secreta: &secreta, publica: secreta.Public()}, nil
+}
+
+// llaveFirma retorna la llave activa y el modo de firma
+func (k *llavero) llaveFirma() (*llave, string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	activa, ok := k.llaves[k.activa]
+	if !ok {
+		return nil, "", errors.New("el firmador no está inicializado")
+	}
+	return activa, k.modo, nil
+}
+
+// llaveVerificacion busca la llave con el kid indicado, descartando las retiradas cuyo periodo de gracia terminó.
+// Los tokens sin kid (emitidos antes del llavero) se verifican con la llave activa.
+func (k *llavero) llaveVerificacion(id string) (*llave, string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if id == "" {
+		id = k.activa
+	}
+
+	encontrada, ok := k.llaves[id]
+	if !ok {
+		return nil, "", fmt.Errorf("llave %q desconocida", id)
+	}
+
+	if !encontrada.retiradaEn.IsZero() && time.Since(encontrada.retiradaEn) >= k.gracia {
+		return nil, "", fmt.Errorf("llave %q retirada", id)
 	}
 
+	return encontrada, k.modo, nil
 }
@@ -0,0 +1,122 @@
+package firmador
+
+import (
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// reiniciarLlavero deja el llavero vacío durante la prueba y configura las variables de entorno
+// para leer una llave local con el kid indicado
+func reiniciarLlavero(t *testing.T, kid, keyHex string) {
+	t.Helper()
+	anterior := keyring
+	keyring = &llavero{modo: ModoLocal, llaves: map[string]*llave{}}
+	t.Cleanup(func() { keyring = anterior })
+
+	t.Setenv("PASETO_MODE", ModoLocal)
+	t.Setenv("PASETO_KEYS_FILE", "")
+	t.Setenv("PASETO_KEY_GRACE_HOURS", "1")
+	t.Setenv("SECRET_KEY_ID", kid)
+	t.Setenv("SECRET_KEY", keyHex)
+}
+
+func nuevaClaveHex() string {
+	return paseto.NewV4SymmetricKey().ExportHex()
+}
+
+func firmarPrueba(t *testing.T) string {
+	t.Helper()
+	token, err := FirmarToken(map[string]string{"user_id": "1"}, time.Minute)
+	if err != nil {
+		t.Fatalf("FirmarToken: %v", err)
+	}
+	return token
+}
+
+func TestLlaveAnteriorDuranteLaGracia(t *testing.T) {
+	reiniciarLlavero(t, "2025-01", nuevaClaveHex())
+	if err := RecargarLlaves(); err != nil {
+		t.Fatalf("RecargarLlaves: %v", err)
+	}
+	token := firmarPrueba(t)
+
+	// Rotación: la llave anterior deja de estar configurada
+	t.Setenv("SECRET_KEY_ID", "2025-06")
+	t.Setenv("SECRET_KEY", nuevaClaveHex())
+	if err := RecargarLlaves(); err != nil {
+		t.Fatalf("RecargarLlaves: %v", err)
+	}
+	if LlaveActiva() != "2025-06" {
+		t.Fatalf("LlaveActiva = %q, se esperaba 2025-06", LlaveActiva())
+	}
+
+	if _, err := VerificarToken(token); err != nil {
+		t.Errorf("el token de la llave anterior debería verificarse durante la gracia: %v", err)
+	}
+
+	// Se simula que el periodo de gracia ya terminó
+	keyring.llaves["2025-01"].retiradaEn = time.Now().Add(-2 * time.Hour)
+	if _, err := VerificarToken(token); err == nil {
+		t.Error("el token de la llave anterior no debería verificarse después de la gracia")
+	}
+
+	if _, err := VerificarToken(firmarPrueba(t)); err != nil {
+		t.Errorf("el token de la llave activa debería verificarse: %v", err)
+	}
+}
+
+func TestLlaveDesconocida(t *testing.T) {
+	reiniciarLlavero(t, "otra", nuevaClaveHex())
+	if err := RecargarLlaves(); err != nil {
+		t.Fatalf("RecargarLlaves: %v", err)
+	}
+	token := firmarPrueba(t)
+
+	// Un llavero nuevo sin la llave "otra" no debe aceptar el token
+	reiniciarLlavero(t, "propia", nuevaClaveHex())
+	if err := RecargarLlaves(); err != nil {
+		t.Fatalf("RecargarLlaves: %v", err)
+	}
+
+	if _, err := VerificarToken(token); err == nil {
+		t.Error("un token con un kid desconocido no debería verificarse")
+	}
+}
+
+func TestRecargaInvalidaConservaLlavero(t *testing.T) {
+	reiniciarLlavero(t, "2025-01", nuevaClaveHex())
+	if err := RecargarLlaves(); err != nil {
+		t.Fatalf("RecargarLlaves: %v", err)
+	}
+	token := firmarPrueba(t)
+
+	casos := []struct {
+		nombre string
+		env    map[string]string
+	}{
+		{nombre: "llave no hexadecimal", env: map[string]string{"SECRET_KEY_ID": "2025-06", "SECRET_KEY": "no-es-hex"}},
+		{nombre: "llave vacía", env: map[string]string{"SECRET_KEY_ID": "2025-06", "SECRET_KEY": ""}},
+		{nombre: "modo desconocido", env: map[string]string{"PASETO_MODE": "v3"}},
+		{nombre: "archivo inexistente", env: map[string]string{"PASETO_KEYS_FILE": t.TempDir() + "/llaves.json"}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			for clave, valor := range caso.env {
+				t.Setenv(clave, valor)
+			}
+
+			if err := RecargarLlaves(); err == nil {
+				t.Fatal("RecargarLlaves debería fallar")
+			}
+			if LlaveActiva() != "2025-01" || Modo() != ModoLocal {
+				t.Errorf("el llavero cambió: activa = %q, modo = %q", LlaveActiva(), Modo())
+			}
+			if _, err := VerificarToken(token); err != nil {
+				t.Errorf("el token anterior debería seguir verificándose: %v", err)
+			}
+		})
+	}
+}
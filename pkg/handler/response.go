@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"backend_reservation/pkg/validator"
 	"encoding/json"
 	"net/http"
 )
@@ -27,6 +28,11 @@ func Success(w http.ResponseWriter, r *http.Request, message string, data interf
 }
 
 func Error(w http.ResponseWriter, r *http.Request, status int, message string) {
+	ErrorData(w, r, status, message, nil)
+}
+
+// ErrorData responde con un error que incluye información adicional en el campo data
+func ErrorData(w http.ResponseWriter, r *http.Request, status int, message string, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	_, messageStatus := statusError(status)
 	w.WriteHeader(status)
@@ -35,7 +41,14 @@ func Error(w http.ResponseWriter, r *http.Request, status int, message string) {
 		message = messageStatus
 	}
 
-	json.NewEncoder(w).Encode(Response{Message: message, Data: nil})
+	json.NewEncoder(w).Encode(Response{Message: message, Data: data})
+}
+
+// ErrorValidacion responde con 422 y la lista de campos que no cumplen las reglas de validación
+func ErrorValidacion(w http.ResponseWriter, r *http.Request, errores validator.Errores) {
+	ErrorData(w, r, http.StatusUnprocessableEntity, "Los datos enviados no son válidos", map[string]any{
+		"errors": errores,
+	})
 }
 
 func statusSuccess(r *http.Request) (int, string) {
@@ -65,6 +78,10 @@ func statusError(status int) (int, string) {
 		return http.StatusUnauthorized, "Unauthorized"
 	case http.StatusForbidden:
 		return http.StatusForbidden, "Forbidden"
+	case http.StatusConflict:
+		return http.StatusConflict, "Conflict"
+	case http.StatusUnprocessableEntity:
+		return http.StatusUnprocessableEntity, "Unprocessable entity"
 	case http.StatusInternalServerError:
 		return http.StatusInternalServerError, "Internal server error"
 	default:
@@ -0,0 +1,166 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Evento representa un VEVENT de un archivo iCalendar (.ics)
+type Evento struct {
+	UID       string
+	Resumen   string
+	Inicio    time.Time
+	Fin       time.Time
+	TodoElDia bool
+	// Recurrente indica que el evento tiene una regla de repetición (RRULE o RDATE) o que modifica
+	// una ocurrencia de un evento repetido (RECURRENCE-ID). Las repeticiones no se expanden.
+	Recurrente bool
+}
+
+// Parsear lee un calendario iCalendar (RFC 5545) y devuelve sus eventos.
+// Soporta líneas plegadas, fechas de día completo (VALUE=DATE), fechas UTC (sufijo Z),
+// fechas con TZID y fechas flotantes, que se interpretan en la zona horaria loc.
+// Si un evento no tiene DTEND, los eventos de día completo duran un día y el resto termina en su inicio.
+func Parsear(r io.Reader, loc *time.Location) ([]Evento, error) {
+	lineas, err := desplegarLineas(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventos []Evento
+	var actual *Evento
+	tieneFin := false
+
+	for numero, linea := range lineas {
+		nombre, parametros, valor, ok := separarPropiedad(linea)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case nombre == "BEGIN" && valor == "VEVENT":
+			actual = &Evento{}
+			tieneFin = false
+		case nombre == "END" && valor == "VEVENT":
+			if actual == nil {
+				return nil, fmt.Errorf("línea %d: END:VEVENT sin BEGIN:VEVENT", numero+1)
+			}
+			if actual.Inicio.IsZero() {
+				return nil, fmt.Errorf("línea %d: evento sin DTSTART", numero+1)
+			}
+			if !tieneFin {
+				actual.Fin = actual.Inicio
+				if actual.TodoElDia {
+					actual.Fin = actual.Inicio.AddDate(0, 0, 1)
+				}
+			}
+			eventos = append(eventos, *actual)
+			actual = nil
+		case actual == nil:
+			continue
+		case nombre == "UID":
+			actual.UID = valor
+		case nombre == "SUMMARY":
+			actual.Resumen = desescaparTexto(valor)
+		case nombre == "DTSTART":
+			actual.Inicio, actual.TodoElDia, err = parsearFecha(valor, parametros, loc)
+			if err != nil {
+				return nil, fmt.Errorf("línea %d: %v", numero+1, err)
+			}
+		case nombre == "RRULE" || nombre == "RDATE" || nombre == "RECURRENCE-ID":
+			actual.Recurrente = true
+		case nombre == "DTEND":
+			actual.Fin, _, err = parsearFecha(valor, parametros, loc)
+			if err != nil {
+				return nil, fmt.Errorf("línea %d: %v", numero+1, err)
+			}
+			tieneFin = true
+		}
+	}
+
+	if actual != nil {
+		return nil, errors.New("el calendario termina dentro de un VEVENT")
+	}
+
+	return eventos, nil
+}
+
+// desplegarLineas une las líneas plegadas (las que comienzan con espacio o tabulación continúan la anterior)
+func desplegarLineas(r io.Reader) ([]string, error) {
+	var lineas []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		linea := strings.TrimRight(scanner.Text(), "\r")
+		if linea == "" {
+			continue
+		}
+		if (linea[0] == ' ' || linea[0] == '\t') && len(lineas) > 0 {
+			lineas[len(lineas)-1] += linea[1:]
+			continue
+		}
+		lineas = append(lineas, linea)
+	}
+
+	return lineas, scanner.Err()
+}
+
+// separarPropiedad separa una línea "NOMBRE;PARAM=VALOR:valor" en nombre, parámetros y valor
+func separarPropiedad(linea string) (string, map[string]string, string, bool) {
+	cabecera, valor, ok := strings.Cut(linea, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+
+	partes := strings.Split(cabecera, ";")
+	parametros := make(map[string]string, len(partes)-1)
+	for _, parte := range partes[1:] {
+		if clave, val, ok := strings.Cut(parte, "="); ok {
+			parametros[strings.ToUpper(clave)] = strings.Trim(val, `"`)
+		}
+	}
+
+	return strings.ToUpper(partes[0]), parametros, valor, true
+}
+
+// parsearFecha interpreta un valor DATE o DATE-TIME e indica si es de día completo
+func parsearFecha(valor string, parametros map[string]string, loc *time.Location) (time.Time, bool, error) {
+	if parametros["VALUE"] == "DATE" || len(valor) == 8 {
+		fecha, err := time.ParseInLocation("20060102", valor, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("fecha no válida %q", valor)
+		}
+		return fecha, true, nil
+	}
+
+	if strings.HasSuffix(valor, "Z") {
+		fecha, err := time.Parse("20060102T150405Z", valor)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("fecha no válida %q", valor)
+		}
+		return fecha, false, nil
+	}
+
+	zona := loc
+	if tzid := parametros["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			zona = tz
+		}
+	}
+
+	fecha, err := time.ParseInLocation("20060102T150405", valor, zona)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("fecha no válida %q", valor)
+	}
+	return fecha, false, nil
+}
+
+// desescaparTexto revierte el escape de los valores de texto de iCalendar
+func desescaparTexto(valor string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(valor)
+}
@@ -0,0 +1,49 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsearRecurrentes(t *testing.T) {
+	calendario := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:navidad",
+		"SUMMARY:Navidad",
+		"DTSTART;VALUE=DATE:20261225",
+		"RRULE:FREQ=YEARLY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:navidad",
+		"RECURRENCE-ID;VALUE=DATE:20271225",
+		"DTSTART;VALUE=DATE:20271226",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:inventario",
+		"SUMMARY:Inventario",
+		"DTSTART:20260301T090000Z",
+		"DTEND:20260301T130000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	eventos, err := Parsear(strings.NewReader(calendario), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eventos) != 3 {
+		t.Fatalf("se esperaban 3 eventos, hay %d", len(eventos))
+	}
+
+	for i, recurrente := range []bool{true, true, false} {
+		if eventos[i].Recurrente != recurrente {
+			t.Errorf("evento %d: Recurrente = %v, se esperaba %v", i, eventos[i].Recurrente, recurrente)
+		}
+	}
+
+	if fin := eventos[0].Fin; !fin.Equal(time.Date(2026, 12, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("un evento de día completo sin DTEND debería durar un día, termina %v", fin)
+	}
+}
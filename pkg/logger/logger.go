@@ -29,6 +29,15 @@ type RotationConfig struct {
 	Compress bool
 }
 
+// EsDesarrollo indica si el entorno (APP_ENV) es el de desarrollo
+func EsDesarrollo(entorno string) bool {
+	switch strings.ToLower(entorno) {
+	case "development", "dev":
+		return true
+	}
+	return false
+}
+
 func InitLogger(cfg Config) {
 	var handler slog.Handler
 
@@ -0,0 +1,127 @@
+package notificador
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Canales por los que se puede enviar un mensaje
+const (
+	CanalEmail = "email"
+	CanalSMS   = "sms"
+)
+
+// Mensaje es una notificación para un usuario. Destino es el email o el teléfono según el canal.
+type Mensaje struct {
+	Canal   string
+	Destino string
+	Asunto  string
+	Cuerpo  string
+}
+
+// Notificador entrega mensajes a los usuarios (email, SMS, etc.)
+type Notificador interface {
+	Enviar(mensaje Mensaje) error
+}
+
+var (
+	mu             sync.RWMutex
+	predeterminado Notificador = Log{}
+)
+
+// Init define el notificador usado por Enviar
+func Init(n Notificador) {
+	mu.Lock()
+	defer mu.Unlock()
+	predeterminado = n
+}
+
+// DesdeEntorno construye el notificador indicado en NOTIFIER: log o file.
+// El notificador file escribe en la ruta NOTIFIER_FILE (notifications.log por defecto).
+// En desarrollo NOTIFIER es opcional (se usa log) y el log incluye el cuerpo de los mensajes;
+// fuera de desarrollo debe indicarse y el log omite el cuerpo, que contiene códigos y tokens.
+func DesdeEntorno(desarrollo bool) (Notificador, error) {
+	switch strings.ToLower(os.Getenv("NOTIFIER")) {
+	case "":
+		if !desarrollo {
+			return nil, errors.New("NOTIFIER no está definido")
+		}
+		return Log{MostrarCuerpo: true}, nil
+	case "log":
+		return Log{MostrarCuerpo: desarrollo}, nil
+	case "file":
+		ruta := os.Getenv("NOTIFIER_FILE")
+		if ruta == "" {
+			ruta = "notifications.log"
+		}
+		return &Archivo{Ruta: ruta}, nil
+	default:
+		return nil, fmt.Errorf("NOTIFIER no válido: %q", os.Getenv("NOTIFIER"))
+	}
+}
+
+// Enviar entrega el mensaje con el notificador configurado
+func Enviar(mensaje Mensaje) error {
+	mu.RLock()
+	n := predeterminado
+	mu.RUnlock()
+	return n.Enviar(mensaje)
+}
+
+// Log escribe los mensajes en el log de la aplicación. Pensado para desarrollo.
+// El cuerpo solo se escribe con MostrarCuerpo, porque contiene códigos y tokens que no deben quedar en los logs.
+type Log struct {
+	MostrarCuerpo bool
+}
+
+func (l Log) Enviar(mensaje Mensaje) error {
+	cuerpo := "[oculto]"
+	if l.MostrarCuerpo {
+		cuerpo = mensaje.Cuerpo
+	}
+
+	slog.Info("notificación",
+		"canal", mensaje.Canal,
+		"destino", mensaje.Destino,
+		"asunto", mensaje.Asunto,
+		"cuerpo", cuerpo,
+	)
+	return nil
+}
+
+// Archivo agrega cada mensaje como una línea JSON al final del archivo Ruta. Pensado para desarrollo y pruebas.
+type Archivo struct {
+	Ruta string
+	mu   sync.Mutex
+}
+
+func (a *Archivo) Enviar(mensaje Mensaje) error {
+	linea, err := json.Marshal(map[string]string{
+		"fecha":   time.Now().Format(time.RFC3339),
+		"canal":   mensaje.Canal,
+		"destino": mensaje.Destino,
+		"asunto":  mensaje.Asunto,
+		"cuerpo":  mensaje.Cuerpo,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	archivo, err := os.OpenFile(a.Ruta, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer archivo.Close()
+
+	_, err = archivo.Write(append(linea, '\n'))
+	return err
+}
@@ -0,0 +1,39 @@
+package notificador
+
+import "testing"
+
+func TestDesdeEntorno(t *testing.T) {
+	casos := []struct {
+		nombre     string
+		notifier   string
+		desarrollo bool
+		esperado   Notificador
+		error      bool
+	}{
+		{nombre: "sin definir en desarrollo", desarrollo: true, esperado: Log{MostrarCuerpo: true}},
+		{nombre: "sin definir fuera de desarrollo", error: true},
+		{nombre: "log en desarrollo", notifier: "log", desarrollo: true, esperado: Log{MostrarCuerpo: true}},
+		{nombre: "log fuera de desarrollo", notifier: "LOG", esperado: Log{}},
+		{nombre: "no válido", notifier: "smtp", desarrollo: true, error: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			t.Setenv("NOTIFIER", caso.notifier)
+
+			n, err := DesdeEntorno(caso.desarrollo)
+			if caso.error {
+				if err == nil {
+					t.Fatalf("se esperaba un error, se obtuvo %#v", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != caso.esperado {
+				t.Errorf("DesdeEntorno = %#v, se esperaba %#v", n, caso.esperado)
+			}
+		})
+	}
+}
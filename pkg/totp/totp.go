@@ -0,0 +1,95 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parámetros de los códigos (los valores por defecto de RFC 6238 que usan las apps de autenticación)
+const (
+	Digitos = 6
+	Periodo = 30 * time.Second
+)
+
+var codificacion = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerarSecreto genera un secreto aleatorio de 160 bits codificado en base32 (sin relleno)
+func GenerarSecreto() (string, error) {
+	bytes := make([]byte, 20)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return codificacion.EncodeToString(bytes), nil
+}
+
+// URI construye el enlace otpauth:// que las apps de autenticación leen desde un código QR
+func URI(emisor, cuenta, secreto string) string {
+	etiqueta := url.PathEscape(emisor + ":" + cuenta)
+	parametros := url.Values{}
+	parametros.Set("secret", secreto)
+	parametros.Set("issuer", emisor)
+	parametros.Set("algorithm", "SHA1")
+	parametros.Set("digits", fmt.Sprint(Digitos))
+	parametros.Set("period", fmt.Sprint(int(Periodo.Seconds())))
+	return "otpauth://totp/" + etiqueta + "?" + parametros.Encode()
+}
+
+// Paso retorna el número de intervalo de tiempo que corresponde a t
+func Paso(t time.Time) int64 {
+	return t.Unix() / int64(Periodo.Seconds())
+}
+
+// Codigo calcula el código del secreto para el paso indicado (HOTP de RFC 4226 con HMAC-SHA1)
+func Codigo(secreto string, paso int64) (string, error) {
+	clave, err := codificacion.DecodeString(strings.ToUpper(strings.TrimRight(secreto, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var mensaje [8]byte
+	binary.BigEndian.PutUint64(mensaje[:], uint64(paso))
+
+	mac := hmac.New(sha1.New, clave)
+	mac.Write(mensaje[:])
+	suma := mac.Sum(nil)
+
+	desplazamiento := suma[len(suma)-1] & 0x0f
+	valor := binary.BigEndian.Uint32(suma[desplazamiento:desplazamiento+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digitos; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digitos, valor%modulo), nil
+}
+
+// Validar comprueba el código contra el paso de t y los ventana pasos anteriores y posteriores
+// (para tolerar desfases de reloj). Retorna el paso que coincidió, para impedir que un código se reutilice.
+func Validar(secreto, codigo string, t time.Time, ventana int) (int64, bool) {
+	codigo = strings.ReplaceAll(strings.TrimSpace(codigo), " ", "")
+	if len(codigo) != Digitos {
+		return 0, false
+	}
+
+	actual := Paso(t)
+	for i := -ventana; i <= ventana; i++ {
+		esperado, err := Codigo(secreto, actual+int64(i))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(esperado), []byte(codigo)) == 1 {
+			return actual + int64(i), true
+		}
+	}
+
+	return 0, false
+}
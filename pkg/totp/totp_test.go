@@ -0,0 +1,95 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// secretoRFC es la llave "12345678901234567890" de los vectores de prueba de RFC 6238, en base32
+const secretoRFC = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodigoVectoresRFC6238(t *testing.T) {
+	// Los vectores de RFC 6238 tienen 8 dígitos; los códigos de 6 dígitos son sus últimos 6
+	casos := []struct {
+		segundos int64
+		codigo   string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, caso := range casos {
+		codigo, err := Codigo(secretoRFC, Paso(time.Unix(caso.segundos, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codigo != caso.codigo {
+			t.Errorf("Codigo(T=%d) = %s, se esperaba %s", caso.segundos, codigo, caso.codigo)
+		}
+	}
+}
+
+func TestValidar(t *testing.T) {
+	ahora := time.Unix(1111111111, 0)
+	paso := Paso(ahora)
+
+	codigoEn := func(p int64) string {
+		codigo, err := Codigo(secretoRFC, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return codigo
+	}
+
+	casos := []struct {
+		nombre  string
+		secreto string
+		codigo  string
+		valido  bool
+		paso    int64
+	}{
+		{nombre: "paso actual", secreto: secretoRFC, codigo: codigoEn(paso), valido: true, paso: paso},
+		{nombre: "paso anterior", secreto: secretoRFC, codigo: codigoEn(paso - 1), valido: true, paso: paso - 1},
+		{nombre: "paso siguiente", secreto: secretoRFC, codigo: codigoEn(paso + 1), valido: true, paso: paso + 1},
+		{nombre: "fuera de la ventana", secreto: secretoRFC, codigo: codigoEn(paso - 2)},
+		{nombre: "con espacios", secreto: secretoRFC, codigo: " " + codigoEn(paso)[:3] + " " + codigoEn(paso)[3:], valido: true, paso: paso},
+		{nombre: "secreto en minúsculas", secreto: strings.ToLower(secretoRFC), codigo: codigoEn(paso), valido: true, paso: paso},
+		{nombre: "código incorrecto", secreto: secretoRFC, codigo: "000000"},
+		{nombre: "código corto", secreto: secretoRFC, codigo: codigoEn(paso)[:5]},
+		{nombre: "código vacío", secreto: secretoRFC, codigo: ""},
+		{nombre: "secreto no válido", secreto: "no-es-base32!", codigo: "123456"},
+		{nombre: "sin secreto", secreto: "", codigo: codigoEn(paso)},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			obtenido, ok := Validar(caso.secreto, caso.codigo, ahora, 1)
+			if ok != caso.valido {
+				t.Fatalf("Validar = %v, se esperaba %v", ok, caso.valido)
+			}
+			if ok && obtenido != caso.paso {
+				t.Errorf("paso = %d, se esperaba %d", obtenido, caso.paso)
+			}
+		})
+	}
+}
+
+func TestGenerarSecreto(t *testing.T) {
+	secreto, err := GenerarSecreto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Codigo(secreto, 1); err != nil {
+		t.Errorf("el secreto generado no es base32 válido: %v", err)
+	}
+
+	otro, _ := GenerarSecreto()
+	if otro == secreto {
+		t.Error("dos secretos generados son iguales")
+	}
+}
@@ -0,0 +1,457 @@
+// Package validator valida estructuras (normalmente DTOs) a partir de las reglas declaradas en la etiqueta validate.
+//
+// Las reglas se separan por comas:
+//
+//	required        el campo no puede estar vacío; las cadenas con solo espacios se consideran vacías
+//	notblank        si el campo se envió (puntero no nil), no puede estar vacío
+//	email           dirección de email válida
+//	phone           teléfono de 7 a 15 dígitos; admite un + inicial, espacios, guiones y paréntesis
+//	min=N, max=N    largo en caracteres para cadenas, cantidad de elementos para listas y valor para números
+//	oneof=a b c     uno de los valores indicados, separados por espacios
+//	hhmm            hora con el formato HH:MM, con dos dígitos para la hora ("09:00", no "9:00")
+//	date            fecha con el formato YYYY-MM-DD
+//	gtfield=Campo   mayor que otro campo de la misma estructura (fechas, números o cadenas como HH:MM)
+//
+// Salvo required y notblank, las reglas no se aplican a los campos vacíos. Un puntero nil es vacío;
+// si no es nil, las reglas se aplican al valor al que apunta. Las estructuras anidadas y las listas de
+// estructuras se validan recursivamente. Los campos se nombran con su etiqueta json.
+//
+// Una regla desconocida o mal escrita provoca un panic al validar; Comprobar permite detectarlas al iniciar.
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrorCampo indica por qué un campo no es válido. Campo es la ruta del campo según sus etiquetas json
+// (por ejemplo "blocks[1].end_time") y Regla es la regla que no se cumple.
+type ErrorCampo struct {
+	Campo   string `json:"field"`
+	Regla   string `json:"rule"`
+	Mensaje string `json:"message"`
+}
+
+// Errores es la lista de campos no válidos de una estructura, con un error por campo
+type Errores []ErrorCampo
+
+func (e Errores) Error() string {
+	mensajes := make([]string, len(e))
+	for i, errCampo := range e {
+		mensajes[i] = errCampo.Campo + ": " + errCampo.Mensaje
+	}
+	return strings.Join(mensajes, "; ")
+}
+
+// Validar aplica las reglas de la estructura (o puntero a estructura) v y retorna todos los campos
+// que no las cumplen, o nil si es válida. Una regla desconocida o mal escrita provoca un panic.
+func Validar(v any) Errores {
+	return validar(v, false)
+}
+
+// ValidarParcial es como Validar pero omite la regla required, para las actualizaciones parciales
+// en las que los campos vacíos significan que no se modifican.
+func ValidarParcial(v any) Errores {
+	return validar(v, true)
+}
+
+func validar(v any, parcial bool) Errores {
+	valor := reflect.ValueOf(v)
+	for valor.Kind() == reflect.Pointer {
+		if valor.IsNil() {
+			return nil
+		}
+		valor = valor.Elem()
+	}
+	if valor.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: se esperaba una estructura y se recibió %s", valor.Kind()))
+	}
+
+	var errores Errores
+	validarEstructura(valor, "", parcial, &errores)
+	return errores
+}
+
+var tipoTiempo = reflect.TypeFor[time.Time]()
+
+// Comprobar verifica las etiquetas validate de las estructuras indicadas (y de sus estructuras anidadas)
+// sin validar valores: que las reglas existan, que sus parámetros sean válidos y que admitan el tipo del campo.
+// Se usa al iniciar la aplicación para que un error en una etiqueta no provoque un panic al atender una solicitud.
+func Comprobar(estructuras ...any) error {
+	var errs []error
+	for _, estructura := range estructuras {
+		tipo := reflect.TypeOf(estructura)
+		for tipo != nil && tipo.Kind() == reflect.Pointer {
+			tipo = tipo.Elem()
+		}
+		if tipo == nil || tipo.Kind() != reflect.Struct {
+			errs = append(errs, fmt.Errorf("validator: se esperaba una estructura y se recibió %v", tipo))
+			continue
+		}
+		comprobarEstructura(tipo, make(map[reflect.Type]bool), &errs)
+	}
+	return errors.Join(errs...)
+}
+
+// comprobarEstructura comprueba las reglas de cada campo exportado de la estructura y recorre los campos anidados
+func comprobarEstructura(tipo reflect.Type, visitados map[reflect.Type]bool, errs *[]error) {
+	if visitados[tipo] {
+		return
+	}
+	visitados[tipo] = true
+
+	for i := range tipo.NumField() {
+		campo := tipo.Field(i)
+		if !campo.IsExported() {
+			continue
+		}
+
+		for _, regla := range reglas(campo.Tag.Get("validate")) {
+			if err := comprobarRegla(regla, campo.Type, tipo); err != nil {
+				*errs = append(*errs, fmt.Errorf("validator: %s.%s: %w", tipo.Name(), campo.Name, err))
+			}
+		}
+
+		anidado := tipoBase(campo.Type)
+		if anidado.Kind() == reflect.Slice || anidado.Kind() == reflect.Array {
+			anidado = tipoBase(anidado.Elem())
+		}
+		if anidado.Kind() == reflect.Struct && anidado != tipoTiempo {
+			comprobarEstructura(anidado, visitados, errs)
+		}
+	}
+}
+
+// comprobarRegla retorna un error si la regla no existe, su parámetro no es válido o no admite el tipo del campo
+func comprobarRegla(r regla, tipo, estructura reflect.Type) error {
+	base := tipoBase(tipo)
+
+	switch r.nombre {
+	case "required", "notblank":
+		return nil
+	case "email", "phone", "hhmm", "date":
+		if base.Kind() != reflect.String {
+			return fmt.Errorf("la regla %s no admite el tipo %s", r.nombre, base)
+		}
+		return nil
+	case "min", "max":
+		if _, err := strconv.Atoi(r.parametro); err != nil {
+			return fmt.Errorf("parámetro no válido para %s: %q", r.nombre, r.parametro)
+		}
+		switch base.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return nil
+		}
+		if esEntero(base) {
+			return nil
+		}
+		return fmt.Errorf("la regla %s no admite el tipo %s", r.nombre, base)
+	case "oneof":
+		if len(strings.Fields(r.parametro)) == 0 {
+			return errors.New("la regla oneof necesita al menos un valor")
+		}
+		return nil
+	case "gtfield":
+		otro, ok := estructura.FieldByName(r.parametro)
+		if !ok {
+			return fmt.Errorf("gtfield hace referencia al campo inexistente %q", r.parametro)
+		}
+		if tipoBase(otro.Type) != base {
+			return fmt.Errorf("gtfield compara %s con el campo %s de tipo %s", base, r.parametro, tipoBase(otro.Type))
+		}
+		if base != tipoTiempo && base.Kind() != reflect.String && !esEntero(base) {
+			return fmt.Errorf("la regla gtfield no admite el tipo %s", base)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("regla desconocida %q", r.nombre)
+}
+
+// tipoBase retorna el tipo al que apuntan los punteros
+func tipoBase(tipo reflect.Type) reflect.Type {
+	for tipo.Kind() == reflect.Pointer {
+		tipo = tipo.Elem()
+	}
+	return tipo
+}
+
+// esEntero indica si el tipo es un entero con o sin signo
+func esEntero(tipo reflect.Type) bool {
+	switch tipo.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// validarEstructura aplica las reglas de cada campo exportado de la estructura y recorre los campos anidados.
+// prefijo es la ruta de la estructura dentro del valor validado.
+func validarEstructura(estructura reflect.Value, prefijo string, parcial bool, errores *Errores) {
+	tipo := estructura.Type()
+	for i := range tipo.NumField() {
+		campo := tipo.Field(i)
+		if !campo.IsExported() {
+			continue
+		}
+
+		valor := estructura.Field(i)
+
+		// Las estructuras embebidas sin nombre json aportan sus campos al mismo nivel
+		if campo.Anonymous && campo.Tag.Get("json") == "" {
+			if anidado := indirecto(valor); anidado.Kind() == reflect.Struct {
+				validarEstructura(anidado, prefijo, parcial, errores)
+			}
+			continue
+		}
+
+		nombre := nombreCampo(campo)
+		if nombre == "" {
+			continue
+		}
+		ruta := nombre
+		if prefijo != "" {
+			ruta = prefijo + "." + nombre
+		}
+
+		for _, regla := range reglas(campo.Tag.Get("validate")) {
+			if parcial && regla.nombre == "required" {
+				continue
+			}
+			if mensaje := aplicarRegla(regla, valor, estructura); mensaje != "" {
+				*errores = append(*errores, ErrorCampo{Campo: ruta, Regla: regla.nombre, Mensaje: mensaje})
+				break
+			}
+		}
+
+		validarAnidados(valor, ruta, parcial, errores)
+	}
+}
+
+// validarAnidados valida el campo si es una estructura o una lista de estructuras
+func validarAnidados(valor reflect.Value, ruta string, parcial bool, errores *Errores) {
+	valor = indirecto(valor)
+
+	switch valor.Kind() {
+	case reflect.Struct:
+		if valor.Type() != tipoTiempo {
+			validarEstructura(valor, ruta, parcial, errores)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range valor.Len() {
+			elemento := indirecto(valor.Index(i))
+			if elemento.Kind() == reflect.Struct && elemento.Type() != tipoTiempo {
+				validarEstructura(elemento, fmt.Sprintf("%s[%d]", ruta, i), parcial, errores)
+			}
+		}
+	}
+}
+
+// regla es una regla de la etiqueta validate con su parámetro (lo que sigue al "=")
+type regla struct {
+	nombre    string
+	parametro string
+}
+
+func reglas(etiqueta string) []regla {
+	if etiqueta == "" {
+		return nil
+	}
+
+	partes := strings.Split(etiqueta, ",")
+	lista := make([]regla, 0, len(partes))
+	for _, parte := range partes {
+		nombre, parametro, _ := strings.Cut(strings.TrimSpace(parte), "=")
+		lista = append(lista, regla{nombre: nombre, parametro: parametro})
+	}
+	return lista
+}
+
+// aplicarRegla retorna el mensaje de error si el valor no cumple la regla, o una cadena vacía si la cumple
+func aplicarRegla(r regla, valor, estructura reflect.Value) string {
+	switch r.nombre {
+	case "required":
+		if vacio(valor) {
+			return "es obligatorio"
+		}
+		return ""
+	case "notblank":
+		if valor.Kind() == reflect.Pointer && !valor.IsNil() && vacio(valor.Elem()) {
+			return "no puede estar vacío"
+		}
+		return ""
+	}
+
+	if vacio(valor) {
+		return ""
+	}
+	valor = indirecto(valor)
+
+	switch r.nombre {
+	case "email":
+		texto := valor.String()
+		if direccion, err := mail.ParseAddress(texto); err != nil || direccion.Address != texto {
+			return "debe ser un email válido"
+		}
+	case "phone":
+		if !telefonoValido(valor.String()) {
+			return "debe ser un teléfono válido"
+		}
+	case "min", "max":
+		return compararLimite(r, valor)
+	case "oneof":
+		opciones := strings.Fields(r.parametro)
+		texto := fmt.Sprint(valor.Interface())
+		for _, opcion := range opciones {
+			if texto == opcion {
+				return ""
+			}
+		}
+		return "debe ser uno de: " + strings.Join(opciones, ", ")
+	case "hhmm":
+		// Se exigen dos dígitos para la hora: así las horas se ordenan y comparan (gtfield) como texto
+		texto := valor.String()
+		if _, err := time.Parse("15:04", texto); err != nil || len(texto) != len("15:04") {
+			return "debe tener el formato HH:MM"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", valor.String()); err != nil {
+			return "debe tener el formato YYYY-MM-DD"
+		}
+	case "gtfield":
+		return compararCampo(r, valor, estructura)
+	default:
+		panic(fmt.Sprintf("validator: regla desconocida %q", r.nombre))
+	}
+
+	return ""
+}
+
+// compararLimite aplica las reglas min y max según el tipo del valor
+func compararLimite(r regla, valor reflect.Value) string {
+	limite, err := strconv.Atoi(r.parametro)
+	if err != nil {
+		panic(fmt.Sprintf("validator: parámetro no válido para %s: %q", r.nombre, r.parametro))
+	}
+	minimo := r.nombre == "min"
+
+	var medida int64
+	var mensajeMin, mensajeMax string
+	switch valor.Kind() {
+	case reflect.String:
+		medida = int64(utf8.RuneCountInString(valor.String()))
+		mensajeMin, mensajeMax = "debe tener al menos %d caracteres", "no puede superar los %d caracteres"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		medida = int64(valor.Len())
+		mensajeMin, mensajeMax = "debe tener al menos %d elementos", "no puede tener más de %d elementos"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		medida = valor.Int()
+		mensajeMin, mensajeMax = "debe ser mayor o igual a %d", "debe ser menor o igual a %d"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		medida = int64(valor.Uint())
+		mensajeMin, mensajeMax = "debe ser mayor o igual a %d", "debe ser menor o igual a %d"
+	default:
+		panic(fmt.Sprintf("validator: la regla %s no admite el tipo %s", r.nombre, valor.Kind()))
+	}
+
+	if minimo && medida < int64(limite) {
+		return fmt.Sprintf(mensajeMin, limite)
+	}
+	if !minimo && medida > int64(limite) {
+		return fmt.Sprintf(mensajeMax, limite)
+	}
+	return ""
+}
+
+// compararCampo aplica la regla gtfield: el valor debe ser mayor que el del campo indicado.
+// Si el otro campo está vacío no se compara.
+func compararCampo(r regla, valor, estructura reflect.Value) string {
+	campo, ok := estructura.Type().FieldByName(r.parametro)
+	if !ok {
+		panic(fmt.Sprintf("validator: gtfield hace referencia al campo inexistente %q", r.parametro))
+	}
+
+	otro := estructura.FieldByIndex(campo.Index)
+	if vacio(otro) {
+		return ""
+	}
+	otro = indirecto(otro)
+
+	var mayor bool
+	switch {
+	case valor.Type() == tipoTiempo:
+		mayor = valor.Interface().(time.Time).After(otro.Interface().(time.Time))
+	case valor.Kind() == reflect.String:
+		mayor = valor.String() > otro.String()
+	case valor.CanInt():
+		mayor = valor.Int() > otro.Int()
+	case valor.CanUint():
+		mayor = valor.Uint() > otro.Uint()
+	default:
+		panic(fmt.Sprintf("validator: la regla gtfield no admite el tipo %s", valor.Kind()))
+	}
+
+	if !mayor {
+		return "debe ser posterior a " + nombreCampo(campo)
+	}
+	return ""
+}
+
+// telefonoValido indica si el texto es un teléfono de 7 a 15 dígitos, con un + inicial opcional
+// y espacios, guiones o paréntesis como separadores
+func telefonoValido(texto string) bool {
+	digitos := 0
+	for i, c := range texto {
+		switch {
+		case c >= '0' && c <= '9':
+			digitos++
+		case c == '+' && i == 0:
+		case c == ' ' || c == '-' || c == '(' || c == ')':
+		default:
+			return false
+		}
+	}
+	return digitos >= 7 && digitos <= 15
+}
+
+// vacio indica si el valor es el valor cero de su tipo. Las cadenas con solo espacios y las listas sin
+// elementos también se consideran vacías.
+func vacio(valor reflect.Value) bool {
+	switch valor.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return valor.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(valor.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return valor.Len() == 0
+	default:
+		return valor.IsZero()
+	}
+}
+
+// indirecto retorna el valor al que apunta un puntero no nil, o el mismo valor en otro caso
+func indirecto(valor reflect.Value) reflect.Value {
+	for valor.Kind() == reflect.Pointer && !valor.IsNil() {
+		valor = valor.Elem()
+	}
+	return valor
+}
+
+// nombreCampo retorna el nombre json del campo, o una cadena vacía si el campo se omite en JSON
+func nombreCampo(campo reflect.StructField) string {
+	nombre, _, _ := strings.Cut(campo.Tag.Get("json"), ",")
+	switch nombre {
+	case "-":
+		return ""
+	case "":
+		return campo.Name
+	}
+	return nombre
+}
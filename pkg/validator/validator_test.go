@@ -0,0 +1,194 @@
+package validator
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+type datosPrueba struct {
+	Nombre    string     `json:"nombre" validate:"required,max=5"`
+	Apodo     *string    `json:"apodo" validate:"notblank,min=2"`
+	Email     string     `json:"email" validate:"email"`
+	Telefono  string     `json:"telefono" validate:"phone"`
+	Edad      int        `json:"edad" validate:"min=18,max=99"`
+	Cupo      uint       `json:"cupo" validate:"max=10"`
+	Etiquetas []string   `json:"etiquetas" validate:"max=2"`
+	Estado    string     `json:"estado" validate:"oneof=activo inactivo"`
+	Desde     string     `json:"desde" validate:"hhmm"`
+	Hasta     string     `json:"hasta" validate:"hhmm,gtfield=Desde"`
+	Fecha     string     `json:"fecha" validate:"date"`
+	Inicio    time.Time  `json:"inicio"`
+	Fin       time.Time  `json:"fin" validate:"gtfield=Inicio"`
+	Minimo    int        `json:"minimo"`
+	Maximo    int        `json:"maximo" validate:"gtfield=Minimo"`
+	Bloques   []bloque   `json:"bloques"`
+	Anidado   *bloque    `json:"anidado"`
+	Oculto    string     `json:"-" validate:"required"`
+	SinJSON   string     `validate:"max=1"`
+	Vencido   *time.Time `json:"vencido"`
+	sinExport string     `validate:"required"`
+}
+
+type bloque struct {
+	Tipo string `json:"tipo" validate:"required"`
+}
+
+func texto(s string) *string { return &s }
+
+// valido retorna datos que cumplen todas las reglas; cada caso modifica un campo
+func valido() datosPrueba {
+	inicio := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	return datosPrueba{Nombre: "Ana", Inicio: inicio, Fin: inicio.Add(time.Hour)}
+}
+
+func TestValidar(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		modificar func(*datosPrueba)
+		campo     string
+		regla     string
+	}{
+		{nombre: "válido", modificar: func(*datosPrueba) {}},
+		{nombre: "required vacío", modificar: func(d *datosPrueba) { d.Nombre = "" }, campo: "nombre", regla: "required"},
+		{nombre: "required solo espacios", modificar: func(d *datosPrueba) { d.Nombre = "   " }, campo: "nombre", regla: "required"},
+		{nombre: "max en caracteres", modificar: func(d *datosPrueba) { d.Nombre = "Ñañañá" }, campo: "nombre", regla: "max"},
+		{nombre: "max en caracteres multibyte", modificar: func(d *datosPrueba) { d.Nombre = "Ñañañ" }},
+		{nombre: "notblank nil", modificar: func(d *datosPrueba) { d.Apodo = nil }},
+		{nombre: "notblank vacío", modificar: func(d *datosPrueba) { d.Apodo = texto(" ") }, campo: "apodo", regla: "notblank"},
+		{nombre: "min en puntero", modificar: func(d *datosPrueba) { d.Apodo = texto("A") }, campo: "apodo", regla: "min"},
+		{nombre: "email válido", modificar: func(d *datosPrueba) { d.Email = "ana@example.com" }},
+		{nombre: "email con nombre", modificar: func(d *datosPrueba) { d.Email = "Ana <ana@example.com>" }, campo: "email", regla: "email"},
+		{nombre: "email no válido", modificar: func(d *datosPrueba) { d.Email = "ana" }, campo: "email", regla: "email"},
+		{nombre: "phone válido", modificar: func(d *datosPrueba) { d.Telefono = "+54 (11) 5555-1234" }},
+		{nombre: "phone corto", modificar: func(d *datosPrueba) { d.Telefono = "12345" }, campo: "telefono", regla: "phone"},
+		{nombre: "phone con letras", modificar: func(d *datosPrueba) { d.Telefono = "555-CASA-12" }, campo: "telefono", regla: "phone"},
+		{nombre: "phone + en medio", modificar: func(d *datosPrueba) { d.Telefono = "5+55123456" }, campo: "telefono", regla: "phone"},
+		{nombre: "min número", modificar: func(d *datosPrueba) { d.Edad = 17 }, campo: "edad", regla: "min"},
+		{nombre: "max número", modificar: func(d *datosPrueba) { d.Edad = 100 }, campo: "edad", regla: "max"},
+		{nombre: "max sin signo", modificar: func(d *datosPrueba) { d.Cupo = 11 }, campo: "cupo", regla: "max"},
+		{nombre: "max lista", modificar: func(d *datosPrueba) { d.Etiquetas = []string{"a", "b", "c"} }, campo: "etiquetas", regla: "max"},
+		{nombre: "oneof válido", modificar: func(d *datosPrueba) { d.Estado = "activo" }},
+		{nombre: "oneof no válido", modificar: func(d *datosPrueba) { d.Estado = "borrado" }, campo: "estado", regla: "oneof"},
+		{nombre: "hhmm válido", modificar: func(d *datosPrueba) { d.Desde = "09:00" }},
+		{nombre: "hhmm un dígito", modificar: func(d *datosPrueba) { d.Desde = "9:00" }, campo: "desde", regla: "hhmm"},
+		{nombre: "hhmm fuera de rango", modificar: func(d *datosPrueba) { d.Desde = "24:00" }, campo: "desde", regla: "hhmm"},
+		{nombre: "gtfield horas", modificar: func(d *datosPrueba) { d.Desde, d.Hasta = "09:00", "17:00" }},
+		{nombre: "gtfield horas invertidas", modificar: func(d *datosPrueba) { d.Desde, d.Hasta = "17:00", "09:00" }, campo: "hasta", regla: "gtfield"},
+		{nombre: "gtfield horas iguales", modificar: func(d *datosPrueba) { d.Desde, d.Hasta = "09:00", "09:00" }, campo: "hasta", regla: "gtfield"},
+		{nombre: "gtfield otro campo vacío", modificar: func(d *datosPrueba) { d.Hasta = "09:00" }},
+		{nombre: "date válido", modificar: func(d *datosPrueba) { d.Fecha = "2026-02-28" }},
+		{nombre: "date no válido", modificar: func(d *datosPrueba) { d.Fecha = "2026-02-30" }, campo: "fecha", regla: "date"},
+		{nombre: "gtfield tiempo anterior", modificar: func(d *datosPrueba) { d.Fin = d.Inicio.Add(-time.Minute) }, campo: "fin", regla: "gtfield"},
+		{nombre: "gtfield tiempo igual", modificar: func(d *datosPrueba) { d.Fin = d.Inicio }, campo: "fin", regla: "gtfield"},
+		{nombre: "gtfield números", modificar: func(d *datosPrueba) { d.Minimo, d.Maximo = 5, 3 }, campo: "maximo", regla: "gtfield"},
+		{nombre: "lista anidada", modificar: func(d *datosPrueba) { d.Bloques = []bloque{{Tipo: "a"}, {}} }, campo: "bloques[1].tipo", regla: "required"},
+		{nombre: "puntero anidado", modificar: func(d *datosPrueba) { d.Anidado = &bloque{} }, campo: "anidado.tipo", regla: "required"},
+		{nombre: "campo sin json", modificar: func(d *datosPrueba) { d.SinJSON = "ab" }, campo: "SinJSON", regla: "max"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			datos := valido()
+			caso.modificar(&datos)
+
+			errores := Validar(&datos)
+			if caso.campo == "" {
+				if errores != nil {
+					t.Fatalf("se esperaba que fuera válido: %v", errores)
+				}
+				return
+			}
+
+			if len(errores) != 1 || errores[0].Campo != caso.campo || errores[0].Regla != caso.regla {
+				t.Fatalf("errores = %v, se esperaba %s en %s", errores, caso.regla, caso.campo)
+			}
+		})
+	}
+}
+
+func TestValidarParcial(t *testing.T) {
+	datos := valido()
+	datos.Nombre = ""
+	datos.Anidado = &bloque{}
+
+	if errores := ValidarParcial(&datos); errores != nil {
+		t.Errorf("ValidarParcial no debería aplicar required: %v", errores)
+	}
+
+	datos.Nombre = "Demasiado largo"
+	errores := ValidarParcial(&datos)
+	if len(errores) != 1 || errores[0].Regla != "max" {
+		t.Errorf("ValidarParcial debería aplicar las demás reglas: %v", errores)
+	}
+}
+
+func TestValidarTodosLosCampos(t *testing.T) {
+	datos := valido()
+	datos.Nombre = ""
+	datos.Edad = 5
+	datos.Estado = "x"
+
+	var campos []string
+	for _, errCampo := range Validar(datos) {
+		campos = append(campos, errCampo.Campo)
+	}
+
+	if esperado := []string{"nombre", "edad", "estado"}; !slices.Equal(campos, esperado) {
+		t.Errorf("campos = %v, se esperaba %v", campos, esperado)
+	}
+}
+
+func TestComprobar(t *testing.T) {
+	type reglaDesconocida struct {
+		Campo string `validate:"requird"`
+	}
+	type parametroNoValido struct {
+		Campo string `validate:"max=diez"`
+	}
+	type tipoNoAdmitido struct {
+		Campo int `validate:"email"`
+	}
+	type campoInexistente struct {
+		Fin time.Time `validate:"gtfield=Inicio"`
+	}
+	type tiposDistintos struct {
+		Inicio string
+		Fin    time.Time `validate:"gtfield=Inicio"`
+	}
+	type oneofVacio struct {
+		Campo string `validate:"oneof="`
+	}
+	type anidadoNoValido struct {
+		Bloques []reglaDesconocida
+	}
+
+	casos := []struct {
+		nombre string
+		valor  any
+		valido bool
+	}{
+		{nombre: "estructura válida", valor: datosPrueba{}, valido: true},
+		{nombre: "puntero a estructura", valor: &datosPrueba{}, valido: true},
+		{nombre: "regla desconocida", valor: reglaDesconocida{}},
+		{nombre: "parámetro no válido", valor: parametroNoValido{}},
+		{nombre: "tipo no admitido", valor: tipoNoAdmitido{}},
+		{nombre: "gtfield campo inexistente", valor: campoInexistente{}},
+		{nombre: "gtfield tipos distintos", valor: tiposDistintos{}},
+		{nombre: "oneof sin valores", valor: oneofVacio{}},
+		{nombre: "estructura anidada", valor: anidadoNoValido{}},
+		{nombre: "no es estructura", valor: "texto"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			err := Comprobar(caso.valor)
+			if caso.valido && err != nil {
+				t.Errorf("se esperaba que fuera válida: %v", err)
+			}
+			if !caso.valido && err == nil {
+				t.Error("se esperaba un error")
+			}
+		})
+	}
+}